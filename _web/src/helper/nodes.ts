@@ -3,6 +3,9 @@ import type { DrawflowNode } from "drawflow";
 type Endpoint = {
   methods: string[];
   public: boolean;
+  queue: boolean;
+  rate_limit?: { rate: number; burst: number };
+  signature?: Record<string, string>;
 };
 
 const getEndpoints = (exported: { [nodeKey: string]: DrawflowNode }) => {
@@ -13,7 +16,28 @@ const getEndpoints = (exported: { [nodeKey: string]: DrawflowNode }) => {
       values[v.data["endpoint"]] = {
         methods: (v.data["methods"] as string).replaceAll(" ", "").toUpperCase().split(","),
         public: v.data["public"],
+        queue: v.data["queue"] ?? false,
       };
+
+      // empty rate uses the default limit of the server
+      if ((v.data["rate"] ?? "") != "") {
+        values[v.data["endpoint"]].rate_limit = {
+          rate: Number(v.data["rate"]),
+          burst: Number(v.data["burst"] || 0),
+        };
+      }
+
+      // webhook signature, server skips the token check of verified calls
+      if ((v.data["signature_secret"] ?? "") != "") {
+        const signature = { secret: v.data["signature_secret"] } as Record<string, string>;
+        for (const key of ["preset", "header", "prefix", "timestamp_header", "payload", "tolerance"]) {
+          if ((v.data[`signature_${key}`] ?? "") != "") {
+            signature[key] = v.data[`signature_${key}`];
+          }
+        }
+
+        values[v.data["endpoint"]].signature = signature;
+      }
     }
   }
 
@@ -1,4 +1,6 @@
 import { endpoint } from "./nodes/endpoint";
+import { schedule } from "./nodes/schedule";
+import { onError } from "./nodes/onError";
 import { template } from "./nodes/template";
 import { request } from "./nodes/request";
 import { script } from "./nodes/script";
@@ -25,6 +27,8 @@ export type node = {
 
 export const nodes = {
   endpoint,
+  schedule,
+  onError,
   template,
   request,
   script,
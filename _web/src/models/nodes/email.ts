@@ -6,6 +6,7 @@ export type emailData = {
   cc: string
   bcc: string
   subject: string
+  attach_files: boolean
   tags: string
 };
 
@@ -22,6 +23,7 @@ export const email: node = {
     cc: "",
     bcc: "",
     subject: "",
+    attach_files: false,
     tags: "",
   },
   input: 2,
@@ -4,6 +4,16 @@ export type endpointData = {
   endpoint: string
   methods: string
   public: boolean
+  queue: boolean
+  rate: string
+  burst: string
+  signature_preset: string
+  signature_secret: string
+  signature_header: string
+  signature_prefix: string
+  signature_timestamp_header: string
+  signature_payload: string
+  signature_tolerance: string
   tags: string
 };
 
@@ -21,6 +31,16 @@ export const endpoint: node = {
     endpoint: "",
     methods: "POST",
     public: false,
+    queue: false,
+    rate: "",
+    burst: "",
+    signature_preset: "",
+    signature_secret: "",
+    signature_header: "",
+    signature_prefix: "",
+    signature_timestamp_header: "",
+    signature_payload: "",
+    signature_tolerance: "",
     tags: "",
   } as endpointData,
   input: 0,
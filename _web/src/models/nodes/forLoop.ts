@@ -2,6 +2,7 @@ import type { node } from "@/models/node";
 
 export type forLoopData = {
   for: string
+  parallel: string
   tags: string
 };
 
@@ -18,6 +19,7 @@ export const forLoop: node = {
   `,
   data: {
     for: "data",
+    parallel: "",
     tags: "",
   } as forLoopData,
   input: 1,
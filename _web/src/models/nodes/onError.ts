@@ -0,0 +1,20 @@
+import type { node } from "@/models/node";
+
+export type onErrorData = {
+  tags: string
+};
+
+export const onError: node = {
+  name: "onError",
+  html: `
+  <div>
+    <div class="title-box">On Error</div>
+  </div>
+  `,
+  data: {
+    tags: "",
+  } as onErrorData,
+  input: 0,
+  output: 1,
+  class: "node-on-error title-box-alone",
+};
@@ -6,7 +6,21 @@ export type requestData = {
   payload_nil: boolean,
   retry_disabled: boolean,
   oauth2: string,
+  tls: string,
+  signer: string,
   proxy: string,
+  upload_field: string,
+  body_mode: string,
+  pagination: string,
+  pagination_param: string,
+  pagination_cursor: string,
+  pagination_items: string,
+  pagination_start: string,
+  pagination_size: string,
+  pagination_max: string,
+  pagination_each: boolean,
+  max_response_size: string,
+  spool_size: string,
   url: string,
   method: string,
   auth: string,
@@ -30,6 +44,10 @@ export const request: node = {
     info: "",
     skip_verify: false,
     payload_nil: false,
+    upload_field: "",
+    body_mode: "",
+    pagination: "",
+    pagination_each: false,
     url: "",
     method: "",
     auth: "",
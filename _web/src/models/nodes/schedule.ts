@@ -0,0 +1,29 @@
+import type { node } from "@/models/node";
+
+export type scheduleData = {
+  cron: string
+  timezone: string
+  payload: string
+  tags: string
+};
+
+export const schedule: node = {
+  name: "schedule",
+  html: `
+  <div>
+    <div class="title-box">Schedule</div>
+    <div class="box">
+      <input type="text" placeholder="0 * * * *" name="cron" readonly disabled df-cron>
+    </div>
+  </div>
+  `,
+  data: {
+    cron: "",
+    timezone: "",
+    payload: "",
+    tags: "",
+  } as scheduleData,
+  input: 0,
+  output: 1,
+  class: "node-schedule",
+};
@@ -0,0 +1,214 @@
+package args
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/google/uuid"
+	"github.com/rs/zerolog/log"
+	"github.com/rytsh/mugo/pkg/fstore"
+	"github.com/rytsh/mugo/pkg/templatex"
+	"github.com/worldline-go/logz"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"github.com/rakunlabs/chore/internal/config"
+	"github.com/rakunlabs/chore/internal/store"
+	"github.com/rakunlabs/chore/internal/utils"
+	"github.com/rakunlabs/chore/pkg/models"
+	"github.com/rakunlabs/chore/pkg/models/apimodels"
+	"github.com/rakunlabs/chore/pkg/registry"
+	"github.com/rakunlabs/chore/pkg/request"
+)
+
+// TemplateExt is removed from template file names, same as data/record.sh.
+var TemplateExt = ".tmpl"
+
+// localDirs hold folders to load in local store, same layout with data/record.sh.
+type localDirs struct {
+	Templates string
+	Auths     string
+	Controls  string
+}
+
+// openLocalStore opens temporary sqlite database and loads local files to run controls without server.
+func openLocalStore(ctx context.Context, wg *sync.WaitGroup, dirs localDirs) (*registry.Registry, func(), error) {
+	tmpDir, err := os.MkdirTemp("", "chore-")
+	if err != nil {
+		return nil, nil, fmt.Errorf("cannot create temp dir: %w", err)
+	}
+
+	dbConn, err := store.OpenConnection("sqlite", map[string]interface{}{
+		"fileName": filepath.Join(tmpDir, "chore.db"),
+	})
+	if err != nil {
+		os.RemoveAll(tmpDir)
+
+		return nil, nil, err //nolint:wrapcheck // no need
+	}
+
+	closeFn := func() {
+		if db, err := dbConn.DB(); err == nil {
+			db.Close()
+		}
+
+		os.RemoveAll(tmpDir)
+	}
+
+	if err := loadLocal(ctx, dbConn, dirs); err != nil {
+		closeFn()
+
+		return nil, nil, err
+	}
+
+	reg := &registry.Registry{
+		DB: dbConn,
+		Template: templatex.New(templatex.WithAddFuncsTpl(
+			fstore.FuncMapTpl(
+				fstore.WithLog(logz.AdapterKV{Log: log.With().Str("component", "template").Logger()}),
+				fstore.WithTrust(config.Application.Template.Trust),
+			),
+		)),
+		WG: wg,
+	}
+
+	if request.GlobalRegistry == nil {
+		request.InitGlobalRegistry(ctx).Start(wg)
+	}
+
+	return reg, closeFn, nil
+}
+
+func loadLocal(ctx context.Context, dbConn *gorm.DB, dirs localDirs) error {
+	if err := dbConn.WithContext(ctx).AutoMigrate(store.Models...); err != nil {
+		return fmt.Errorf("auto migrate failed: %w", err)
+	}
+
+	db := dbConn.WithContext(ctx)
+
+	if err := walkFiles(dirs.Templates, "", func(name string, content []byte) error {
+		name = strings.TrimSuffix(name, TemplateExt)
+
+		template := models.Template{
+			TemplatePure: models.TemplatePure{
+				Name:    name,
+				Content: base64.StdEncoding.EncodeToString(content),
+			},
+			ModelCU: apimodels.ModelCU{ID: apimodels.ID{ID: uuid.New()}},
+		}
+
+		if result := db.Create(&template); result.Error != nil {
+			return result.Error
+		}
+
+		return db.Clauses(clause.OnConflict{DoNothing: true}).Create(utils.FolderFile(name)).Error
+	}); err != nil {
+		return fmt.Errorf("cannot load templates: %w", err)
+	}
+
+	if err := walkFiles(dirs.Auths, ".json", func(name string, content []byte) error {
+		auth := models.Auth{ModelCU: apimodels.ModelCU{ID: apimodels.ID{ID: uuid.New()}}}
+		if err := json.Unmarshal(content, &auth.AuthPure); err != nil {
+			return fmt.Errorf("%s: %w", name, err)
+		}
+
+		if auth.Name == "" {
+			auth.Name = strings.TrimSuffix(name, ".json")
+		}
+
+		return db.Create(&auth).Error
+	}); err != nil {
+		return fmt.Errorf("cannot load auths: %w", err)
+	}
+
+	if err := walkFiles(dirs.Controls, ".json", func(name string, content []byte) error {
+		control := models.Control{
+			ControlPureContent: readControl(content, strings.TrimSuffix(name, ".json")),
+			ModelCU:            apimodels.ModelCU{ID: apimodels.ID{ID: uuid.New()}},
+		}
+
+		return db.Create(&control).Error
+	}); err != nil {
+		return fmt.Errorf("cannot load controls: %w", err)
+	}
+
+	return nil
+}
+
+// walkFiles calls fn with slash separated relative names of the files, empty dir skipped.
+func walkFiles(dir, ext string, fn func(name string, content []byte) error) error {
+	if dir == "" {
+		return nil
+	}
+
+	return filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error { //nolint:wrapcheck // no need
+		if err != nil {
+			return err
+		}
+
+		// skip hidden files and folders
+		if strings.HasPrefix(d.Name(), ".") && path != dir {
+			if d.IsDir() {
+				return filepath.SkipDir
+			}
+
+			return nil
+		}
+
+		if d.IsDir() || (ext != "" && filepath.Ext(path) != ext) {
+			return nil
+		}
+
+		rel, err := filepath.Rel(dir, path)
+		if err != nil {
+			return err
+		}
+
+		content, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+
+		return fn(filepath.ToSlash(rel), content)
+	})
+}
+
+// readControl accepts dump of the control from API or directly exported flow data.
+func readControl(raw []byte, name string) models.ControlPureContent {
+	dump := models.ControlPureContent{}
+	if err := json.Unmarshal(raw, &dump); err == nil && dump.Content != "" {
+		if _, err := base64.StdEncoding.DecodeString(dump.Content); err == nil {
+			if dump.Name == "" {
+				dump.Name = name
+			}
+
+			return dump
+		}
+	}
+
+	return models.ControlPureContent{
+		Content:     base64.StdEncoding.EncodeToString(raw),
+		ControlPure: models.ControlPure{Name: name},
+	}
+}
+
+// readFileOrStdin reads the file, "-" reads from stdin.
+func readFileOrStdin(path string) ([]byte, error) {
+	if path == "" {
+		return nil, nil
+	}
+
+	if path == "-" {
+		return io.ReadAll(os.Stdin) //nolint:wrapcheck // no need
+	}
+
+	return os.ReadFile(path) //nolint:wrapcheck // no need
+}
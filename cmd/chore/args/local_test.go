@@ -0,0 +1,211 @@
+package args
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"sync"
+	"testing"
+
+	"github.com/rakunlabs/chore/internal/store"
+	"github.com/rakunlabs/chore/pkg/flow"
+	"github.com/rakunlabs/chore/pkg/models"
+)
+
+// writeFiles creates the files under dir, names are slash separated.
+func writeFiles(t *testing.T, dir string, files map[string]string) {
+	t.Helper()
+
+	for name, content := range files {
+		path := filepath.Join(dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+
+		if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestReadControl(t *testing.T) {
+	flowData := `{"1": {"name": "endpoint", "data": {"endpoint": "test"}}}`
+	content := base64.StdEncoding.EncodeToString([]byte(flowData))
+
+	tests := []struct {
+		name string
+		raw  string
+		want models.ControlPureContent
+	}{
+		{
+			name: "flow data",
+			raw:  flowData,
+			want: models.ControlPureContent{Content: content, ControlPure: models.ControlPure{Name: "file"}},
+		},
+		{
+			name: "dump",
+			raw:  `{"name": "dumped", "content": "` + content + `"}`,
+			want: models.ControlPureContent{Content: content, ControlPure: models.ControlPure{Name: "dumped"}},
+		},
+		{
+			name: "dump without name",
+			raw:  `{"content": "` + content + `"}`,
+			want: models.ControlPureContent{Content: content, ControlPure: models.ControlPure{Name: "file"}},
+		},
+		{
+			name: "content not base64",
+			raw:  `{"content": "not base64"}`,
+			want: models.ControlPureContent{
+				Content:     base64.StdEncoding.EncodeToString([]byte(`{"content": "not base64"}`)),
+				ControlPure: models.ControlPure{Name: "file"},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := readControl([]byte(tt.raw), "file"); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("readControl() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLoadLocal(t *testing.T) {
+	root := t.TempDir()
+
+	dirs := localDirs{
+		Templates: filepath.Join(root, "templates"),
+		Auths:     filepath.Join(root, "auths"),
+		Controls:  filepath.Join(root, "controls"),
+	}
+
+	writeFiles(t, dirs.Templates, map[string]string{
+		"hello.tmpl":          "hello {{.name}}",
+		"mail/welcome.tmpl":   "welcome",
+		".git/config":         "hidden",
+		"mail/.draft.tmpl":    "hidden",
+		"mail/plain-text.txt": "plain",
+	})
+
+	writeFiles(t, dirs.Auths, map[string]string{
+		"basic.json":   `{"headers": {"Authorization": "Basic abc"}}`,
+		"named.json":   `{"name": "other"}`,
+		"readme.md":    "not an auth",
+		".hidden.json": `{"name": "hidden"}`,
+	})
+
+	writeFiles(t, dirs.Controls, map[string]string{
+		"flow.json":      `{"1": {"name": "endpoint", "data": {"endpoint": "test"}}}`,
+		"team/dump.json": `{"name": "dumped", "content": "e30="}`,
+	})
+
+	dbConn, err := store.OpenConnection("sqlite", map[string]interface{}{
+		"fileName": filepath.Join(root, "chore.db"),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := loadLocal(context.Background(), dbConn, dirs); err != nil {
+		t.Fatalf("loadLocal() error = %v", err)
+	}
+
+	names := func(model interface{}, column string) []string {
+		var got []string
+		if err := dbConn.Model(model).Order(column).Pluck(column, &got).Error; err != nil {
+			t.Fatal(err)
+		}
+
+		return got
+	}
+
+	tests := []struct {
+		name  string
+		model interface{}
+		want  []string
+	}{
+		{name: "templates", model: &models.Template{}, want: []string{"hello", "mail/plain-text.txt", "mail/welcome"}},
+		{name: "folders", model: &models.Folder{}, want: []string{"hello", "mail/", "mail/plain-text.txt", "mail/welcome"}},
+		{name: "auths", model: &models.Auth{}, want: []string{"basic", "other"}},
+		{name: "controls", model: &models.Control{}, want: []string{"dumped", "flow"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := names(tt.model, "name")
+			sort.Strings(got)
+
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("%s = %v, want %v", tt.name, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRunLocal(t *testing.T) {
+	root := t.TempDir()
+
+	writeFiles(t, root, map[string]string{
+		"try.json": `{
+			"1": {"name": "endpoint", "data": {"endpoint": "test", "methods": "POST"}, "outputs": {"output_1": {"connections": [{"node": "2", "output": "input_1"}]}}},
+			"2": {"name": "template", "data": {"template": "greet"}, "inputs": {"input_1": {"connections": [{"node": "1"}]}}, "outputs": {"output_1": {"connections": [{"node": "3", "output": "input_1"}]}}},
+			"3": {"name": "respond", "data": {}, "inputs": {"input_1": {"connections": [{"node": "2"}]}}}
+		}`,
+		"values.yml":           "name: deepcore",
+		"templates/greet.tmpl": "hello {{.name}}",
+	})
+
+	tests := []struct {
+		name     string
+		endpoint string
+		want     string
+		wantErr  bool
+	}{
+		{name: "respond", endpoint: "test", want: "hello deepcore"},
+		{name: "unknown endpoint", endpoint: "missing", wantErr: true},
+	}
+
+	prevFlags := localFlags
+	prevHistory := flow.HistoryDisabled
+
+	t.Cleanup(func() {
+		localFlags = prevFlags
+		flow.HistoryDisabled = prevHistory
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	wg := &sync.WaitGroup{}
+
+	t.Cleanup(func() {
+		cancel()
+		wg.Wait()
+	})
+
+	ctx = context.WithValue(ctx, ctxKeyWg, wg)
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			localFlags.Control = filepath.Join(root, "try.json")
+			localFlags.Endpoint = tt.endpoint
+			localFlags.Method = "post"
+			localFlags.Input = filepath.Join(root, "values.yml")
+			localFlags.Dirs = localDirs{Templates: filepath.Join(root, "templates")}
+
+			w := &bytes.Buffer{}
+
+			err := runLocal(ctx, w)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("runLocal() error = %v, wantErr %v", err, tt.wantErr)
+			}
+
+			if got := w.String(); got != tt.want {
+				t.Errorf("runLocal() output = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
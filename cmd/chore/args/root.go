@@ -6,9 +6,14 @@ import (
 	"strings"
 	"sync"
 
+	"github.com/rakunlabs/chore/internal/api"
 	"github.com/rakunlabs/chore/internal/config"
 	"github.com/rakunlabs/chore/internal/server"
+	"github.com/rakunlabs/chore/internal/server/middlewares"
 	"github.com/rakunlabs/chore/internal/store"
+	"github.com/rakunlabs/chore/pkg/flow"
+	"github.com/rakunlabs/chore/pkg/models"
+	"github.com/rakunlabs/chore/pkg/request"
 	"github.com/worldline-go/initializer"
 	"github.com/worldline-go/tell"
 
@@ -163,6 +168,7 @@ func runRoot(ctx context.Context) error {
 		"schema":   config.Application.Store.Schema,
 		"timeZone": config.Application.Store.TimeZone,
 		"dsn":      config.Application.Store.DBDataSource,
+		"fileName": config.Application.Store.FileName,
 	})
 	if err != nil {
 		return fmt.Errorf("cannot open db: %w", err)
@@ -191,6 +197,24 @@ func runRoot(ctx context.Context) error {
 		return fmt.Errorf("auto migration: %w", err)
 	}
 
+	// run history settings
+	flow.HistoryDisabled = config.Application.History.Disabled
+	flow.HistoryPayloadLimit = config.Application.History.PayloadLimit
+	flow.ResultLimit = config.Application.History.ResultLimit
+
+	// concurrency limits
+	flow.RunLimits.Set(config.Application.Limit.Runs, config.Application.Limit.ControlRuns, config.Application.Limit.Controls)
+	flow.MaxParallel = config.Application.Limit.ForParallel
+	request.DefaultMaxResponseSize = config.Application.Limit.ResponseSize
+	request.DefaultSpoolSize = config.Application.Limit.ResponseSpool
+	api.SendBodyLimit = config.Application.Limit.BodySize
+	flow.UploadSpoolSize = config.Application.Limit.BodySpool
+
+	middlewares.EndpointRateLimit = models.RateLimit(config.Application.RateLimit.Endpoint)
+	middlewares.TokenRateLimit = models.RateLimit(config.Application.RateLimit.Token)
+
+	api.CallbackHosts = config.Application.Callback.Hosts
+
 	// server wait
 	e, err := server.Set(ctx, wg, dbConn)
 	if err != nil {
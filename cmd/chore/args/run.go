@@ -0,0 +1,296 @@
+package args
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/spf13/cobra"
+
+	"github.com/rakunlabs/chore/pkg/flow"
+	"github.com/rakunlabs/chore/pkg/models"
+	"github.com/rakunlabs/chore/pkg/registry"
+)
+
+var errValidation = errors.New("validation failed")
+
+var localFlags = struct {
+	Control  string
+	Endpoint string
+	Method   string
+	Input    string
+	Headers  []string
+	Query    string
+	Files    []string
+	Dirs     localDirs
+}{
+	Method: "POST",
+}
+
+var runCmd = &cobra.Command{
+	Use:   "run",
+	Short: "run control file locally without server",
+	Example: "chore run --control controls/try.json --endpoint test --input values.yml --templates templates/\n" +
+		"echo 'name: deepcore' | chore run --control try.json --endpoint test --input -",
+	RunE: func(cmd *cobra.Command, _ []string) error {
+		return runLocal(cmd.Context(), cmd.OutOrStdout())
+	},
+}
+
+var validateCmd = &cobra.Command{
+	Use:     "validate",
+	Short:   "validate control file with fetching templates and auths from local folders",
+	Example: "chore validate --control controls/try.json --templates templates/ --auths auths/",
+	RunE: func(cmd *cobra.Command, _ []string) error {
+		return validateLocal(cmd.Context(), cmd.OutOrStdout())
+	},
+}
+
+//nolint:gochecknoinits // cobra init
+func init() {
+	for _, cmd := range []*cobra.Command{runCmd, validateCmd} {
+		cmd.Flags().StringVarP(&localFlags.Control, "control", "c", localFlags.Control, "control file, flow data or dump of the control")
+		cmd.Flags().StringVar(&localFlags.Dirs.Templates, "templates", localFlags.Dirs.Templates, "templates folder, file path is the template name")
+		cmd.Flags().StringVar(&localFlags.Dirs.Auths, "auths", localFlags.Dirs.Auths, "auths folder with json dump files")
+		cmd.Flags().StringVar(&localFlags.Dirs.Controls, "controls", localFlags.Dirs.Controls, "controls folder for calling other controls")
+
+		_ = cmd.MarkFlagRequired("control")
+
+		rootCmd.AddCommand(cmd)
+	}
+
+	runCmd.Flags().StringVarP(&localFlags.Endpoint, "endpoint", "e", localFlags.Endpoint, "endpoint to start")
+	runCmd.Flags().StringVarP(&localFlags.Method, "method", "m", localFlags.Method, "method of the endpoint")
+	runCmd.Flags().StringVarP(&localFlags.Input, "input", "i", localFlags.Input, "input payload file, - for stdin")
+	runCmd.Flags().StringArrayVarP(&localFlags.Headers, "header", "H", localFlags.Headers, "request header of the call like 'X-GitHub-Event: push'")
+	runCmd.Flags().StringVarP(&localFlags.Query, "query", "q", localFlags.Query, "query parameters of the call like 'filter=open&page=2'")
+	runCmd.Flags().StringArrayVarP(&localFlags.Files, "file", "f", localFlags.Files, "uploaded file of the call like 'report=report.pdf'")
+
+	_ = runCmd.MarkFlagRequired("endpoint")
+
+	validateCmd.Flags().StringVarP(&localFlags.Endpoint, "endpoint", "e", localFlags.Endpoint, "validate only this endpoint")
+}
+
+// localControl reads the control file and opens local store.
+func localControl(ctx context.Context) (string, []byte, *registry.Registry, func(), error) {
+	raw, err := readFileOrStdin(localFlags.Control)
+	if err != nil {
+		return "", nil, nil, nil, fmt.Errorf("cannot read control: %w", err)
+	}
+
+	control := readControl(raw, strings.TrimSuffix(filepath.Base(localFlags.Control), ".json"))
+
+	content, err := base64.StdEncoding.DecodeString(control.Content)
+	if err != nil {
+		return "", nil, nil, nil, fmt.Errorf("cannot decode control content: %w", err)
+	}
+
+	wg, _ := ctx.Value(ctxKeyWg).(*sync.WaitGroup)
+	if wg == nil {
+		return "", nil, nil, nil, fmt.Errorf("wg not found in context")
+	}
+
+	reg, closeFn, err := openLocalStore(ctx, wg, localFlags.Dirs)
+	if err != nil {
+		return "", nil, nil, nil, err
+	}
+
+	return control.Name, content, reg, closeFn, nil
+}
+
+func runLocal(ctx context.Context, w io.Writer) error {
+	input, err := readFileOrStdin(localFlags.Input)
+	if err != nil {
+		return fmt.Errorf("cannot read input: %w", err)
+	}
+
+	name, content, reg, closeFn, err := localControl(ctx)
+	if err != nil {
+		return err
+	}
+
+	defer closeFn()
+
+	// nothing to record in temporary store
+	flow.HistoryDisabled = true
+
+	header := http.Header{}
+	for _, h := range localFlags.Headers {
+		key, value, _ := strings.Cut(h, ":")
+		header.Add(strings.TrimSpace(key), strings.TrimSpace(value))
+	}
+
+	request := flow.NewRequest(strings.ToUpper(localFlags.Method), &url.URL{Path: "/send", RawQuery: localFlags.Query}, header)
+	request.Control = name
+	request.Endpoint = localFlags.Endpoint
+
+	for _, f := range localFlags.Files {
+		field, path, ok := strings.Cut(f, "=")
+		if !ok {
+			return fmt.Errorf("file %q should be field=path", f)
+		}
+
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return fmt.Errorf("cannot read file: %w", err)
+		}
+
+		request.Files = append(request.Files, flow.File{
+			Field:       field,
+			Name:        filepath.Base(path),
+			ContentType: mime.TypeByExtension(filepath.Ext(path)),
+			Data:        data,
+		})
+	}
+
+	ctx = context.WithValue(ctx, flow.CtxRequest, request)
+
+	wg := &sync.WaitGroup{}
+
+	nodesReg, err := flow.StartFlow(ctx, wg, name, localFlags.Endpoint, strings.ToUpper(localFlags.Method), content, reg, models.Access{All: true}, input)
+	if err != nil {
+		return err //nolint:wrapcheck // no need
+	}
+
+	var respondErr error
+
+	if respondChan := nodesReg.GetChan(); respondChan != nil {
+		respond := <-respondChan
+		if err := writeRespond(w, respond); err != nil {
+			return err
+		}
+
+		if respond.IsError {
+			respondErr = fmt.Errorf("respond with error")
+		}
+	}
+
+	wg.Wait()
+
+	if err := nodesReg.Err(); err != nil {
+		return err
+	}
+
+	return respondErr
+}
+
+func validateLocal(ctx context.Context, w io.Writer) error {
+	name, content, reg, closeFn, err := localControl(ctx)
+	if err != nil {
+		return err
+	}
+
+	defer closeFn()
+
+	// static checks
+	validation := flow.Validate(ctx, content, reg, models.Access{All: true})
+
+	for _, issue := range validation.Errors {
+		fmt.Fprintf(w, "error: %s\n", issue)
+	}
+
+	for _, issue := range validation.Warnings {
+		fmt.Fprintf(w, "warning: %s\n", issue)
+	}
+
+	if validation.HasError() {
+		return errValidation
+	}
+
+	nodesData, err := flow.ParseData(content)
+	if err != nil {
+		return err //nolint:wrapcheck // no need
+	}
+
+	// collect endpoints
+	reference, err := flow.DataToNode(ctx, name, "", "", nodesData, reg, models.Access{All: true})
+	if err != nil {
+		return err //nolint:wrapcheck // no need
+	}
+
+	type endpointMethod struct {
+		endpoint string
+		method   string
+	}
+
+	endpoints := []endpointMethod{}
+
+	for nodeID := range nodesData {
+		node, _ := reference.Get(nodeID)
+
+		nodeEndpoint, ok := node.(flow.NoderEndpoint)
+		if !ok {
+			continue
+		}
+
+		if localFlags.Endpoint != "" && nodeEndpoint.Endpoint() != localFlags.Endpoint {
+			continue
+		}
+
+		for _, method := range nodeEndpoint.Methods() {
+			endpoints = append(endpoints, endpointMethod{
+				endpoint: nodeEndpoint.Endpoint(),
+				method:   strings.ToUpper(strings.TrimSpace(method)),
+			})
+		}
+	}
+
+	sort.Slice(endpoints, func(i, j int) bool {
+		if endpoints[i].endpoint == endpoints[j].endpoint {
+			return endpoints[i].method < endpoints[j].method
+		}
+
+		return endpoints[i].endpoint < endpoints[j].endpoint
+	})
+
+	failed := false
+
+	// fetch records for each endpoint like in the call
+	for _, e := range endpoints {
+		nodesReg, err := flow.DataToNode(ctx, name, e.endpoint, e.method, nodesData, reg, models.Access{All: true})
+		if err == nil {
+			err = flow.VisitAndFetch(ctx, nodesReg)
+		}
+
+		if err != nil {
+			failed = true
+
+			fmt.Fprintf(w, "%s [%s]: %v\n", e.endpoint, e.method, err)
+
+			continue
+		}
+
+		fmt.Fprintf(w, "%s [%s]: ok\n", e.endpoint, e.method)
+	}
+
+	if failed {
+		return errValidation
+	}
+
+	return nil
+}
+
+// writeRespond writes the respond body, spooled body is streamed from the file.
+func writeRespond(w io.Writer, respond flow.Respond) error {
+	if respond.Reader == nil {
+		_, err := w.Write(respond.Data)
+
+		return err //nolint:wrapcheck // no need
+	}
+
+	defer respond.Reader.Close()
+
+	_, err := io.Copy(w, respond.Reader)
+
+	return err //nolint:wrapcheck // no need
+}
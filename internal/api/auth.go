@@ -43,7 +43,9 @@ func listAuths(c echo.Context) error {
 		return c.JSON(http.StatusBadRequest, apimodels.Error{Error: err.Error()})
 	}
 
-	query := registry.Reg.DB.WithContext(c.Request().Context()).Model(&models.Auth{}).Limit(meta.Limit).Offset(meta.Offset)
+	access := middlewares.GetAccess(c)
+
+	query := registry.Reg.DB.WithContext(c.Request().Context()).Model(&models.Auth{}).Scopes(access.Scope(false)).Limit(meta.Limit).Offset(meta.Offset)
 
 	if meta.Search != "" {
 		query = query.Where("name LIKE ?", meta.Search+"%")
@@ -57,7 +59,7 @@ func listAuths(c echo.Context) error {
 	}
 
 	// get counts
-	query = registry.Reg.DB.WithContext(c.Request().Context()).Model(&models.Auth{})
+	query = registry.Reg.DB.WithContext(c.Request().Context()).Model(&models.Auth{}).Scopes(access.Scope(false))
 	if meta.Search != "" {
 		query = query.Where("name LIKE ?", meta.Search+"%")
 	}
@@ -105,7 +107,7 @@ func getAuth(c echo.Context) error {
 
 	getData := new(AuthPureID)
 
-	query := registry.Reg.DB.WithContext(c.Request().Context()).Model(&models.Auth{})
+	query := registry.Reg.DB.WithContext(c.Request().Context()).Model(&models.Auth{}).Scopes(middlewares.GetAccess(c).Scope(false))
 	if id != "" {
 		query = query.Where("id = ?", id)
 	}
@@ -147,6 +149,7 @@ func getAuth(c echo.Context) error {
 // @Param payload body AuthPureID{} false "send auth object"
 // @Success 204 "No Content"
 // @failure 400 {object} apimodels.Error{}
+// @failure 403 {object} apimodels.Error{}
 // @failure 500 {object} apimodels.Error{}
 func putAuth(c echo.Context) error {
 	var body AuthPureID
@@ -168,7 +171,25 @@ func putAuth(c echo.Context) error {
 	}
 
 	ctx := utils.Context(c)
-	result := registry.Reg.DB.WithContext(ctx).Model(&models.Auth{}).Clauses(
+
+	// check access of the existing record
+	prev := models.Auth{}
+	result := registry.Reg.DB.WithContext(ctx).Model(&models.Auth{}).Select("groups").Where("id = ?", id).Limit(1).Find(&prev)
+	if result.Error != nil {
+		return c.JSON(http.StatusInternalServerError, apimodels.Error{Error: result.Error.Error()})
+	}
+
+	access := middlewares.GetAccess(c)
+
+	if result.RowsAffected > 0 && !access.Can(prev.Groups.Groups, true) {
+		return c.JSON(http.StatusForbidden, apimodels.Error{Error: apimodels.ErrForbidden.Error()})
+	}
+
+	if !access.CanAssign(body.Groups.Groups, prev.Groups.Groups) {
+		return c.JSON(http.StatusForbidden, apimodels.Error{Error: apimodels.ErrGroupAssign.Error()})
+	}
+
+	result = registry.Reg.DB.WithContext(ctx).Model(&models.Auth{}).Clauses(
 		clause.OnConflict{
 			UpdateAll: true,
 			Columns:   []clause.Column{{Name: "id"}},
@@ -209,6 +230,10 @@ func postAuth(c echo.Context) error {
 		return c.JSON(http.StatusBadRequest, apimodels.Error{Error: apimodels.ErrRequiredName.Error()})
 	}
 
+	if !middlewares.GetAccess(c).CanAssign(body.Groups.Groups, nil) {
+		return c.JSON(http.StatusForbidden, apimodels.Error{Error: apimodels.ErrGroupAssign.Error()})
+	}
+
 	id, err := uuid.NewUUID()
 	if err != nil {
 		return c.JSON(http.StatusInternalServerError, apimodels.Error{Error: err.Error()})
@@ -262,17 +287,30 @@ func patchAuth(c echo.Context) error {
 		return c.JSON(http.StatusBadRequest, apimodels.Error{Error: "id is required and cannot be empty"})
 	}
 
-	if body["groups"] != nil {
-		var err error
+	ctx := utils.Context(c)
+	access := middlewares.GetAccess(c)
 
-		body["groups"], err = json.Marshal(body["groups"])
+	if body["groups"] != nil {
+		groups, err := json.Marshal(body["groups"])
 		if err != nil {
 			return c.JSON(http.StatusInternalServerError, apimodels.Error{Error: err.Error()})
 		}
+
+		prev := models.Auth{}
+		result := registry.Reg.DB.WithContext(ctx).Model(&models.Auth{}).Select("groups").Where("id = ?", body["id"]).
+			Scopes(access.Scope(true)).Limit(1).Find(&prev)
+		if result.Error != nil {
+			return c.JSON(http.StatusInternalServerError, apimodels.Error{Error: result.Error.Error()})
+		}
+
+		if !access.CanAssign(groups, prev.Groups.Groups) {
+			return c.JSON(http.StatusForbidden, apimodels.Error{Error: apimodels.ErrGroupAssign.Error()})
+		}
+
+		body["groups"] = groups
 	}
 
-	ctx := utils.Context(c)
-	query := registry.Reg.DB.WithContext(ctx).Model(&models.Auth{}).Where("id = ?", body["id"])
+	query := registry.Reg.DB.WithContext(ctx).Model(&models.Auth{}).Where("id = ?", body["id"]).Scopes(access.Scope(true))
 
 	result := query.Updates(body)
 
@@ -285,6 +323,10 @@ func patchAuth(c echo.Context) error {
 		return c.JSON(http.StatusInternalServerError, apimodels.Error{Error: result.Error.Error()})
 	}
 
+	if result.RowsAffected == 0 {
+		return c.JSON(http.StatusNotFound, apimodels.Error{Error: apimodels.ErrNotFound.Error()})
+	}
+
 	resultData := make(map[string]interface{})
 	resultData["id"] = body["id"]
 
@@ -314,7 +356,7 @@ func deleteAuth(c echo.Context) error {
 	}
 
 	ctx := utils.Context(c)
-	query := registry.Reg.DB.WithContext(ctx).Where("id = ?", id)
+	query := registry.Reg.DB.WithContext(ctx).Where("id = ?", id).Scopes(middlewares.GetAccess(c).Scope(true))
 
 	// delete directly in DB
 	result := query.Unscoped().Delete(&models.Auth{})
@@ -0,0 +1,543 @@
+package api
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"github.com/rakunlabs/chore/internal/server/middlewares"
+	"github.com/rakunlabs/chore/internal/utils"
+	"github.com/rakunlabs/chore/pkg/flow"
+	"github.com/rakunlabs/chore/pkg/models"
+	"github.com/rakunlabs/chore/pkg/models/apimodels"
+	"github.com/rakunlabs/chore/pkg/registry"
+)
+
+// Conflict modes of the import.
+var (
+	ImportSkip      = "skip"
+	ImportOverwrite = "overwrite"
+	ImportRename    = "rename"
+)
+
+// renameTry is max try to find a free name in rename mode.
+var renameTry = 100
+
+var (
+	errInvalidMode      = errors.New("mode must be one of skip, overwrite, rename")
+	errImportValidation = errors.New("imported controls are not valid")
+)
+
+type ImportResult struct {
+	Created     []string          `json:"created,omitempty"`
+	Overwritten []string          `json:"overwritten,omitempty"`
+	Renamed     map[string]string `json:"renamed,omitempty"`
+	Skipped     []string          `json:"skipped,omitempty"`
+}
+
+type ImportReport struct {
+	Controls  ImportResult `json:"controls"`
+	Templates ImportResult `json:"templates"`
+	Auths     ImportResult `json:"auths"`
+	Settings  ImportResult `json:"settings"`
+	// Validations of the imported controls having errors or warnings.
+	Validations map[string]flow.Validation `json:"validations,omitempty"`
+}
+
+// ImportValidationError returns when imported controls have errors, nothing is imported.
+type ImportValidationError struct {
+	apimodels.Error
+	Validations map[string]flow.Validation `json:"validations"`
+}
+
+// exportQuery selects names from comma separated list, names ending with "/" selects prefix.
+func exportQuery(query *gorm.DB, column, list string, all bool) *gorm.DB {
+	if all {
+		return query
+	}
+
+	names := []string{}
+	prefixes := []string{}
+
+	for _, v := range strings.Split(list, ",") {
+		v = strings.TrimSpace(v)
+		if v == "" {
+			continue
+		}
+
+		if strings.HasSuffix(v, "/") {
+			prefixes = append(prefixes, v)
+
+			continue
+		}
+
+		names = append(names, v)
+	}
+
+	cond := registry.Reg.DB.Where(column+" IN ?", append(names, ""))
+	for _, p := range prefixes {
+		cond = cond.Or(column+" LIKE ?", p+"%")
+	}
+
+	return query.Where(cond)
+}
+
+// @Summary Export bundle
+// @Tags bundle
+// @Description Export selected controls, templates, auths and settings namespaces as a JSON bundle
+// @Security ApiKeyAuth
+// @Router /export [get]
+// @Param all query bool false "export everything"
+// @Param controls query string false "control names 'control1,control2'"
+// @Param templates query string false "template names, ending with / selects folder 'deepcore/,template1'"
+// @Param auths query string false "auth names 'auth1,auth2'"
+// @Param settings query string false "settings namespaces 'email,oauth2'"
+// @Success 200 {object} models.Bundle{}
+// @failure 400 {object} apimodels.Error{}
+// @failure 500 {object} apimodels.Error{}
+func exportBundle(c echo.Context) error {
+	all := c.QueryParam("all") == "true"
+
+	ctx := c.Request().Context()
+	db := registry.Reg.DB.WithContext(ctx)
+
+	bundle := models.Bundle{
+		Version:   models.BundleVersion,
+		CreatedAt: time.Now(),
+	}
+
+	if v := c.QueryParam("controls"); v != "" || all {
+		if result := exportQuery(db.Model(&models.Control{}), "name", v, all).Order("name").Find(&bundle.Controls); result.Error != nil {
+			return c.JSON(http.StatusInternalServerError, apimodels.Error{Error: result.Error.Error()})
+		}
+	}
+
+	if v := c.QueryParam("templates"); v != "" || all {
+		if result := exportQuery(db.Model(&models.Template{}), "name", v, all).Order("name").Find(&bundle.Templates); result.Error != nil {
+			return c.JSON(http.StatusInternalServerError, apimodels.Error{Error: result.Error.Error()})
+		}
+
+		folders := map[string]struct{}{}
+		for _, t := range bundle.Templates {
+			for _, f := range utils.FolderFile(t.Name) {
+				folders[f.Name] = struct{}{}
+			}
+		}
+
+		if len(folders) > 0 {
+			names := make([]string, 0, len(folders))
+			for name := range folders {
+				names = append(names, name)
+			}
+
+			if result := db.Model(&models.Folder{}).Where("name IN ?", names).Order("name").Find(&bundle.Folders); result.Error != nil {
+				return c.JSON(http.StatusInternalServerError, apimodels.Error{Error: result.Error.Error()})
+			}
+		}
+	}
+
+	if v := c.QueryParam("auths"); v != "" || all {
+		if result := exportQuery(db.Model(&models.Auth{}), "name", v, all).Order("name").Find(&bundle.Auths); result.Error != nil {
+			return c.JSON(http.StatusInternalServerError, apimodels.Error{Error: result.Error.Error()})
+		}
+	}
+
+	if v := c.QueryParam("settings"); v != "" || all {
+		if result := exportQuery(db.Model(&models.Settings{}), "namespace", v, all).Order("namespace, name").Find(&bundle.Settings); result.Error != nil {
+			return c.JSON(http.StatusInternalServerError, apimodels.Error{Error: result.Error.Error()})
+		}
+	}
+
+	c.Response().Header().Set(echo.HeaderContentDisposition, fmt.Sprintf("attachment; filename=chore-%s.json", bundle.CreatedAt.Format("20060102-150405")))
+
+	return c.JSON(http.StatusOK, bundle)
+}
+
+// freeName finds not used name with adding number suffix.
+func freeName(name string, exist func(string) (bool, error)) (string, error) {
+	for i := 1; i <= renameTry; i++ {
+		newName := fmt.Sprintf("%s-%d", name, i)
+
+		found, err := exist(newName)
+		if err != nil {
+			return "", err
+		}
+
+		if !found {
+			return newName, nil
+		}
+	}
+
+	return "", fmt.Errorf("cannot find free name for %s", name)
+}
+
+func existFunc(tx *gorm.DB, model interface{}, where string, args ...interface{}) func(string) (bool, error) {
+	return func(name string) (bool, error) {
+		var count int64
+
+		result := tx.Model(model).Where(where, append([]interface{}{name}, args...)...).Count(&count)
+
+		return count > 0, result.Error
+	}
+}
+
+// @Summary Import bundle
+// @Tags bundle
+// @Description Import JSON bundle, conflict mode decides existing records
+// @Security ApiKeyAuth
+// @Router /import [post]
+// @Param mode query string false "conflict mode skip, overwrite, rename; default is skip"
+// @Param force query bool false "import controls with validation errors"
+// @Param payload body models.Bundle{} true "exported bundle"
+// @Success 200 {object} apimodels.Data{data=ImportReport{}}
+// @failure 400 {object} ImportValidationError{}
+// @failure 500 {object} apimodels.Error{}
+func importBundle(c echo.Context) error {
+	mode := c.QueryParam("mode")
+	if mode == "" {
+		mode = ImportSkip
+	}
+
+	if mode != ImportSkip && mode != ImportOverwrite && mode != ImportRename {
+		return c.JSON(http.StatusBadRequest, apimodels.Error{Error: errInvalidMode.Error()})
+	}
+
+	var bundle models.Bundle
+	if err := c.Bind(&bundle); err != nil {
+		return c.JSON(http.StatusBadRequest, apimodels.Error{Error: err.Error()})
+	}
+
+	if bundle.Version > models.BundleVersion {
+		return c.JSON(http.StatusBadRequest, apimodels.Error{Error: fmt.Sprintf("unsupported bundle version %d", bundle.Version)})
+	}
+
+	force := c.QueryParam("force") == "true"
+
+	ctx := utils.Context(c)
+
+	report := ImportReport{}
+
+	// previous values of overwritten records
+	var prevControls []models.Control
+	var prevTemplates []models.Template
+
+	// new names of the renamed records and recorded controls to fix their references
+	renamed := make(map[flow.Reference]string)
+	var imported []models.ControlPureContent
+
+	err := registry.Reg.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		// controls
+		for _, v := range bundle.Controls {
+			prev := models.Control{}
+
+			result := tx.Where("name = ?", v.Name).Limit(1).Find(&prev)
+			if result.Error != nil {
+				return result.Error
+			}
+
+			name, ok, err := importName(mode, v.Name, result.RowsAffected > 0, &report.Controls,
+				existFunc(tx, &models.Control{}, "name = ?"))
+			if err != nil {
+				return err
+			}
+
+			if !ok {
+				continue
+			}
+
+			if name != v.Name {
+				renamed[flow.Reference{Type: flow.ReferenceControl, Name: v.Name}] = name
+			}
+
+			v.Name = name
+
+			if result.RowsAffected > 0 && mode == ImportOverwrite {
+				prevControls = append(prevControls, prev)
+				result = tx.Model(&models.Control{}).Where("id = ?", prev.ID.ID).Select("*").Omit("id", "created_at").Updates(
+					&models.Control{ControlPureContent: v, Author: middlewares.Subject(c)},
+				)
+			} else {
+				result = tx.Create(&models.Control{
+					ControlPureContent: v,
+					Author:             middlewares.Subject(c),
+					ModelCU:            apimodels.ModelCU{ID: apimodels.ID{ID: uuid.New()}},
+				})
+			}
+
+			if result.Error != nil {
+				return fmt.Errorf("control %s: %w", v.Name, result.Error)
+			}
+
+			imported = append(imported, v)
+		}
+
+		// templates
+		for _, v := range bundle.Templates {
+			prev := models.Template{}
+
+			result := tx.Where("name = ?", v.Name).Limit(1).Find(&prev)
+			if result.Error != nil {
+				return result.Error
+			}
+
+			name, ok, err := importName(mode, v.Name, result.RowsAffected > 0, &report.Templates,
+				existFunc(tx, &models.Template{}, "name = ?"))
+			if err != nil {
+				return err
+			}
+
+			if !ok {
+				continue
+			}
+
+			if name != v.Name {
+				renamed[flow.Reference{Type: flow.ReferenceTemplate, Name: v.Name}] = name
+			}
+
+			v.Name = name
+
+			if result.RowsAffected > 0 && mode == ImportOverwrite {
+				prevTemplates = append(prevTemplates, prev)
+				result = tx.Model(&models.Template{}).Where("id = ?", prev.ID.ID).Select("*").Omit("id", "created_at").Updates(
+					&models.Template{TemplatePure: v, Author: middlewares.Subject(c)},
+				)
+			} else {
+				result = tx.Create(&models.Template{
+					TemplatePure: v,
+					Author:       middlewares.Subject(c),
+					ModelCU:      apimodels.ModelCU{ID: apimodels.ID{ID: uuid.New()}},
+				})
+			}
+
+			if result.Error != nil {
+				return fmt.Errorf("template %s: %w", v.Name, result.Error)
+			}
+
+			if folders := utils.FolderFile(v.Name); len(folders) > 0 {
+				// on conflict do nothing
+				if result := tx.Model(models.Folder{}).Clauses(clause.OnConflict{DoNothing: true}).Create(folders); result.Error != nil {
+					return fmt.Errorf("template %s folder: %w", v.Name, result.Error)
+				}
+			}
+		}
+
+		// folders of the bundle, already existing ones are same
+		for _, v := range bundle.Folders {
+			folder := models.Folder{FolderPure: v, ID: apimodels.ID{ID: uuid.New()}}
+			if result := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&folder); result.Error != nil {
+				return fmt.Errorf("folder %s: %w", v.Name, result.Error)
+			}
+		}
+
+		// auths
+		for _, v := range bundle.Auths {
+			prev := models.Auth{}
+
+			result := tx.Where("name = ?", v.Name).Limit(1).Find(&prev)
+			if result.Error != nil {
+				return result.Error
+			}
+
+			name, ok, err := importName(mode, v.Name, result.RowsAffected > 0, &report.Auths,
+				existFunc(tx, &models.Auth{}, "name = ?"))
+			if err != nil {
+				return err
+			}
+
+			if !ok {
+				continue
+			}
+
+			if name != v.Name {
+				renamed[flow.Reference{Type: flow.ReferenceAuth, Name: v.Name}] = name
+			}
+
+			v.Name = name
+
+			if result.RowsAffected > 0 && mode == ImportOverwrite {
+				result = tx.Model(&models.Auth{}).Where("id = ?", prev.ID.ID).Select("*").Omit("id", "created_at").Updates(
+					&models.Auth{AuthPure: v},
+				)
+			} else {
+				result = tx.Create(&models.Auth{
+					AuthPure: v,
+					ModelCU:  apimodels.ModelCU{ID: apimodels.ID{ID: uuid.New()}},
+				})
+			}
+
+			if result.Error != nil {
+				return fmt.Errorf("auth %s: %w", v.Name, result.Error)
+			}
+		}
+
+		// settings
+		for _, v := range bundle.Settings {
+			var count int64
+
+			result := tx.Model(&models.Settings{}).Where("namespace = ? AND name = ?", v.Namespace, v.Name).Count(&count)
+			if result.Error != nil {
+				return result.Error
+			}
+
+			name, ok, err := importName(mode, v.Name, count > 0, &report.Settings,
+				existFunc(tx, &models.Settings{}, "name = ? AND namespace = ?", v.Namespace))
+			if err != nil {
+				return err
+			}
+
+			if !ok {
+				continue
+			}
+
+			if name != v.Name {
+				renamed[flow.Reference{Type: flow.ReferenceSettings, Namespace: v.Namespace, Name: v.Name}] = name
+			}
+
+			v.Name = name
+
+			if count > 0 && mode == ImportOverwrite {
+				result = tx.Model(&models.Settings{}).Where("namespace = ? AND name = ?", v.Namespace, v.Name).
+					Select("data", "groups", "updated_at").Updates(&models.Settings{SettingsPure: v})
+			} else {
+				result = tx.Create(&models.Settings{SettingsPure: v})
+			}
+
+			if result.Error != nil {
+				return fmt.Errorf("settings %s/%s: %w", v.Namespace, v.Name, result.Error)
+			}
+		}
+
+		// imported controls use new names of the renamed records
+		if len(renamed) > 0 {
+			for i := range imported {
+				if err := renameReferences(ctx, tx, &imported[i], renamed); err != nil {
+					return err
+				}
+			}
+		}
+
+		// validate with all records of the bundle
+		hasError := false
+
+		for _, v := range imported {
+			validation, err := validateControlDB(ctx, tx, v.Content, v.Groups.Groups)
+			if err != nil {
+				validation = flow.Validation{Errors: []flow.Issue{{Message: err.Error()}}}
+			}
+
+			if len(validation.Errors) == 0 && len(validation.Warnings) == 0 {
+				continue
+			}
+
+			if report.Validations == nil {
+				report.Validations = make(map[string]flow.Validation)
+			}
+
+			report.Validations[v.Name] = validation
+			hasError = hasError || validation.HasError()
+		}
+
+		if hasError && !force {
+			return errImportValidation
+		}
+
+		return nil
+	})
+	if errors.Is(err, errImportValidation) {
+		return c.JSON(http.StatusBadRequest, ImportValidationError{
+			Error:       apimodels.Error{Error: err.Error()},
+			Validations: report.Validations,
+		})
+	}
+
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, apimodels.Error{Error: err.Error()})
+	}
+
+	for i := range prevControls {
+		saveControlRevision(ctx, &prevControls[i])
+	}
+
+	for i := range prevTemplates {
+		saveTemplateRevision(ctx, &prevTemplates[i])
+	}
+
+	reloadSchedules(ctx)
+
+	return c.JSON(http.StatusOK, apimodels.Data{Data: report})
+}
+
+// renameReferences updates the references of the control to the renamed records.
+// Broken contents are kept as is, validation reports them.
+func renameReferences(ctx context.Context, tx *gorm.DB, control *models.ControlPureContent, renamed map[flow.Reference]string) error {
+	content, err := base64.StdEncoding.DecodeString(control.Content)
+	if err != nil {
+		return nil //nolint:nilerr // reported in validation
+	}
+
+	newContent, changed, err := flow.RenameReferences(ctx, content, func(ref flow.Reference) (string, bool) {
+		ref.Field = ""
+		name, ok := renamed[ref]
+
+		return name, ok
+	})
+	if err != nil || !changed {
+		return nil //nolint:nilerr // reported in validation
+	}
+
+	control.Content = base64.StdEncoding.EncodeToString(newContent)
+
+	result := tx.Model(&models.Control{}).Where("name = ?", control.Name).Update("content", control.Content)
+	if result.Error != nil {
+		return fmt.Errorf("control %s: %w", control.Name, result.Error)
+	}
+
+	return nil
+}
+
+// importName returns the name to record, false means skip.
+func importName(mode, name string, exist bool, result *ImportResult, existName func(string) (bool, error)) (string, bool, error) {
+	if !exist {
+		result.Created = append(result.Created, name)
+
+		return name, true, nil
+	}
+
+	switch mode {
+	case ImportOverwrite:
+		result.Overwritten = append(result.Overwritten, name)
+
+		return name, true, nil
+	case ImportRename:
+		newName, err := freeName(name, existName)
+		if err != nil {
+			return "", false, err
+		}
+
+		if result.Renamed == nil {
+			result.Renamed = make(map[string]string)
+		}
+
+		result.Renamed[name] = newName
+
+		return newName, true, nil
+	}
+
+	result.Skipped = append(result.Skipped, name)
+
+	return "", false, nil
+}
+
+func Bundle(e *echo.Group, authMiddleware echo.MiddlewareFunc) {
+	e.GET("/export", exportBundle, authMiddleware, middlewares.AdminRole, middlewares.PatToken)
+	e.POST("/import", importBundle, authMiddleware, middlewares.AdminRole, middlewares.PatToken)
+}
@@ -0,0 +1,323 @@
+package api
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"reflect"
+	"testing"
+
+	"github.com/google/uuid"
+
+	"github.com/rakunlabs/chore/pkg/flow"
+	"github.com/rakunlabs/chore/pkg/models"
+	"github.com/rakunlabs/chore/pkg/models/apimodels"
+)
+
+func testControlContent(content string) string {
+	return base64.StdEncoding.EncodeToString([]byte(content))
+}
+
+func TestImportBundleValidation(t *testing.T) {
+	templateContent := testControlContent(`{
+		"1": {"name": "endpoint", "data": {"endpoint": "test", "methods": "POST"}, "outputs": {"output_1": {"connections": [{"node": "2", "output": "input_1"}]}}},
+		"2": {"name": "template", "data": {"template": "bundle/tpl"}, "inputs": {"input_1": {"connections": [{"node": "1"}]}}}
+	}`)
+	invalidContent := testControlContent(`{"1": {"name": "unknown-node", "data": {}}}`)
+
+	tests := []struct {
+		name            string
+		query           string
+		content         string
+		status          int
+		wantImported    bool
+		wantValidations bool
+	}{
+		{name: "template in bundle", content: templateContent, status: http.StatusOK, wantImported: true},
+		{name: "invalid control", content: invalidContent, status: http.StatusBadRequest, wantValidations: true},
+		{name: "invalid control with force", query: "&force=true", content: invalidContent, status: http.StatusOK, wantImported: true, wantValidations: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dbConn := testDB(t)
+
+			bundle := models.Bundle{
+				Version: models.BundleVersion,
+				Controls: []models.ControlPureContent{
+					{Content: tt.content, ControlPure: models.ControlPure{Name: "imported"}},
+				},
+				Templates: []models.TemplatePure{
+					{Name: "bundle/tpl", Content: testControlContent("hello")},
+				},
+			}
+
+			body, err := json.Marshal(bundle)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			c, rec := testContext(http.MethodPost, "/import?mode=skip"+tt.query, string(body))
+			if err := importBundle(c); err != nil {
+				t.Fatal(err)
+			}
+
+			if rec.Code != tt.status {
+				t.Fatalf("importBundle() status = %d, want %d; %s", rec.Code, tt.status, rec.Body.String())
+			}
+
+			var controls, templates int64
+			dbConn.Model(&models.Control{}).Count(&controls)
+			dbConn.Model(&models.Template{}).Count(&templates)
+
+			if imported := controls == 1 && templates == 1; imported != tt.wantImported {
+				t.Errorf("imported controls = %d, templates = %d, want imported %v", controls, templates, tt.wantImported)
+			}
+
+			var got struct {
+				Validations map[string]json.RawMessage `json:"validations"`
+				Data        struct {
+					Validations map[string]json.RawMessage `json:"validations"`
+				} `json:"data"`
+			}
+
+			if err := json.Unmarshal(rec.Body.Bytes(), &got); err != nil {
+				t.Fatal(err)
+			}
+
+			validations := len(got.Validations) + len(got.Data.Validations)
+			if (validations > 0) != tt.wantValidations {
+				t.Errorf("validations = %s, want validations %v", rec.Body.String(), tt.wantValidations)
+			}
+		})
+	}
+}
+
+func TestImportBundleModes(t *testing.T) {
+	controlContent := func(template string) string {
+		return testControlContent(`{
+			"1": {"name": "endpoint", "data": {"endpoint": "test", "methods": "POST"}, "outputs": {"output_1": {"connections": [{"node": "2", "output": "input_1"}]}}},
+			"2": {"name": "template", "data": {"template": "` + template + `"}, "inputs": {"input_1": {"connections": [{"node": "1"}]}}}
+		}`)
+	}
+
+	oldControl := controlContent("bundle/old")
+	newControl := controlContent("bundle/tpl")
+
+	oldTemplate := testControlContent("old")
+	newTemplate := testControlContent("new")
+
+	tests := []struct {
+		name string
+		mode string
+		// wantControls is the template used by the control
+		wantControls  map[string]string
+		wantTemplates map[string]string
+		wantResult    ImportResult
+		wantRevisions int64
+	}{
+		{
+			name:          "skip",
+			mode:          ImportSkip,
+			wantControls:  map[string]string{"imported": "bundle/old"},
+			wantTemplates: map[string]string{"bundle/old": oldTemplate, "bundle/tpl": oldTemplate},
+			wantResult:    ImportResult{Skipped: []string{"imported"}},
+		},
+		{
+			name:          "overwrite",
+			mode:          ImportOverwrite,
+			wantControls:  map[string]string{"imported": "bundle/tpl"},
+			wantTemplates: map[string]string{"bundle/old": oldTemplate, "bundle/tpl": newTemplate},
+			wantResult:    ImportResult{Overwritten: []string{"imported"}},
+			wantRevisions: 1,
+		},
+		{
+			name:          "rename",
+			mode:          ImportRename,
+			wantControls:  map[string]string{"imported": "bundle/old", "imported-1": "bundle/tpl-1"},
+			wantTemplates: map[string]string{"bundle/old": oldTemplate, "bundle/tpl": oldTemplate, "bundle/tpl-1": newTemplate},
+			wantResult:    ImportResult{Renamed: map[string]string{"imported": "imported-1"}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dbConn := testDB(t)
+
+			if err := dbConn.Create(&models.Control{
+				ControlPureContent: models.ControlPureContent{Content: oldControl, ControlPure: models.ControlPure{Name: "imported"}},
+				ModelCU:            apimodels.ModelCU{ID: apimodels.ID{ID: uuid.New()}},
+			}).Error; err != nil {
+				t.Fatal(err)
+			}
+
+			for _, name := range []string{"bundle/old", "bundle/tpl"} {
+				if err := dbConn.Create(&models.Template{
+					TemplatePure: models.TemplatePure{Name: name, Content: oldTemplate},
+					ModelCU:      apimodels.ModelCU{ID: apimodels.ID{ID: uuid.New()}},
+				}).Error; err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			bundle := models.Bundle{
+				Version: models.BundleVersion,
+				Controls: []models.ControlPureContent{
+					{Content: newControl, ControlPure: models.ControlPure{Name: "imported"}},
+				},
+				Templates: []models.TemplatePure{
+					{Name: "bundle/tpl", Content: newTemplate},
+				},
+			}
+
+			body, err := json.Marshal(bundle)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			c, rec := testContext(http.MethodPost, "/import?mode="+tt.mode, string(body))
+			if err := importBundle(c); err != nil {
+				t.Fatal(err)
+			}
+
+			if rec.Code != http.StatusOK {
+				t.Fatalf("importBundle() status = %d; %s", rec.Code, rec.Body.String())
+			}
+
+			var got struct {
+				Data ImportReport `json:"data"`
+			}
+
+			if err := json.Unmarshal(rec.Body.Bytes(), &got); err != nil {
+				t.Fatal(err)
+			}
+
+			if !reflect.DeepEqual(got.Data.Controls, tt.wantResult) {
+				t.Errorf("report controls = %+v, want %+v", got.Data.Controls, tt.wantResult)
+			}
+
+			var controls []models.Control
+			dbConn.Find(&controls)
+
+			gotControls := make(map[string]string, len(controls))
+			for _, v := range controls {
+				content, err := base64.StdEncoding.DecodeString(v.Content)
+				if err != nil {
+					t.Fatal(err)
+				}
+
+				datas, err := flow.ParseData(content)
+				if err != nil {
+					t.Fatal(err)
+				}
+
+				gotControls[v.Name], _ = datas["2"].Data["template"].(string)
+			}
+
+			if !reflect.DeepEqual(gotControls, tt.wantControls) {
+				t.Errorf("controls = %v, want %v", gotControls, tt.wantControls)
+			}
+
+			var templates []models.Template
+			dbConn.Find(&templates)
+
+			gotTemplates := make(map[string]string, len(templates))
+			for _, v := range templates {
+				gotTemplates[v.Name] = v.Content
+			}
+
+			if !reflect.DeepEqual(gotTemplates, tt.wantTemplates) {
+				t.Errorf("templates = %v, want %v", gotTemplates, tt.wantTemplates)
+			}
+
+			var revisions int64
+			dbConn.Model(&models.ControlRevision{}).Count(&revisions)
+
+			if revisions != tt.wantRevisions {
+				t.Errorf("control revisions = %d, want %d", revisions, tt.wantRevisions)
+			}
+		})
+	}
+}
+
+func TestRenameReferences(t *testing.T) {
+	renamed := map[flow.Reference]string{
+		{Type: flow.ReferenceTemplate, Name: "t1"}: "t1-1",
+	}
+
+	tests := []struct {
+		name    string
+		content string
+		want    string
+	}{
+		{
+			name:    "renamed",
+			content: testControlContent(`{"1": {"name": "template", "data": {"template": "t1"}}}`),
+			want:    `{"1": {"name": "template", "data": {"template": "t1-1"}}}`,
+		},
+		{
+			name:    "not renamed",
+			content: testControlContent(`{"1": {"name": "template", "data": {"template": "t2"}}}`),
+			want:    `{"1": {"name": "template", "data": {"template": "t2"}}}`,
+		},
+		{
+			name:    "broken content kept",
+			content: "not base64",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dbConn := testDB(t)
+
+			control := models.ControlPureContent{Content: tt.content, ControlPure: models.ControlPure{Name: "test"}}
+			if err := dbConn.Create(&models.Control{
+				ControlPureContent: control,
+				ModelCU:            apimodels.ModelCU{ID: apimodels.ID{ID: uuid.New()}},
+			}).Error; err != nil {
+				t.Fatal(err)
+			}
+
+			if err := renameReferences(context.Background(), dbConn, &control, renamed); err != nil {
+				t.Fatalf("renameReferences() error = %v", err)
+			}
+
+			stored := models.Control{}
+			if err := dbConn.Where("name = ?", "test").First(&stored).Error; err != nil {
+				t.Fatal(err)
+			}
+
+			if stored.Content != control.Content {
+				t.Errorf("stored content = %q, want %q", stored.Content, control.Content)
+			}
+
+			if tt.want == "" {
+				if control.Content != tt.content {
+					t.Errorf("renameReferences() content = %q, want %q", control.Content, tt.content)
+				}
+
+				return
+			}
+
+			content, err := base64.StdEncoding.DecodeString(control.Content)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			got, err := flow.ParseData(content)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			want, err := flow.ParseData([]byte(tt.want))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if !reflect.DeepEqual(got["1"].Data, want["1"].Data) {
+				t.Errorf("renameReferences() data = %v, want %v", got["1"].Data, want["1"].Data)
+			}
+		})
+	}
+}
@@ -1,21 +1,29 @@
 package api
 
 import (
+	"bytes"
+	"context"
+	"encoding/base64"
 	"encoding/json"
 	"errors"
+	"fmt"
 	"net/http"
 
 	"github.com/google/uuid"
 	"github.com/labstack/echo/v4"
+	"github.com/rs/zerolog/log"
+	"gorm.io/datatypes"
 	"gorm.io/gorm"
 	"gorm.io/gorm/clause"
 
 	"github.com/rakunlabs/chore/internal/parser"
 	"github.com/rakunlabs/chore/internal/server/middlewares"
 	"github.com/rakunlabs/chore/internal/utils"
+	"github.com/rakunlabs/chore/pkg/flow"
 	"github.com/rakunlabs/chore/pkg/models"
 	"github.com/rakunlabs/chore/pkg/models/apimodels"
 	"github.com/rakunlabs/chore/pkg/registry"
+	"github.com/rakunlabs/chore/pkg/scheduler"
 )
 
 type ControlPureContentID struct {
@@ -28,6 +36,14 @@ type ControlPureID struct {
 	apimodels.ID
 }
 
+// ControlValidationError returns when content of the control has errors.
+type ControlValidationError struct {
+	apimodels.Error
+	flow.Validation
+}
+
+var errControlValidation = errors.New("control content is not valid")
+
 // @Summary List controls
 // @Tags control
 // @Description Get list of the controls
@@ -48,7 +64,9 @@ func listControls(c echo.Context) error {
 		return c.JSON(http.StatusBadRequest, apimodels.Error{Error: err.Error()})
 	}
 
-	query := registry.Reg.DB.WithContext(c.Request().Context()).Model(&models.Control{})
+	access := middlewares.GetAccess(c)
+
+	query := registry.Reg.DB.WithContext(c.Request().Context()).Model(&models.Control{}).Scopes(access.Scope(false))
 
 	if meta.Search != "" {
 		query = query.Where("name LIKE ?", meta.Search+"%")
@@ -62,7 +80,7 @@ func listControls(c echo.Context) error {
 	}
 
 	// get counts
-	query = registry.Reg.DB.WithContext(c.Request().Context()).Model(&models.Control{})
+	query = registry.Reg.DB.WithContext(c.Request().Context()).Model(&models.Control{}).Scopes(access.Scope(false))
 	if meta.Search != "" {
 		query = query.Where("name LIKE ?", meta.Search+"%")
 	}
@@ -117,7 +135,7 @@ func getControl(c echo.Context) error {
 	controlContent := new(ControlPureContentID)
 	control := new(ControlPureID)
 
-	query := registry.Reg.DB.WithContext(c.Request().Context()).Model(&models.Control{})
+	query := registry.Reg.DB.WithContext(c.Request().Context()).Model(&models.Control{}).Scopes(middlewares.GetAccess(c).Scope(false))
 
 	if id != "" {
 		query = query.Where("id = ?", id)
@@ -183,7 +201,7 @@ func getControl(c echo.Context) error {
 // @Router /control [post]
 // @Param payload body models.ControlPureContent{} false "send control object"
 // @Success 200 {object} apimodels.Data{data=apimodels.ID{}}
-// @failure 400 {object} apimodels.Error{}
+// @failure 400 {object} ControlValidationError{}
 // @failure 409 {object} apimodels.Error{}
 // @failure 500 {object} apimodels.Error{}
 func postControl(c echo.Context) error {
@@ -199,15 +217,33 @@ func postControl(c echo.Context) error {
 	// body content must be base64
 	// body.Content = base64.StdEncoding.EncodeToString([]byte(body.Content))
 
+	ctx := utils.Context(c)
+
+	if !middlewares.GetAccess(c).CanAssign(body.Groups.Groups, nil) {
+		return c.JSON(http.StatusForbidden, apimodels.Error{Error: apimodels.ErrGroupAssign.Error()})
+	}
+
+	validation, err := validateControl(ctx, body.Content, body.Groups.Groups)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, apimodels.Error{Error: err.Error()})
+	}
+
+	if validation.HasError() {
+		return c.JSON(http.StatusBadRequest, ControlValidationError{
+			Error:      apimodels.Error{Error: errControlValidation.Error()},
+			Validation: validation,
+		})
+	}
+
 	id, err := uuid.NewUUID()
 	if err != nil {
 		return c.JSON(http.StatusInternalServerError, apimodels.Error{Error: err.Error()})
 	}
 
-	ctx := utils.Context(c)
 	result := registry.Reg.DB.WithContext(ctx).Model(&models.Control{}).Create(
 		&models.Control{
 			ControlPureContent: body,
+			Author:             middlewares.Subject(c),
 			ModelCU: apimodels.ModelCU{
 				ID: apimodels.ID{ID: id},
 			},
@@ -223,6 +259,8 @@ func postControl(c echo.Context) error {
 		return c.JSON(http.StatusInternalServerError, apimodels.Error{Error: result.Error.Error()})
 	}
 
+	reloadSchedules(ctx)
+
 	// return recorded data's id
 	return c.JSON(http.StatusOK, apimodels.Data{Data: apimodels.ID{ID: id}})
 }
@@ -254,7 +292,8 @@ func cloneControl(c echo.Context) error {
 	controlContent := new(ControlPureContentID)
 
 	ctx := utils.Context(c)
-	result := registry.Reg.DB.WithContext(ctx).Model(&models.Control{}).Where("name = ?", body.Name).First(&controlContent)
+	result := registry.Reg.DB.WithContext(ctx).Model(&models.Control{}).Where("name = ?", body.Name).
+		Scopes(middlewares.GetAccess(c).Scope(false)).First(&controlContent)
 
 	if errors.Is(result.Error, gorm.ErrRecordNotFound) {
 		return c.JSON(http.StatusNotFound, apimodels.Error{Error: result.Error.Error()})
@@ -264,6 +303,11 @@ func cloneControl(c echo.Context) error {
 		return c.JSON(http.StatusInternalServerError, apimodels.Error{Error: result.Error.Error()})
 	}
 
+	// clone runs with the same groups
+	if !middlewares.GetAccess(c).CanAssign(controlContent.Groups.Groups, nil) {
+		return c.JSON(http.StatusForbidden, apimodels.Error{Error: apimodels.ErrGroupAssign.Error()})
+	}
+
 	id, err := uuid.NewUUID()
 	if err != nil {
 		return c.JSON(http.StatusInternalServerError, apimodels.Error{Error: err.Error()})
@@ -275,6 +319,7 @@ func cloneControl(c echo.Context) error {
 	result = registry.Reg.DB.WithContext(ctx).Model(&models.Control{}).Create(
 		&models.Control{
 			ControlPureContent: controlContent.ControlPureContent,
+			Author:             middlewares.Subject(c),
 			ModelCU: apimodels.ModelCU{
 				ID: apimodels.ID{ID: id},
 			},
@@ -289,6 +334,8 @@ func cloneControl(c echo.Context) error {
 		return c.JSON(http.StatusInternalServerError, apimodels.Error{Error: result.Error.Error()})
 	}
 
+	reloadSchedules(ctx)
+
 	// return recorded data's id
 	return c.JSON(http.StatusOK,
 		apimodels.Data{
@@ -304,7 +351,8 @@ func cloneControl(c echo.Context) error {
 // @Router /control [put]
 // @Param payload body models.ControlPureContent{} false "send control object"
 // @Success 204 "No Content"
-// @failure 400 {object} apimodels.Error{}
+// @failure 400 {object} ControlValidationError{}
+// @failure 403 {object} apimodels.Error{}
 // @failure 500 {object} apimodels.Error{}
 func putControl(c echo.Context) error {
 	var body models.ControlPureContent
@@ -319,19 +367,50 @@ func putControl(c echo.Context) error {
 	// body content must be base64
 	// body.Content = base64.StdEncoding.EncodeToString([]byte(body.Content))
 
+	ctx := utils.Context(c)
+	access := middlewares.GetAccess(c)
+
+	// check access of the existing record
+	prev := models.Control{}
+	result := registry.Reg.DB.WithContext(ctx).Model(&models.Control{}).Where("name = ?", body.Name).Limit(1).Find(&prev)
+	if result.Error != nil {
+		return c.JSON(http.StatusInternalServerError, apimodels.Error{Error: result.Error.Error()})
+	}
+
+	exist := result.RowsAffected > 0
+	if exist && !access.Can(prev.Groups.Groups, true) {
+		return c.JSON(http.StatusForbidden, apimodels.Error{Error: apimodels.ErrForbidden.Error()})
+	}
+
+	if !access.CanAssign(body.Groups.Groups, prev.Groups.Groups) {
+		return c.JSON(http.StatusForbidden, apimodels.Error{Error: apimodels.ErrGroupAssign.Error()})
+	}
+
+	validation, err := validateControl(ctx, body.Content, body.Groups.Groups)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, apimodels.Error{Error: err.Error()})
+	}
+
+	if validation.HasError() {
+		return c.JSON(http.StatusBadRequest, ControlValidationError{
+			Error:      apimodels.Error{Error: errControlValidation.Error()},
+			Validation: validation,
+		})
+	}
+
 	id, err := uuid.NewUUID()
 	if err != nil {
 		return c.JSON(http.StatusInternalServerError, apimodels.Error{Error: err.Error()})
 	}
 
-	ctx := utils.Context(c)
-	result := registry.Reg.DB.WithContext(ctx).Model(&models.Control{}).Clauses(
+	result = registry.Reg.DB.WithContext(ctx).Model(&models.Control{}).Clauses(
 		clause.OnConflict{
 			UpdateAll: true,
 			Columns:   []clause.Column{{Name: "name"}},
 		}).Create(
 		&models.Control{
 			ControlPureContent: body,
+			Author:             middlewares.Subject(c),
 			ModelCU: apimodels.ModelCU{
 				ID: apimodels.ID{ID: id},
 			},
@@ -342,6 +421,12 @@ func putControl(c echo.Context) error {
 		return c.JSON(http.StatusInternalServerError, apimodels.Error{Error: result.Error.Error()})
 	}
 
+	if exist && (prev.Content != body.Content || !bytes.Equal(prev.Endpoints.Endpoints, body.Endpoints.Endpoints)) {
+		saveControlRevision(ctx, &prev)
+	}
+
+	reloadSchedules(ctx)
+
 	//nolint:wrapcheck // checking before
 	return c.NoContent(http.StatusNoContent)
 }
@@ -353,7 +438,8 @@ func putControl(c echo.Context) error {
 // @Router /control [patch]
 // @Param payload body ControlPureID{} false "send part of the control object"
 // @Success 200 {object} apimodels.Data{data=apimodels.ID{}}
-// @failure 400 {object} apimodels.Error{}
+// @failure 400 {object} ControlValidationError{}
+// @failure 404 {object} apimodels.Error{}
 // @failure 409 {object} apimodels.Error{}
 // @failure 500 {object} apimodels.Error{}
 func patchControl(c echo.Context) error {
@@ -371,20 +457,66 @@ func patchControl(c echo.Context) error {
 
 	var err error
 
-	body["endpoints"], err = json.Marshal(body["endpoints"])
-	if err != nil {
-		return c.JSON(http.StatusBadRequest, apimodels.Error{Error: err.Error()})
+	if _, ok := body["endpoints"]; ok {
+		body["endpoints"], err = json.Marshal(body["endpoints"])
+		if err != nil {
+			return c.JSON(http.StatusBadRequest, apimodels.Error{Error: err.Error()})
+		}
+	}
+
+	ctx := utils.Context(c)
+	access := middlewares.GetAccess(c)
+
+	prev := models.Control{}
+
+	result := registry.Reg.DB.WithContext(ctx).Where("id = ?", body["id"]).Scopes(access.Scope(true)).First(&prev)
+	if errors.Is(result.Error, gorm.ErrRecordNotFound) {
+		return c.JSON(http.StatusNotFound, apimodels.Error{Error: result.Error.Error()})
+	}
+
+	if result.Error != nil {
+		return c.JSON(http.StatusInternalServerError, apimodels.Error{Error: result.Error.Error()})
 	}
 
-	body["groups"], err = json.Marshal(body["groups"])
+	// groups only change when they are in the body
+	groups := prev.Groups.Groups
+
+	if _, ok := body["groups"]; ok {
+		groups, err = json.Marshal(body["groups"])
+		if err != nil {
+			return c.JSON(http.StatusBadRequest, apimodels.Error{Error: err.Error()})
+		}
+
+		if !access.CanAssign(groups, prev.Groups.Groups) {
+			return c.JSON(http.StatusForbidden, apimodels.Error{Error: apimodels.ErrGroupAssign.Error()})
+		}
+
+		body["groups"] = groups
+	}
+
+	content, ok := body["content"].(string)
+	if !ok {
+		content = prev.Content
+	}
+
+	// new groups change readable records of the run
+	validation, err := validateControl(ctx, content, groups)
 	if err != nil {
 		return c.JSON(http.StatusBadRequest, apimodels.Error{Error: err.Error()})
 	}
 
-	ctx := utils.Context(c)
-	query := registry.Reg.DB.WithContext(ctx).Model(&models.Control{}).Where("id = ?", body["id"])
+	if validation.HasError() {
+		return c.JSON(http.StatusBadRequest, ControlValidationError{
+			Error:      apimodels.Error{Error: errControlValidation.Error()},
+			Validation: validation,
+		})
+	}
 
-	result := query.Updates(body)
+	body["author"] = middlewares.Subject(c)
+
+	query := registry.Reg.DB.WithContext(ctx).Model(&models.Control{}).Where("id = ?", body["id"]).Scopes(access.Scope(true))
+
+	result = query.Updates(body)
 
 	// check write error
 	if result.Error != nil && errors.Is(result.Error, gorm.ErrDuplicatedKey) {
@@ -395,6 +527,16 @@ func patchControl(c echo.Context) error {
 		return c.JSON(http.StatusInternalServerError, apimodels.Error{Error: result.Error.Error()})
 	}
 
+	if result.RowsAffected == 0 {
+		return c.JSON(http.StatusNotFound, apimodels.Error{Error: apimodels.ErrNotFound.Error()})
+	}
+
+	if content, ok := body["content"].(string); ok && content != prev.Content {
+		saveControlRevision(ctx, &prev)
+	}
+
+	reloadSchedules(ctx)
+
 	resultData := make(map[string]interface{})
 	resultData["id"] = body["id"]
 
@@ -425,7 +567,7 @@ func deleteControl(c echo.Context) error {
 	}
 
 	ctx := utils.Context(c)
-	query := registry.Reg.DB.WithContext(ctx)
+	query := registry.Reg.DB.WithContext(ctx).Scopes(middlewares.GetAccess(c).Scope(true))
 
 	if id != "" {
 		query = query.Where("id = ?", id)
@@ -435,6 +577,12 @@ func deleteControl(c echo.Context) error {
 		query = query.Where("name = ?", name)
 	}
 
+	// keep deleted controls as revision
+	prevs := []models.Control{}
+	if result := query.Session(&gorm.Session{}).Find(&prevs); result.Error != nil {
+		return c.JSON(http.StatusInternalServerError, apimodels.Error{Error: result.Error.Error()})
+	}
+
 	// delete directly in DB
 	result := query.Unscoped().Delete(&models.Control{})
 
@@ -446,11 +594,74 @@ func deleteControl(c echo.Context) error {
 		return c.JSON(http.StatusInternalServerError, apimodels.Error{Error: result.Error.Error()})
 	}
 
+	for i := range prevs {
+		saveControlRevision(ctx, &prevs[i])
+	}
+
+	reloadSchedules(ctx)
+
 	//nolint:wrapcheck // checking before
 	return c.NoContent(http.StatusNoContent)
 }
 
+// @Summary Validate control
+// @Tags control
+// @Description Check content of the control without saving, content must be base64 format
+// @Security ApiKeyAuth
+// @Router /control/validate [post]
+// @Param payload body models.ControlPureContent{} false "send control object"
+// @Success 200 {object} apimodels.Data{data=flow.Validation{}}
+// @failure 400 {object} apimodels.Error{}
+func postValidateControl(c echo.Context) error {
+	var body models.ControlPureContent
+	if err := c.Bind(&body); err != nil {
+		return c.JSON(http.StatusBadRequest, apimodels.Error{Error: err.Error()})
+	}
+
+	validation, err := validateControl(c.Request().Context(), body.Content, body.Groups.Groups)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, apimodels.Error{Error: err.Error()})
+	}
+
+	return c.JSON(http.StatusOK, apimodels.Data{Data: validation})
+}
+
+// validateControl checks base64 content of the control, empty content is valid.
+// References are checked with groups of the control like in the run.
+func validateControl(ctx context.Context, content string, groups datatypes.JSON) (flow.Validation, error) {
+	return validateControlDB(ctx, registry.Reg.DB, content, groups)
+}
+
+// validateControlDB validates the content with the records in the db, like records in a transaction.
+func validateControlDB(ctx context.Context, db *gorm.DB, content string, groups datatypes.JSON) (flow.Validation, error) {
+	if content == "" {
+		return flow.Validation{}, nil
+	}
+
+	raw, err := base64.StdEncoding.DecodeString(content)
+	if err != nil {
+		return flow.Validation{}, fmt.Errorf("content is not base64: %w", err)
+	}
+
+	reg := *registry.Reg
+	reg.DB = db
+
+	return flow.Validate(ctx, raw, &reg, models.ResourceAccess(groups)), nil
+}
+
+// reloadSchedules applies changed schedule nodes of controls.
+func reloadSchedules(ctx context.Context) {
+	if scheduler.GlobalScheduler == nil {
+		return
+	}
+
+	if err := scheduler.GlobalScheduler.Reload(ctx); err != nil {
+		log.Ctx(ctx).Error().Err(err).Msg("cannot reload schedules")
+	}
+}
+
 func Control(e *echo.Group, authMiddleware echo.MiddlewareFunc) {
+	e.POST("/control/validate", postValidateControl, authMiddleware, middlewares.UserRole, middlewares.PatToken)
 	e.POST("/control/clone", cloneControl, authMiddleware, middlewares.UserRole, middlewares.PatToken)
 	e.GET("/controls", listControls, authMiddleware, middlewares.UserRole, middlewares.PatToken)
 	e.GET("/control", getControl, authMiddleware, middlewares.UserRole, middlewares.PatToken)
@@ -0,0 +1,145 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+	wclaims "github.com/worldline-go/auth/claims"
+	"github.com/worldline-go/auth/pkg/authecho"
+	"gorm.io/datatypes"
+	"gorm.io/gorm"
+
+	"github.com/rakunlabs/chore/internal/server/claims"
+	"github.com/rakunlabs/chore/internal/store"
+	_ "github.com/rakunlabs/chore/pkg/flow/nodes"
+	"github.com/rakunlabs/chore/pkg/models"
+	"github.com/rakunlabs/chore/pkg/models/apimodels"
+	"github.com/rakunlabs/chore/pkg/registry"
+)
+
+// testDB sets a new sqlite database to the registry for the test.
+func testDB(t *testing.T) *gorm.DB {
+	t.Helper()
+
+	dbConn, err := store.OpenConnection("sqlite", map[string]interface{}{
+		"fileName": filepath.Join(t.TempDir(), "chore.db"),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := dbConn.AutoMigrate(store.Models...); err != nil {
+		t.Fatal(err)
+	}
+
+	prev := registry.Reg
+	t.Cleanup(func() { registry.Init(prev) })
+
+	registry.Init(&registry.Registry{DB: dbConn})
+
+	return dbConn
+}
+
+// testContext returns a request context of the user with the groups.
+func testContext(method, target, body string, groups ...string) (echo.Context, *httptest.ResponseRecorder) {
+	req := httptest.NewRequest(method, target, strings.NewReader(body))
+	req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+
+	rec := httptest.NewRecorder()
+	c := echo.New().NewContext(req, rec)
+
+	roles := make(map[string]struct{}, len(groups))
+	for _, g := range groups {
+		roles["chore_"+g] = struct{}{}
+	}
+
+	c.Set(authecho.KeyClaims, &claims.Custom{
+		Custom: wclaims.Custom{
+			RoleSet:          roles,
+			RegisteredClaims: jwt.RegisteredClaims{Subject: "user1"},
+		},
+	})
+
+	return c, rec
+}
+
+func TestPatchControlGroups(t *testing.T) {
+	dbConn := testDB(t)
+
+	tests := []struct {
+		name   string
+		body   string
+		status int
+		groups string
+	}{
+		{
+			name:   "without groups",
+			body:   `{"content":"e30="}`,
+			status: http.StatusOK,
+			groups: `["team1"]`,
+		},
+		{
+			name:   "with groups",
+			body:   `{"groups":["team1","team1:read"]}`,
+			status: http.StatusOK,
+			groups: `["team1","team1:read"]`,
+		},
+		{
+			name:   "not member of groups",
+			body:   `{"groups":["team2"]}`,
+			status: http.StatusForbidden,
+			groups: `["team1"]`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			control := models.Control{}
+			control.ID.ID = uuid.New()
+			control.Name = tt.name
+			control.Groups = apimodels.Groups{Groups: datatypes.JSON(`["team1"]`)}
+			control.Endpoints = models.Endpoints{Endpoints: datatypes.JSON(`{"test":{"methods":["POST"]}}`)}
+
+			if result := dbConn.Create(&control); result.Error != nil {
+				t.Fatal(result.Error)
+			}
+
+			var body map[string]interface{}
+			if err := json.Unmarshal([]byte(tt.body), &body); err != nil {
+				t.Fatal(err)
+			}
+
+			body["id"] = control.ID.ID.String()
+			bodyRaw, _ := json.Marshal(body)
+
+			c, rec := testContext(http.MethodPatch, "/control", string(bodyRaw), "team1")
+			if err := patchControl(c); err != nil {
+				t.Fatal(err)
+			}
+
+			if rec.Code != tt.status {
+				t.Fatalf("patchControl() status = %d, want %d; %s", rec.Code, tt.status, rec.Body.String())
+			}
+
+			got := models.Control{}
+			if result := dbConn.Where("id = ?", control.ID.ID).First(&got); result.Error != nil {
+				t.Fatal(result.Error)
+			}
+
+			if string(got.Groups.Groups) != tt.groups {
+				t.Errorf("groups = %s, want %s", got.Groups.Groups, tt.groups)
+			}
+
+			if string(got.Endpoints.Endpoints) != `{"test":{"methods":["POST"]}}` {
+				t.Errorf("endpoints = %s, want unchanged", got.Endpoints.Endpoints)
+			}
+		})
+	}
+}
@@ -0,0 +1,256 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"net/http"
+
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+	"gorm.io/gorm"
+
+	"github.com/rakunlabs/chore/internal/server/middlewares"
+	"github.com/rakunlabs/chore/pkg/flow"
+	"github.com/rakunlabs/chore/pkg/models"
+	"github.com/rakunlabs/chore/pkg/models/apimodels"
+	"github.com/rakunlabs/chore/pkg/registry"
+)
+
+type MetaRun struct {
+	Control  string `json:"control,omitempty" query:"control"`
+	Endpoint string `json:"endpoint,omitempty" query:"endpoint"`
+	Status   string `json:"status,omitempty" query:"status"`
+	apimodels.Meta
+}
+
+type RunDetail struct {
+	models.Run
+	Nodes []models.RunNode `json:"nodes"`
+}
+
+// @Summary List runs
+// @Tags run
+// @Description Get list of the control flow runs, latest first
+// @Security ApiKeyAuth
+// @Router /runs [get]
+// @Param control query string false "filter by control name"
+// @Param endpoint query string false "filter by endpoint"
+// @Param status query string false "filter by status (running, success, failed, canceled)"
+// @Param limit query int false "set the limit, default is 20"
+// @Param offset query int false "set the offset, default is 0"
+// @Success 200 {object} apimodels.DataMeta{data=[]models.Run{},meta=MetaRun{}}
+// @failure 400 {object} apimodels.Error{}
+// @failure 500 {object} apimodels.Error{}
+func listRuns(c echo.Context) error {
+	runs := []models.Run{}
+
+	meta := &MetaRun{Meta: apimodels.Meta{Limit: apimodels.Limit}}
+
+	if err := c.Bind(meta); err != nil {
+		return c.JSON(http.StatusBadRequest, apimodels.Error{Error: err.Error()})
+	}
+
+	ctx := c.Request().Context()
+	access := middlewares.GetAccess(c)
+
+	filter := func(query *gorm.DB) *gorm.DB {
+		query = query.Scopes(runAccess(ctx, access))
+
+		if meta.Control != "" {
+			query = query.Where("control = ?", meta.Control)
+		}
+
+		if meta.Endpoint != "" {
+			query = query.Where("endpoint = ?", meta.Endpoint)
+		}
+
+		if meta.Status != "" {
+			query = query.Where("status = ?", meta.Status)
+		}
+
+		return query
+	}
+
+	query := registry.Reg.DB.WithContext(ctx).Model(&models.Run{}).Scopes(filter)
+	result := query.Order("started_at DESC").Limit(meta.Limit).Offset(meta.Offset).Find(&runs)
+
+	// check write error
+	if result.Error != nil {
+		return c.JSON(http.StatusInternalServerError, apimodels.Error{Error: result.Error.Error()})
+	}
+
+	// get counts
+	registry.Reg.DB.WithContext(ctx).Model(&models.Run{}).Scopes(filter).Count(&meta.Count)
+
+	return c.JSON(http.StatusOK,
+		apimodels.DataMeta{
+			Meta: meta,
+			Data: apimodels.Data{Data: runs},
+		},
+	)
+}
+
+// @Summary Get run
+// @Tags run
+// @Description Get one run with node execution records
+// @Security ApiKeyAuth
+// @Router /run [get]
+// @Param id query string true "get by id"
+// @Success 200 {object} apimodels.Data{data=RunDetail{}}
+// @failure 400 {object} apimodels.Error{}
+// @failure 404 {object} apimodels.Error{}
+// @failure 500 {object} apimodels.Error{}
+func getRun(c echo.Context) error {
+	id := c.QueryParam("id")
+	if id == "" {
+		return c.JSON(http.StatusBadRequest, apimodels.Error{Error: apimodels.ErrRequiredID.Error()})
+	}
+
+	ctx := c.Request().Context()
+
+	run := RunDetail{}
+
+	result := registry.Reg.DB.WithContext(ctx).Model(&models.Run{}).Where("id = ?", id).
+		Scopes(runAccess(ctx, middlewares.GetAccess(c))).First(&run.Run)
+	if errors.Is(result.Error, gorm.ErrRecordNotFound) {
+		return c.JSON(http.StatusNotFound, apimodels.Error{Error: result.Error.Error()})
+	}
+
+	if result.Error != nil {
+		return c.JSON(http.StatusInternalServerError, apimodels.Error{Error: result.Error.Error()})
+	}
+
+	result = registry.Reg.DB.WithContext(ctx).Model(&models.RunNode{}).Where("run_id = ?", id).Order("started_at").Find(&run.Nodes)
+	if result.Error != nil {
+		return c.JSON(http.StatusInternalServerError, apimodels.Error{Error: result.Error.Error()})
+	}
+
+	return c.JSON(http.StatusOK,
+		apimodels.Data{
+			Data: run,
+		},
+	)
+}
+
+// @Summary List active runs
+// @Tags run
+// @Description Get running control flows in this instance, oldest first
+// @Security ApiKeyAuth
+// @Router /runs/active [get]
+// @Success 200 {object} apimodels.Data{data=[]flow.RunInfo{}}
+// @failure 500 {object} apimodels.Error{}
+func listActiveRuns(c echo.Context) error {
+	runs := flow.ActiveRuns.List()
+
+	access := middlewares.GetAccess(c)
+	if !access.All {
+		names := make([]string, 0, len(runs))
+		for _, run := range runs {
+			names = append(names, run.Control)
+		}
+
+		allowed, err := allowedControls(c.Request().Context(), access, names, false)
+		if err != nil {
+			return c.JSON(http.StatusInternalServerError, apimodels.Error{Error: err.Error()})
+		}
+
+		filtered := make([]flow.RunInfo, 0, len(runs))
+		for _, run := range runs {
+			if _, ok := allowed[run.Control]; ok {
+				filtered = append(filtered, run)
+			}
+		}
+
+		runs = filtered
+	}
+
+	return c.JSON(http.StatusOK,
+		apimodels.Data{
+			Data: runs,
+		},
+	)
+}
+
+// @Summary Cancel run
+// @Tags run
+// @Description Cancel the running control flow with the runs started inside of it
+// @Security ApiKeyAuth
+// @Router /run [delete]
+// @Param id query string true "run id"
+// @Success 204 "No Content"
+// @failure 400 {object} apimodels.Error{}
+// @failure 403 {object} apimodels.Error{}
+// @failure 404 {object} apimodels.Error{}
+// @failure 500 {object} apimodels.Error{}
+func cancelRun(c echo.Context) error {
+	id, err := uuid.Parse(c.QueryParam("id"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, apimodels.Error{Error: apimodels.ErrRequiredID.Error()})
+	}
+
+	nodesReg, ok := flow.ActiveRuns.Get(id)
+	if !ok {
+		return c.JSON(http.StatusNotFound, apimodels.Error{Error: apimodels.ErrNotFound.Error()})
+	}
+
+	access := middlewares.GetAccess(c)
+	if !access.All {
+		control := nodesReg.Info().Control
+
+		allowed, err := allowedControls(c.Request().Context(), access, []string{control}, true)
+		if err != nil {
+			return c.JSON(http.StatusInternalServerError, apimodels.Error{Error: err.Error()})
+		}
+
+		if _, ok := allowed[control]; !ok {
+			return c.JSON(http.StatusForbidden, apimodels.Error{Error: apimodels.ErrForbidden.Error()})
+		}
+	}
+
+	nodesReg.Cancel()
+
+	//nolint:wrapcheck // checking before
+	return c.NoContent(http.StatusNoContent)
+}
+
+// allowedControls returns accessible control names in the list.
+func allowedControls(ctx context.Context, access models.Access, names []string, write bool) (map[string]struct{}, error) {
+	allowed := make(map[string]struct{}, len(names))
+	if len(names) == 0 {
+		return allowed, nil
+	}
+
+	var found []string
+
+	result := registry.Reg.DB.WithContext(ctx).Model(&models.Control{}).Where("name IN ?", names).
+		Scopes(access.Scope(write)).Pluck("name", &found)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+
+	for _, name := range found {
+		allowed[name] = struct{}{}
+	}
+
+	return allowed, nil
+}
+
+// runAccess shows runs of the readable controls.
+func runAccess(ctx context.Context, access models.Access) func(*gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		if access.All {
+			return db
+		}
+
+		readable := registry.Reg.DB.WithContext(ctx).Model(&models.Control{}).Select("name").Scopes(access.Scope(false))
+
+		return db.Where("control IN (?)", readable)
+	}
+}
+
+func History(e *echo.Group, authMiddleware echo.MiddlewareFunc) {
+	e.GET("/runs", listRuns, authMiddleware, middlewares.UserRole, middlewares.PatToken)
+	e.GET("/runs/active", listActiveRuns, authMiddleware, middlewares.UserRole, middlewares.PatToken)
+	e.GET("/run", getRun, authMiddleware, middlewares.UserRole, middlewares.PatToken)
+	e.DELETE("/run", cancelRun, authMiddleware, middlewares.UserRole, middlewares.PatToken)
+}
@@ -0,0 +1,180 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+	"gorm.io/gorm"
+
+	"github.com/rakunlabs/chore/internal/server/middlewares"
+	"github.com/rakunlabs/chore/pkg/models"
+	"github.com/rakunlabs/chore/pkg/models/apimodels"
+	"github.com/rakunlabs/chore/pkg/queue"
+	"github.com/rakunlabs/chore/pkg/registry"
+)
+
+// HeaderJobID returns the job id of the queued call.
+var HeaderJobID = "X-Job-Id"
+
+var errQueueDisabled = errors.New("queue is not enabled")
+
+// jobSkipHeaders are credentials, not kept in the job table.
+var jobSkipHeaders = []string{"Authorization", "Cookie", "Proxy-Authorization"}
+
+type MetaJob struct {
+	Control string `json:"control,omitempty" query:"control"`
+	Status  string `json:"status,omitempty" query:"status"`
+	apimodels.Meta
+}
+
+// enqueue adds the send call to the job queue.
+func enqueue(ctx context.Context, c echo.Context, control, endpoint string, body []byte) error {
+	if queue.GlobalQueue == nil {
+		return c.JSON(http.StatusServiceUnavailable, apimodels.Error{Error: errQueueDisabled.Error()})
+	}
+
+	header := c.Request().Header.Clone()
+	for _, k := range jobSkipHeaders {
+		header.Del(k)
+	}
+
+	// keep request id of the call for the flow
+	if id := c.Response().Header().Get(echo.HeaderXRequestID); id != "" {
+		header.Set(echo.HeaderXRequestID, id)
+	}
+
+	headerRaw, err := json.Marshal(header)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, apimodels.Error{Error: err.Error()})
+	}
+
+	// url with host, server side url has only path and query
+	u := *c.Request().URL
+	u.Host = c.Request().Host
+
+	job := models.Job{
+		JobPure: models.JobPure{
+			Control:  control,
+			Endpoint: endpoint,
+			Method:   c.Request().Method,
+			Header:   headerRaw,
+			URL:      u.String(),
+			RemoteIP: c.RealIP(),
+			Body:     body,
+		},
+	}
+
+	if err := queue.GlobalQueue.Enqueue(ctx, &job); err != nil {
+		return c.JSON(http.StatusInternalServerError, apimodels.Error{Error: err.Error()})
+	}
+
+	c.Response().Header().Set(HeaderJobID, job.ID.ID.String())
+
+	return c.JSON(http.StatusAccepted,
+		apimodels.Data{
+			Data: job,
+		},
+	)
+}
+
+// @Summary List jobs
+// @Tags run
+// @Description Get list of the queued calls, latest first
+// @Security ApiKeyAuth
+// @Router /jobs [get]
+// @Param control query string false "filter by control name"
+// @Param status query string false "filter by status (pending, running, done, failed)"
+// @Param limit query int false "set the limit, default is 20"
+// @Param offset query int false "set the offset, default is 0"
+// @Success 200 {object} apimodels.DataMeta{data=[]models.Job{},meta=MetaJob{}}
+// @failure 400 {object} apimodels.Error{}
+// @failure 500 {object} apimodels.Error{}
+func listJobs(c echo.Context) error {
+	jobs := []models.Job{}
+
+	meta := &MetaJob{Meta: apimodels.Meta{Limit: apimodels.Limit}}
+
+	if err := c.Bind(meta); err != nil {
+		return c.JSON(http.StatusBadRequest, apimodels.Error{Error: err.Error()})
+	}
+
+	ctx := c.Request().Context()
+	access := middlewares.GetAccess(c)
+
+	filter := func(query *gorm.DB) *gorm.DB {
+		query = query.Scopes(runAccess(ctx, access))
+
+		if meta.Control != "" {
+			query = query.Where("control = ?", meta.Control)
+		}
+
+		if meta.Status != "" {
+			query = query.Where("status = ?", meta.Status)
+		}
+
+		return query
+	}
+
+	query := registry.Reg.DB.WithContext(ctx).Model(&models.Job{}).Scopes(filter).Omit("body")
+	result := query.Order("created_at DESC").Limit(meta.Limit).Offset(meta.Offset).Find(&jobs)
+
+	// check write error
+	if result.Error != nil {
+		return c.JSON(http.StatusInternalServerError, apimodels.Error{Error: result.Error.Error()})
+	}
+
+	// get counts
+	registry.Reg.DB.WithContext(ctx).Model(&models.Job{}).Scopes(filter).Count(&meta.Count)
+
+	return c.JSON(http.StatusOK,
+		apimodels.DataMeta{
+			Meta: meta,
+			Data: apimodels.Data{Data: jobs},
+		},
+	)
+}
+
+// @Summary Get job
+// @Tags run
+// @Description Get one queued call, run_id shows the last run
+// @Security ApiKeyAuth
+// @Router /job [get]
+// @Param id query string true "get by id"
+// @Success 200 {object} apimodels.Data{data=models.Job{}}
+// @failure 400 {object} apimodels.Error{}
+// @failure 404 {object} apimodels.Error{}
+// @failure 500 {object} apimodels.Error{}
+func getJob(c echo.Context) error {
+	id := c.QueryParam("id")
+	if id == "" {
+		return c.JSON(http.StatusBadRequest, apimodels.Error{Error: apimodels.ErrRequiredID.Error()})
+	}
+
+	ctx := c.Request().Context()
+
+	job := models.Job{}
+
+	result := registry.Reg.DB.WithContext(ctx).Model(&models.Job{}).Where("id = ?", id).
+		Scopes(runAccess(ctx, middlewares.GetAccess(c))).Omit("body").First(&job)
+	if errors.Is(result.Error, gorm.ErrRecordNotFound) {
+		return c.JSON(http.StatusNotFound, apimodels.Error{Error: result.Error.Error()})
+	}
+
+	if result.Error != nil {
+		return c.JSON(http.StatusInternalServerError, apimodels.Error{Error: result.Error.Error()})
+	}
+
+	return c.JSON(http.StatusOK,
+		apimodels.Data{
+			Data: job,
+		},
+	)
+}
+
+func Job(e *echo.Group, authMiddleware echo.MiddlewareFunc) {
+	e.GET("/jobs", listJobs, authMiddleware, middlewares.UserRole, middlewares.PatToken)
+	e.GET("/job", getJob, authMiddleware, middlewares.UserRole, middlewares.PatToken)
+}
@@ -0,0 +1,662 @@
+package api
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+	"github.com/rs/zerolog/log"
+	"gorm.io/datatypes"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"github.com/rakunlabs/chore/internal/server/middlewares"
+	"github.com/rakunlabs/chore/internal/utils"
+	"github.com/rakunlabs/chore/pkg/models"
+	"github.com/rakunlabs/chore/pkg/models/apimodels"
+	"github.com/rakunlabs/chore/pkg/registry"
+)
+
+// RevisionCurrent is used in diff to compare with the current record.
+var RevisionCurrent = "current"
+
+type MetaRevision struct {
+	Name string `json:"name" query:"name" example:"deepcore"`
+	apimodels.Meta
+}
+
+// saveControlRevision records previous state of the control with the author of that state.
+func saveControlRevision(ctx context.Context, prev *models.Control) {
+	revision := models.ControlRevision{
+		ControlRevisionPure: models.ControlRevisionPure{
+			Control:   prev.Name,
+			Content:   prev.Content,
+			Endpoints: prev.Endpoints,
+			Groups:    prev.Groups,
+			Author:    prev.Author,
+		},
+		ModelC: apimodels.ModelC{ID: apimodels.ID{ID: uuid.New()}},
+	}
+
+	if result := registry.Reg.DB.WithContext(ctx).Create(&revision); result.Error != nil {
+		log.Ctx(ctx).Error().Err(result.Error).Str("control", prev.Name).Msg("cannot record control revision")
+	}
+}
+
+// saveTemplateRevision records previous state of the template with the author of that state.
+func saveTemplateRevision(ctx context.Context, prev *models.Template) {
+	revision := models.TemplateRevision{
+		TemplateRevisionPure: models.TemplateRevisionPure{
+			Template: prev.Name,
+			Content:  prev.Content,
+			Groups:   prev.Groups,
+			Author:   prev.Author,
+		},
+		ModelC: apimodels.ModelC{ID: apimodels.ID{ID: uuid.New()}},
+	}
+
+	if result := registry.Reg.DB.WithContext(ctx).Create(&revision); result.Error != nil {
+		log.Ctx(ctx).Error().Err(result.Error).Str("template", prev.Name).Msg("cannot record template revision")
+	}
+}
+
+// diffContent decodes base64 contents and returns line difference.
+// JSON contents indented to get meaningful lines.
+func diffContent(from, to string) (string, error) {
+	fromRaw, err := base64.StdEncoding.DecodeString(from)
+	if err != nil {
+		return "", err
+	}
+
+	toRaw, err := base64.StdEncoding.DecodeString(to)
+	if err != nil {
+		return "", err
+	}
+
+	return utils.Diff(indentJSON(fromRaw), indentJSON(toRaw)), nil
+}
+
+func indentJSON(v []byte) string {
+	buf := bytes.Buffer{}
+	if err := json.Indent(&buf, v, "", "  "); err != nil {
+		return string(v)
+	}
+
+	return buf.String()
+}
+
+// controlRevisionGroups returns the groups to authorize revisions of the control.
+// Revisions follow the current groups of the control, the latest revision's groups are used after the control is deleted.
+func controlRevisionGroups(ctx context.Context, name string) (datatypes.JSON, error) {
+	current := models.Control{}
+
+	result := registry.Reg.DB.WithContext(ctx).Omit("content").Where("name = ?", name).Limit(1).Find(&current)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+
+	if result.RowsAffected > 0 {
+		return current.Groups.Groups, nil
+	}
+
+	latest := models.ControlRevision{}
+
+	result = registry.Reg.DB.WithContext(ctx).Omit("content").Where("control = ?", name).Order("created_at DESC").Limit(1).Find(&latest)
+
+	return latest.Groups.Groups, result.Error
+}
+
+// templateRevisionGroups returns the groups to authorize revisions of the template.
+// Revisions follow the current groups of the template, the latest revision's groups are used after the template is deleted.
+func templateRevisionGroups(ctx context.Context, name string) (datatypes.JSON, error) {
+	current := models.Template{}
+
+	result := registry.Reg.DB.WithContext(ctx).Omit("content").Where("name = ?", name).Limit(1).Find(&current)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+
+	if result.RowsAffected > 0 {
+		return current.Groups.Groups, nil
+	}
+
+	latest := models.TemplateRevision{}
+
+	result = registry.Reg.DB.WithContext(ctx).Omit("content").Where("template = ?", name).Order("created_at DESC").Limit(1).Find(&latest)
+
+	return latest.Groups.Groups, result.Error
+}
+
+// getControlRevisionAccess returns the revision when the requester can access the control.
+func getControlRevisionAccess(ctx context.Context, id string, access models.Access, write bool) (models.ControlRevision, error) {
+	revision := models.ControlRevision{}
+
+	if result := registry.Reg.DB.WithContext(ctx).Where("id = ?", id).First(&revision); result.Error != nil {
+		return revision, result.Error
+	}
+
+	groups, err := controlRevisionGroups(ctx, revision.Control)
+	if err != nil {
+		return revision, err
+	}
+
+	if !access.Can(groups, write) {
+		return revision, gorm.ErrRecordNotFound
+	}
+
+	return revision, nil
+}
+
+// getTemplateRevisionAccess returns the revision when the requester can access the template.
+func getTemplateRevisionAccess(ctx context.Context, id string, access models.Access, write bool) (models.TemplateRevision, error) {
+	revision := models.TemplateRevision{}
+
+	if result := registry.Reg.DB.WithContext(ctx).Where("id = ?", id).First(&revision); result.Error != nil {
+		return revision, result.Error
+	}
+
+	groups, err := templateRevisionGroups(ctx, revision.Template)
+	if err != nil {
+		return revision, err
+	}
+
+	if !access.Can(groups, write) {
+		return revision, gorm.ErrRecordNotFound
+	}
+
+	return revision, nil
+}
+
+// @Summary List control revisions
+// @Tags control
+// @Description Get list of the previous versions of a control, latest first
+// @Security ApiKeyAuth
+// @Router /control/revisions [get]
+// @Param name query string true "control name"
+// @Param limit query int false "set the limit, default is 20"
+// @Param offset query int false "set the offset, default is 0"
+// @Success 200 {object} apimodels.DataMeta{data=[]models.ControlRevision{},meta=MetaRevision{}}
+// @failure 400 {object} apimodels.Error{}
+// @failure 403 {object} apimodels.Error{}
+// @failure 500 {object} apimodels.Error{}
+func listControlRevisions(c echo.Context) error {
+	revisions := []models.ControlRevision{}
+
+	meta := &MetaRevision{Meta: apimodels.Meta{Limit: apimodels.Limit}}
+
+	if err := c.Bind(meta); err != nil {
+		return c.JSON(http.StatusBadRequest, apimodels.Error{Error: err.Error()})
+	}
+
+	if meta.Name == "" {
+		return c.JSON(http.StatusBadRequest, apimodels.Error{Error: apimodels.ErrRequiredName.Error()})
+	}
+
+	ctx := c.Request().Context()
+
+	groups, err := controlRevisionGroups(ctx, meta.Name)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, apimodels.Error{Error: err.Error()})
+	}
+
+	if !middlewares.GetAccess(c).Can(groups, false) {
+		return c.JSON(http.StatusForbidden, apimodels.Error{Error: apimodels.ErrForbidden.Error()})
+	}
+
+	query := registry.Reg.DB.WithContext(ctx).Model(&models.ControlRevision{}).Where("control = ?", meta.Name)
+	result := query.Omit("content").Order("created_at DESC").Limit(meta.Limit).Offset(meta.Offset).Find(&revisions)
+
+	if result.Error != nil {
+		return c.JSON(http.StatusInternalServerError, apimodels.Error{Error: result.Error.Error()})
+	}
+
+	// get counts
+	registry.Reg.DB.WithContext(ctx).Model(&models.ControlRevision{}).Where("control = ?", meta.Name).Count(&meta.Count)
+
+	return c.JSON(http.StatusOK,
+		apimodels.DataMeta{
+			Meta: meta,
+			Data: apimodels.Data{Data: revisions},
+		},
+	)
+}
+
+// @Summary Get control revision
+// @Tags control
+// @Description Get one revision of a control with content
+// @Security ApiKeyAuth
+// @Router /control/revision [get]
+// @Param id query string true "revision id"
+// @Success 200 {object} apimodels.Data{data=models.ControlRevision{}}
+// @failure 400 {object} apimodels.Error{}
+// @failure 404 {object} apimodels.Error{}
+// @failure 500 {object} apimodels.Error{}
+func getControlRevision(c echo.Context) error {
+	id := c.QueryParam("id")
+	if id == "" {
+		return c.JSON(http.StatusBadRequest, apimodels.Error{Error: apimodels.ErrRequiredID.Error()})
+	}
+
+	revision, err := getControlRevisionAccess(c.Request().Context(), id, middlewares.GetAccess(c), false)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return c.JSON(http.StatusNotFound, apimodels.Error{Error: err.Error()})
+	}
+
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, apimodels.Error{Error: err.Error()})
+	}
+
+	return c.JSON(http.StatusOK, apimodels.Data{Data: revision})
+}
+
+// @Summary Diff control revisions
+// @Tags control
+// @Description Line difference of two revisions' content, use "current" for the current control
+// @Security ApiKeyAuth
+// @Router /control/revision/diff [get]
+// @Param from query string true "revision id"
+// @Param to query string false "revision id, default is current"
+// @Produce plain
+// @Success 200 {object} string "diff result"
+// @failure 400 {object} apimodels.Error{}
+// @failure 404 {object} apimodels.Error{}
+// @failure 500 {object} apimodels.Error{}
+func diffControlRevision(c echo.Context) error {
+	from := c.QueryParam("from")
+	to := c.QueryParam("to")
+
+	if from == "" {
+		return c.JSON(http.StatusBadRequest, apimodels.Error{Error: apimodels.ErrRequiredID.Error()})
+	}
+
+	if to == "" {
+		to = RevisionCurrent
+	}
+
+	ctx := c.Request().Context()
+	access := middlewares.GetAccess(c)
+
+	fromRevision, err := getControlRevisionAccess(ctx, from, access, false)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return c.JSON(http.StatusNotFound, apimodels.Error{Error: err.Error()})
+	}
+
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, apimodels.Error{Error: err.Error()})
+	}
+
+	var toContent string
+
+	if to == RevisionCurrent {
+		// access checked with the current groups of the control
+		current := models.Control{}
+		err = registry.Reg.DB.WithContext(ctx).Where("name = ?", fromRevision.Control).First(&current).Error
+		toContent = current.Content
+	} else {
+		var toRevision models.ControlRevision
+		toRevision, err = getControlRevisionAccess(ctx, to, access, false)
+		toContent = toRevision.Content
+	}
+
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return c.JSON(http.StatusNotFound, apimodels.Error{Error: err.Error()})
+	}
+
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, apimodels.Error{Error: err.Error()})
+	}
+
+	diff, err := diffContent(fromRevision.Content, toContent)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, apimodels.Error{Error: err.Error()})
+	}
+
+	return c.String(http.StatusOK, diff)
+}
+
+// @Summary Restore control revision
+// @Tags control
+// @Description Restore content and endpoints of the revision, current state recorded as new revision
+// @Security ApiKeyAuth
+// @Router /control/revision/restore [post]
+// @Param id query string true "revision id"
+// @Success 204 "No Content"
+// @failure 400 {object} ControlValidationError{}
+// @failure 403 {object} apimodels.Error{}
+// @failure 404 {object} apimodels.Error{}
+// @failure 500 {object} apimodels.Error{}
+func restoreControlRevision(c echo.Context) error {
+	id := c.QueryParam("id")
+	if id == "" {
+		return c.JSON(http.StatusBadRequest, apimodels.Error{Error: apimodels.ErrRequiredID.Error()})
+	}
+
+	ctx := utils.Context(c)
+	access := middlewares.GetAccess(c)
+
+	revision, err := getControlRevisionAccess(ctx, id, access, true)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return c.JSON(http.StatusNotFound, apimodels.Error{Error: err.Error()})
+	}
+
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, apimodels.Error{Error: err.Error()})
+	}
+
+	prev := models.Control{}
+
+	result := registry.Reg.DB.WithContext(ctx).Where("name = ?", revision.Control).Limit(1).Find(&prev)
+	if result.Error != nil {
+		return c.JSON(http.StatusInternalServerError, apimodels.Error{Error: result.Error.Error()})
+	}
+
+	exist := result.RowsAffected > 0
+
+	// restored content runs with the current groups, deleted control gets the recorded groups
+	groups := revision.Groups.Groups
+	if exist {
+		groups = prev.Groups.Groups
+	}
+
+	validation, err := validateControl(ctx, revision.Content, groups)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, apimodels.Error{Error: err.Error()})
+	}
+
+	if validation.HasError() {
+		return c.JSON(http.StatusBadRequest, ControlValidationError{
+			Error:      apimodels.Error{Error: errControlValidation.Error()},
+			Validation: validation,
+		})
+	}
+
+	if !exist {
+		if !access.CanAssign(revision.Groups.Groups, nil) {
+			return c.JSON(http.StatusForbidden, apimodels.Error{Error: apimodels.ErrGroupAssign.Error()})
+		}
+
+		// deleted control comes back with the recorded groups
+		control := models.Control{
+			ControlPureContent: models.ControlPureContent{
+				Content: revision.Content,
+				ControlPure: models.ControlPure{
+					Name:      revision.Control,
+					Endpoints: revision.Endpoints,
+					Groups:    revision.Groups,
+				},
+			},
+			Author:  middlewares.Subject(c),
+			ModelCU: apimodels.ModelCU{ID: apimodels.ID{ID: uuid.New()}},
+		}
+
+		result = registry.Reg.DB.WithContext(ctx).Create(&control)
+	} else {
+		if !access.Can(prev.Groups.Groups, true) {
+			return c.JSON(http.StatusForbidden, apimodels.Error{Error: apimodels.ErrForbidden.Error()})
+		}
+
+		result = registry.Reg.DB.WithContext(ctx).Model(&models.Control{}).Where("id = ?", prev.ID.ID).Updates(map[string]interface{}{
+			"content":   revision.Content,
+			"endpoints": revision.Endpoints.Endpoints,
+			"author":    middlewares.Subject(c),
+		})
+	}
+
+	if result.Error != nil {
+		return c.JSON(http.StatusInternalServerError, apimodels.Error{Error: result.Error.Error()})
+	}
+
+	if prev.Name != "" {
+		saveControlRevision(ctx, &prev)
+	}
+
+	reloadSchedules(ctx)
+
+	//nolint:wrapcheck // checking before
+	return c.NoContent(http.StatusNoContent)
+}
+
+// @Summary List template revisions
+// @Tags template
+// @Description Get list of the previous versions of a template, latest first
+// @Security ApiKeyAuth
+// @Router /template/revisions [get]
+// @Param name query string true "template name"
+// @Param limit query int false "set the limit, default is 20"
+// @Param offset query int false "set the offset, default is 0"
+// @Success 200 {object} apimodels.DataMeta{data=[]models.TemplateRevision{},meta=MetaRevision{}}
+// @failure 400 {object} apimodels.Error{}
+// @failure 403 {object} apimodels.Error{}
+// @failure 500 {object} apimodels.Error{}
+func listTemplateRevisions(c echo.Context) error {
+	revisions := []models.TemplateRevision{}
+
+	meta := &MetaRevision{Meta: apimodels.Meta{Limit: apimodels.Limit}}
+
+	if err := c.Bind(meta); err != nil {
+		return c.JSON(http.StatusBadRequest, apimodels.Error{Error: err.Error()})
+	}
+
+	if meta.Name == "" {
+		return c.JSON(http.StatusBadRequest, apimodels.Error{Error: apimodels.ErrRequiredName.Error()})
+	}
+
+	ctx := c.Request().Context()
+
+	groups, err := templateRevisionGroups(ctx, meta.Name)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, apimodels.Error{Error: err.Error()})
+	}
+
+	if !middlewares.GetAccess(c).Can(groups, false) {
+		return c.JSON(http.StatusForbidden, apimodels.Error{Error: apimodels.ErrForbidden.Error()})
+	}
+
+	query := registry.Reg.DB.WithContext(ctx).Model(&models.TemplateRevision{}).Where("template = ?", meta.Name)
+	result := query.Omit("content").Order("created_at DESC").Limit(meta.Limit).Offset(meta.Offset).Find(&revisions)
+
+	if result.Error != nil {
+		return c.JSON(http.StatusInternalServerError, apimodels.Error{Error: result.Error.Error()})
+	}
+
+	// get counts
+	registry.Reg.DB.WithContext(ctx).Model(&models.TemplateRevision{}).Where("template = ?", meta.Name).Count(&meta.Count)
+
+	return c.JSON(http.StatusOK,
+		apimodels.DataMeta{
+			Meta: meta,
+			Data: apimodels.Data{Data: revisions},
+		},
+	)
+}
+
+// @Summary Get template revision
+// @Tags template
+// @Description Get one revision of a template with content
+// @Security ApiKeyAuth
+// @Router /template/revision [get]
+// @Param id query string true "revision id"
+// @Success 200 {object} apimodels.Data{data=models.TemplateRevision{}}
+// @failure 400 {object} apimodels.Error{}
+// @failure 404 {object} apimodels.Error{}
+// @failure 500 {object} apimodels.Error{}
+func getTemplateRevision(c echo.Context) error {
+	id := c.QueryParam("id")
+	if id == "" {
+		return c.JSON(http.StatusBadRequest, apimodels.Error{Error: apimodels.ErrRequiredID.Error()})
+	}
+
+	revision, err := getTemplateRevisionAccess(c.Request().Context(), id, middlewares.GetAccess(c), false)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return c.JSON(http.StatusNotFound, apimodels.Error{Error: err.Error()})
+	}
+
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, apimodels.Error{Error: err.Error()})
+	}
+
+	return c.JSON(http.StatusOK, apimodels.Data{Data: revision})
+}
+
+// @Summary Diff template revisions
+// @Tags template
+// @Description Line difference of two revisions' content, use "current" for the current template
+// @Security ApiKeyAuth
+// @Router /template/revision/diff [get]
+// @Param from query string true "revision id"
+// @Param to query string false "revision id, default is current"
+// @Produce plain
+// @Success 200 {object} string "diff result"
+// @failure 400 {object} apimodels.Error{}
+// @failure 404 {object} apimodels.Error{}
+// @failure 500 {object} apimodels.Error{}
+func diffTemplateRevision(c echo.Context) error {
+	from := c.QueryParam("from")
+	to := c.QueryParam("to")
+
+	if from == "" {
+		return c.JSON(http.StatusBadRequest, apimodels.Error{Error: apimodels.ErrRequiredID.Error()})
+	}
+
+	if to == "" {
+		to = RevisionCurrent
+	}
+
+	ctx := c.Request().Context()
+	access := middlewares.GetAccess(c)
+
+	fromRevision, err := getTemplateRevisionAccess(ctx, from, access, false)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return c.JSON(http.StatusNotFound, apimodels.Error{Error: err.Error()})
+	}
+
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, apimodels.Error{Error: err.Error()})
+	}
+
+	var toContent string
+
+	if to == RevisionCurrent {
+		// access checked with the current groups of the template
+		current := models.Template{}
+		err = registry.Reg.DB.WithContext(ctx).Where("name = ?", fromRevision.Template).First(&current).Error
+		toContent = current.Content
+	} else {
+		var toRevision models.TemplateRevision
+		toRevision, err = getTemplateRevisionAccess(ctx, to, access, false)
+		toContent = toRevision.Content
+	}
+
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return c.JSON(http.StatusNotFound, apimodels.Error{Error: err.Error()})
+	}
+
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, apimodels.Error{Error: err.Error()})
+	}
+
+	diff, err := diffContent(fromRevision.Content, toContent)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, apimodels.Error{Error: err.Error()})
+	}
+
+	return c.String(http.StatusOK, diff)
+}
+
+// @Summary Restore template revision
+// @Tags template
+// @Description Restore content of the revision, current state recorded as new revision
+// @Security ApiKeyAuth
+// @Router /template/revision/restore [post]
+// @Param id query string true "revision id"
+// @Success 204 "No Content"
+// @failure 400 {object} apimodels.Error{}
+// @failure 403 {object} apimodels.Error{}
+// @failure 404 {object} apimodels.Error{}
+// @failure 500 {object} apimodels.Error{}
+func restoreTemplateRevision(c echo.Context) error {
+	id := c.QueryParam("id")
+	if id == "" {
+		return c.JSON(http.StatusBadRequest, apimodels.Error{Error: apimodels.ErrRequiredID.Error()})
+	}
+
+	ctx := utils.Context(c)
+	access := middlewares.GetAccess(c)
+
+	revision, err := getTemplateRevisionAccess(ctx, id, access, true)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return c.JSON(http.StatusNotFound, apimodels.Error{Error: err.Error()})
+	}
+
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, apimodels.Error{Error: err.Error()})
+	}
+
+	prev := models.Template{}
+
+	result := registry.Reg.DB.WithContext(ctx).Where("name = ?", revision.Template).Limit(1).Find(&prev)
+	if result.Error != nil {
+		return c.JSON(http.StatusInternalServerError, apimodels.Error{Error: result.Error.Error()})
+	}
+
+	if result.RowsAffected == 0 {
+		if !access.CanAssign(revision.Groups.Groups, nil) {
+			return c.JSON(http.StatusForbidden, apimodels.Error{Error: apimodels.ErrGroupAssign.Error()})
+		}
+
+		// deleted template comes back with the recorded groups
+		template := models.Template{
+			TemplatePure: models.TemplatePure{
+				Name:    revision.Template,
+				Content: revision.Content,
+				Groups:  revision.Groups,
+			},
+			Author:  middlewares.Subject(c),
+			ModelCU: apimodels.ModelCU{ID: apimodels.ID{ID: uuid.New()}},
+		}
+
+		result = registry.Reg.DB.WithContext(ctx).Create(&template)
+		if result.Error == nil {
+			// on conflict do nothing
+			registry.Reg.DB.WithContext(ctx).Model(models.Folder{}).Clauses(
+				clause.OnConflict{DoNothing: true},
+			).Create(utils.FolderFile(template.Name))
+		}
+	} else {
+		if !access.Can(prev.Groups.Groups, true) {
+			return c.JSON(http.StatusForbidden, apimodels.Error{Error: apimodels.ErrForbidden.Error()})
+		}
+
+		result = registry.Reg.DB.WithContext(ctx).Model(&models.Template{}).Where("id = ?", prev.ID.ID).Updates(map[string]interface{}{
+			"content": revision.Content,
+			"author":  middlewares.Subject(c),
+		})
+	}
+
+	if result.Error != nil {
+		return c.JSON(http.StatusInternalServerError, apimodels.Error{Error: result.Error.Error()})
+	}
+
+	if prev.Name != "" {
+		saveTemplateRevision(ctx, &prev)
+	}
+
+	//nolint:wrapcheck // checking before
+	return c.NoContent(http.StatusNoContent)
+}
+
+func Revision(e *echo.Group, authMiddleware echo.MiddlewareFunc) {
+	e.GET("/control/revisions", listControlRevisions, authMiddleware, middlewares.UserRole, middlewares.PatToken)
+	e.GET("/control/revision", getControlRevision, authMiddleware, middlewares.UserRole, middlewares.PatToken)
+	e.GET("/control/revision/diff", diffControlRevision, authMiddleware, middlewares.UserRole, middlewares.PatToken)
+	e.POST("/control/revision/restore", restoreControlRevision, authMiddleware, middlewares.UserRole, middlewares.PatToken)
+	e.GET("/template/revisions", listTemplateRevisions, authMiddleware, middlewares.UserRole, middlewares.PatToken)
+	e.GET("/template/revision", getTemplateRevision, authMiddleware, middlewares.UserRole, middlewares.PatToken)
+	e.GET("/template/revision/diff", diffTemplateRevision, authMiddleware, middlewares.UserRole, middlewares.PatToken)
+	e.POST("/template/revision/restore", restoreTemplateRevision, authMiddleware, middlewares.UserRole, middlewares.PatToken)
+}
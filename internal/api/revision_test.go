@@ -0,0 +1,162 @@
+package api
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/datatypes"
+	"gorm.io/gorm"
+
+	"github.com/rakunlabs/chore/pkg/models"
+	"github.com/rakunlabs/chore/pkg/models/apimodels"
+)
+
+func createControlRevision(t *testing.T, dbConn *gorm.DB, control, groups string, createdAt time.Time) string {
+	t.Helper()
+
+	revision := models.ControlRevision{
+		ControlRevisionPure: models.ControlRevisionPure{
+			Control: control,
+			Content: "e30=",
+			Groups:  apimodels.Groups{Groups: datatypes.JSON(groups)},
+		},
+		ModelC: apimodels.ModelC{CreatedAt: createdAt, ID: apimodels.ID{ID: uuid.New()}},
+	}
+
+	if result := dbConn.Create(&revision); result.Error != nil {
+		t.Fatal(result.Error)
+	}
+
+	return revision.ID.ID.String()
+}
+
+func TestControlRevisionAccess(t *testing.T) {
+	dbConn := testDB(t)
+
+	now := time.Now()
+
+	// control moved from team1 to team2
+	control := models.Control{}
+	control.ID.ID = uuid.New()
+	control.Name = "moved"
+	control.Content = "e30="
+	control.Groups = apimodels.Groups{Groups: datatypes.JSON(`["team2"]`)}
+
+	if result := dbConn.Create(&control); result.Error != nil {
+		t.Fatal(result.Error)
+	}
+
+	movedID := createControlRevision(t, dbConn, "moved", `["team1"]`, now)
+
+	// deleted control, latest revision has team2
+	deletedID := createControlRevision(t, dbConn, "deleted", `["team1"]`, now.Add(-time.Minute))
+	createControlRevision(t, dbConn, "deleted", `["team2"]`, now)
+
+	tests := []struct {
+		name       string
+		control    string
+		id         string
+		group      string
+		listStatus int
+		getStatus  int
+	}{
+		{name: "current groups", control: "moved", id: movedID, group: "team2", listStatus: http.StatusOK, getStatus: http.StatusOK},
+		{name: "old revision groups", control: "moved", id: movedID, group: "team1", listStatus: http.StatusForbidden, getStatus: http.StatusNotFound},
+		{name: "deleted latest groups", control: "deleted", id: deletedID, group: "team2", listStatus: http.StatusOK, getStatus: http.StatusOK},
+		{name: "deleted old revision groups", control: "deleted", id: deletedID, group: "team1", listStatus: http.StatusForbidden, getStatus: http.StatusNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c, rec := testContext(http.MethodGet, "/control/revisions?name="+tt.control, "", tt.group)
+			if err := listControlRevisions(c); err != nil {
+				t.Fatal(err)
+			}
+
+			if rec.Code != tt.listStatus {
+				t.Errorf("listControlRevisions() status = %d, want %d; %s", rec.Code, tt.listStatus, rec.Body.String())
+			}
+
+			c, rec = testContext(http.MethodGet, "/control/revision?id="+tt.id, "", tt.group)
+			if err := getControlRevision(c); err != nil {
+				t.Fatal(err)
+			}
+
+			if rec.Code != tt.getStatus {
+				t.Errorf("getControlRevision() status = %d, want %d; %s", rec.Code, tt.getStatus, rec.Body.String())
+			}
+
+			c, rec = testContext(http.MethodGet, "/control/revision/diff?from="+tt.id+"&to="+tt.id, "", tt.group)
+			if err := diffControlRevision(c); err != nil {
+				t.Fatal(err)
+			}
+
+			if rec.Code != tt.getStatus {
+				t.Errorf("diffControlRevision() status = %d, want %d; %s", rec.Code, tt.getStatus, rec.Body.String())
+			}
+		})
+	}
+}
+
+func TestRestoreControlRevisionValidation(t *testing.T) {
+	dbConn := testDB(t)
+
+	control := models.Control{}
+	control.ID.ID = uuid.New()
+	control.Name = "restore"
+	control.Content = "e30="
+	control.Groups = apimodels.Groups{Groups: datatypes.JSON(`["team1"]`)}
+
+	if result := dbConn.Create(&control); result.Error != nil {
+		t.Fatal(result.Error)
+	}
+
+	validID := createControlRevision(t, dbConn, "restore", `["team1"]`, time.Now())
+
+	invalid := models.ControlRevision{
+		ControlRevisionPure: models.ControlRevisionPure{
+			Control: "restore",
+			// {"1":{"name":"unknown-node","data":{}}}
+			Content: "eyIxIjp7Im5hbWUiOiJ1bmtub3duLW5vZGUiLCJkYXRhIjp7fX19",
+			Groups:  apimodels.Groups{Groups: datatypes.JSON(`["team1"]`)},
+		},
+		ModelC: apimodels.ModelC{CreatedAt: time.Now(), ID: apimodels.ID{ID: uuid.New()}},
+	}
+
+	if result := dbConn.Create(&invalid); result.Error != nil {
+		t.Fatal(result.Error)
+	}
+
+	tests := []struct {
+		name   string
+		id     string
+		status int
+	}{
+		{name: "invalid content", id: invalid.ID.ID.String(), status: http.StatusBadRequest},
+		{name: "valid content", id: validID, status: http.StatusNoContent},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c, rec := testContext(http.MethodPost, "/control/revision/restore?id="+tt.id, "", "team1")
+			if err := restoreControlRevision(c); err != nil {
+				t.Fatal(err)
+			}
+
+			if rec.Code != tt.status {
+				t.Fatalf("restoreControlRevision() status = %d, want %d; %s", rec.Code, tt.status, rec.Body.String())
+			}
+
+			got := models.Control{}
+			if result := dbConn.Where("name = ?", "restore").First(&got); result.Error != nil {
+				t.Fatal(result.Error)
+			}
+
+			if got.Content != "e30=" {
+				t.Errorf("content = %s, want e30=", got.Content)
+			}
+		})
+	}
+}
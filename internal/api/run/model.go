@@ -1,6 +1,9 @@
 package run
 
-import "time"
+import (
+	"encoding/json"
+	"time"
+)
 
 var defaultTimeout = 30 * time.Second
 
@@ -23,3 +26,18 @@ type settings struct {
 	TimeoutDuration time.Duration `json:"-"`
 	Async           bool          `json:"async"`
 }
+
+type templateModel struct {
+	// Template is inline template content.
+	Template string `json:"template" example:"Hello {{.name}}"`
+	// Name of the stored template, used when template is empty.
+	Name string `json:"name" example:"hello"`
+	// Values could be YAML/JSON string or object.
+	Values json.RawMessage `json:"values" swaggertype:"object"`
+}
+
+type templateError struct {
+	Message string `json:"message" example:"template: :1: function \"nme\" not defined"`
+	Line    int    `json:"line,omitempty" example:"1"`
+	Column  int    `json:"column,omitempty" example:"9"`
+}
@@ -1,19 +1,31 @@
 package run
 
 import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
 	"errors"
+	"fmt"
 	"net/http"
+	"regexp"
+	"strconv"
 	"time"
 
 	"github.com/labstack/echo/v4"
 	"github.com/rs/zerolog/log"
+	"github.com/rytsh/mugo/pkg/templatex"
+	"gorm.io/gorm"
 
 	"github.com/rakunlabs/chore/internal/server/middlewares"
+	"github.com/rakunlabs/chore/pkg/models"
 	"github.com/rakunlabs/chore/pkg/models/apimodels"
 	"github.com/rakunlabs/chore/pkg/registry"
 	"github.com/rakunlabs/chore/pkg/script/js"
+	"github.com/rakunlabs/chore/pkg/transfer"
 )
 
+var errTemplateRequired = errors.New("template or name is required")
+
 // @Summary Run JS script
 // @Tags run
 // @Description Run JS script with scripts and input values
@@ -91,22 +103,102 @@ func postJS(c echo.Context) error {
 
 // @Summary Render template
 // @Tags run
-// @Description Render template with input values
+// @Description Render inline or stored template with YAML/JSON values
 // @Security ApiKeyAuth
 // @Router /run/template [post]
-// @Param payload body string false "send key values" SchemaExample()
-// @Accept plain
-// @Success 200 {object} apimodels.Data{data=apimodels.ID{}}
-// @failure 400 {object} apimodels.Error{}
-// @failure 409 {object} apimodels.Error{}
+// @Param payload body templateModel true "template or name with values"
+// @Accept json
+// @Produce plain
+// @Success 200 {object} string "rendered template"
+// @failure 400 {object} apimodels.Error{error=templateError{}}
+// @failure 404 {object} apimodels.Error{}
 // @failure 500 {object} apimodels.Error{}
 func postTemplate(c echo.Context) error {
-	return c.JSON(
-		http.StatusNotImplemented,
-		apimodels.Error{
-			Error: "waiting implementation",
-		},
-	)
+	body := templateModel{}
+	if err := c.Bind(&body); err != nil {
+		return c.JSON(http.StatusBadRequest, apimodels.Error{Error: err.Error()})
+	}
+
+	ctx := c.Request().Context()
+
+	content := body.Template
+	if content == "" {
+		if body.Name == "" {
+			return c.JSON(http.StatusBadRequest, apimodels.Error{Error: errTemplateRequired.Error()})
+		}
+
+		getData := models.TemplatePure{}
+
+		result := registry.Reg.DB.WithContext(ctx).Model(&models.Template{}).Where("name = ?", body.Name).
+			Scopes(middlewares.GetAccess(c).Scope(false)).First(&getData)
+		if errors.Is(result.Error, gorm.ErrRecordNotFound) {
+			return c.JSON(http.StatusNotFound, apimodels.Error{Error: result.Error.Error()})
+		}
+
+		if result.Error != nil {
+			return c.JSON(http.StatusInternalServerError, apimodels.Error{Error: result.Error.Error()})
+		}
+
+		decoded, err := base64.StdEncoding.DecodeString(getData.Content)
+		if err != nil {
+			return c.JSON(http.StatusInternalServerError, apimodels.Error{Error: err.Error()})
+		}
+
+		content = string(decoded)
+	}
+
+	values, err := parseValues(body.Values)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, apimodels.Error{Error: err.Error()})
+	}
+
+	buf := bytes.Buffer{}
+	if err := registry.Reg.Template.Execute(
+		templatex.WithIO(&buf),
+		templatex.WithData(values),
+		templatex.WithContent(content),
+	); err != nil {
+		return c.JSON(http.StatusBadRequest, apimodels.Error{Error: newTemplateError(err)})
+	}
+
+	return c.Blob(http.StatusOK, "text/plain", buf.Bytes())
+}
+
+// parseValues accepts values as YAML/JSON string or as JSON object.
+func parseValues(raw json.RawMessage) (interface{}, error) {
+	if len(bytes.TrimSpace(raw)) == 0 {
+		return nil, nil
+	}
+
+	if raw[0] == '"' {
+		var v string
+		if err := json.Unmarshal(raw, &v); err != nil {
+			return nil, fmt.Errorf("values cannot parse: %w", err)
+		}
+
+		return transfer.BytesToData([]byte(v)), nil
+	}
+
+	return transfer.BytesToData(raw), nil
+}
+
+// rgxTemplatePosition matches "template: name:line:col:" and "template: name:line:" parts of errors.
+var rgxTemplatePosition = regexp.MustCompile(`template: [^:]*:(\d+)(?::(\d+))?:`)
+
+func newTemplateError(err error) templateError {
+	ret := templateError{Message: err.Error()}
+
+	match := rgxTemplatePosition.FindStringSubmatch(ret.Message)
+	if match == nil {
+		return ret
+	}
+
+	ret.Line, _ = strconv.Atoi(match[1])
+	if match[2] != "" {
+		ret.Column, _ = strconv.Atoi(match[2])
+	}
+
+	return ret
 }
 
 func API(e *echo.Group, authMiddleware echo.MiddlewareFunc) {
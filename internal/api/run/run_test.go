@@ -0,0 +1,90 @@
+package run
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/go-test/deep"
+	"github.com/rytsh/mugo/pkg/templatex"
+)
+
+func TestNewTemplateError(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		err     error
+		want    templateError
+	}{
+		{
+			name:    "parse error",
+			content: "line1\n{{ .name ",
+			want:    templateError{Line: 2},
+		},
+		{
+			name:    "execute error",
+			content: "line1\n  {{ index .list 5 }}",
+			want:    templateError{Line: 2, Column: 5},
+		},
+		{
+			name: "without position",
+			err:  errors.New("some error"),
+			want: templateError{Message: "some error"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.err
+			if err == nil {
+				err = templatex.New().Execute(
+					templatex.WithData(map[string]interface{}{"list": []int{}}),
+					templatex.WithContent(tt.content),
+				)
+				if err == nil {
+					t.Fatal("expected template error")
+				}
+
+				tt.want.Message = err.Error()
+			}
+
+			if diff := deep.Equal(newTemplateError(err), tt.want); diff != nil {
+				t.Errorf("newTemplateError() = %v", diff)
+			}
+		})
+	}
+}
+
+func TestParseValues(t *testing.T) {
+	tests := []struct {
+		name string
+		raw  string
+		want interface{}
+	}{
+		{
+			name: "empty",
+			raw:  "",
+			want: nil,
+		},
+		{
+			name: "object",
+			raw:  `{"name":"deepcore"}`,
+			want: map[string]interface{}{"name": "deepcore"},
+		},
+		{
+			name: "yaml string",
+			raw:  `"name: deepcore\nlist:\n  - 1\n"`,
+			want: map[string]interface{}{"name": "deepcore", "list": []interface{}{1}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseValues([]byte(tt.raw))
+			if err != nil {
+				t.Fatalf("parseValues() error = %v", err)
+			}
+
+			if diff := deep.Equal(got, tt.want); diff != nil {
+				t.Errorf("parseValues() = %v", diff)
+			}
+		})
+	}
+}
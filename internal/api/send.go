@@ -1,6 +1,7 @@
 package api
 
 import (
+	"context"
 	"encoding/base64"
 	"encoding/json"
 	"errors"
@@ -22,6 +23,21 @@ import (
 	"github.com/worldline-go/auth/pkg/authecho"
 )
 
+// HeaderRunID returns the run id to find the run history.
+var HeaderRunID = "X-Run-Id"
+
+// SendBodyLimit is the max body of /send calls in bytes, 0 is unlimited.
+var SendBodyLimit int64
+
+const (
+	// keyPublic is set for public endpoints to skip group check.
+	keyPublic = "public"
+	// keyQueue is set for endpoints which run with the job queue.
+	keyQueue = "queue"
+	// keyRateLimit is the rate limit of the endpoint.
+	keyRateLimit = "rate_limit"
+)
+
 // @Summary Send run the control; methods depending in control
 // @Description Send request with bind id or name
 // @Security ApiKeyAuth
@@ -30,12 +46,20 @@ import (
 // @Router /send [get]
 // @Param endpoint query string true "set endpoint"
 // @Param control query string true "set control"
+// @Param async query bool false "return run id immediately, get result with /send/result"
+// @Param callback query string false "post the result to this url when run finished, implies async"
+// @Param queue query bool false "add to the job queue, check with /job"
 // @Param payload body string false "send key values" SchemaExample()
 // @Accept plain
+// @Accept mpfd
 // @Success 200 {object} interface{} "respond from related server"
+// @Success 202 {object} SendResult{} "async call started"
 // @failure 400 {object} apimodels.Error{}
+// @failure 401 {object} apimodels.Error{} "webhook signature not valid"
+// @failure 403 {object} apimodels.Error{}
 // @failure 405 {object} apimodels.Error{}
 // @failure 409 {object} apimodels.Error{}
+// @failure 413 {object} apimodels.Error{}
 // @failure 500 {object} apimodels.Error{}
 func send(c echo.Context) error {
 	endpoint, _ := c.Get("endpoint").(string)
@@ -65,14 +89,14 @@ func send(c echo.Context) error {
 		)
 	}
 
-	// file, err := c.FormFile("document")
-	// if err != nil {
-	// 	return c.Status(http.StatusInternalServerError).JSON(
-	// 		apimodels.Error{
-	// 			Error: err.Error(),
-	// 		},
-	// 	)
-	// }
+	if public, _ := c.Get(keyPublic).(bool); !public && !middlewares.GetAccess(c).Can(control.Groups.Groups, false) {
+		return c.JSON(
+			http.StatusForbidden,
+			apimodels.Error{
+				Error: apimodels.ErrForbidden.Error(),
+			},
+		)
+	}
 
 	content, err := base64.StdEncoding.DecodeString(control.Content)
 	if err != nil {
@@ -95,15 +119,65 @@ func send(c echo.Context) error {
 
 	logControl.Info().Msg("new call")
 
-	bodyReader := c.Request().Body
-	body, _ := io.ReadAll(bodyReader)
+	if queued, _ := c.Get(keyQueue).(bool); queued || c.QueryParam("queue") == "true" {
+		// job keeps the body to parse in the run
+		body, err := io.ReadAll(c.Request().Body)
+		if err != nil {
+			return c.JSON(
+				bodyStatus(err),
+				apimodels.Error{
+					Error: err.Error(),
+				},
+			)
+		}
+
+		if len(body) == 0 {
+			body = nil
+		}
+
+		return enqueue(ctx, c, control.Name, endpoint, body)
+	}
 
-	var bodyCopy []byte
-	if len(body) > 0 {
-		bodyCopy = body
+	async, callback, err := asyncOptions(c)
+	if err != nil {
+		return c.JSON(
+			http.StatusBadRequest,
+			apimodels.Error{
+				Error: err.Error(),
+			},
+		)
+	}
+
+	if async {
+		ctx = context.WithValue(ctx, flow.CtxKeepResult, true)
+	}
+
+	request := incomingRequest(c, control.Name, endpoint)
+
+	// fields of the multipart call are the payload, files are attachments
+	bodyCopy, err := request.ReadMultipart(c.Request().Body)
+	if err != nil {
+		return c.JSON(
+			bodyStatus(err),
+			apimodels.Error{
+				Error: err.Error(),
+			},
+		)
+	}
+
+	if len(bodyCopy) == 0 {
+		bodyCopy = nil
+	}
+
+	ctx = context.WithValue(ctx, flow.CtxRequest, request)
+
+	nodesReg, err := flow.StartFlow(ctx, registry.Reg.WG, control.Name, endpoint, c.Request().Method, content, registry.Reg, models.ResourceAccess(control.Groups.Groups), bodyCopy)
+	if err != nil {
+		request.RemoveFiles()
+	} else {
+		request.RemoveFilesAfter(nodesReg)
 	}
 
-	nodesReg, err := flow.StartFlow(ctx, registry.Reg.WG, control.Name, endpoint, c.Request().Method, content, registry.Reg, bodyCopy)
 	if errors.Is(err, flow.ErrEndpointNotFound) {
 		return c.JSON(
 			http.StatusNotFound,
@@ -122,6 +196,23 @@ func send(c echo.Context) error {
 		)
 	}
 
+	c.Response().Header().Set(HeaderRunID, nodesReg.RunID().String())
+
+	if async {
+		if callback != "" {
+			registry.Reg.WG.Add(1)
+
+			go func() {
+				defer registry.Reg.WG.Done()
+
+				<-nodesReg.Done()
+				sendCallback(ctx, callback, nodesReg)
+			}()
+		}
+
+		return c.JSON(http.StatusAccepted, newSendResult(nodesReg))
+	}
+
 	respondChan := nodesReg.GetChan()
 	if respondChan == nil {
 		return c.String(http.StatusAccepted, http.StatusText(http.StatusAccepted))
@@ -134,24 +225,82 @@ func send(c echo.Context) error {
 
 		return c.String(http.StatusRequestTimeout, http.StatusText(http.StatusRequestTimeout))
 	case valueChan := <-respondChan:
-		for k, v := range valueChan.Header {
-			c.Response().Header().Set(k, fmt.Sprint(v))
+		return respond(c, valueChan)
+	}
+}
+
+// respond writes the respond of the flow.
+func respond(c echo.Context, v flow.Respond) error {
+	for k, v := range v.Header {
+		c.Response().Header().Set(k, fmt.Sprint(v))
+	}
+
+	if v.IsError {
+		return c.JSON(
+			http.StatusPreconditionFailed,
+			apimodels.Error{
+				// prevent to marshal base64
+				Error: string(v.Data),
+			},
+		)
+	}
+
+	if v.Reader != nil {
+		defer v.Reader.Close()
+
+		contentType := c.Response().Header().Get(echo.HeaderContentType)
+		if contentType == "" {
+			contentType = echo.MIMEOctetStream
 		}
 
-		if valueChan.IsError {
-			return c.JSON(
-				http.StatusPreconditionFailed,
-				apimodels.Error{
-					// prevent to marshal base64
-					Error: string(valueChan.Data),
-				},
-			)
+		return c.Stream(v.Status, contentType, v.Reader)
+	}
+
+	return c.Blob(v.Status, echo.MIMETextPlainCharsetUTF8, v.Data)
+}
+
+// bodyStatus returns 413 for the bodies bigger than SendBodyLimit.
+func bodyStatus(err error) int {
+	var maxErr *http.MaxBytesError
+	if errors.As(err, &maxErr) {
+		return http.StatusRequestEntityTooLarge
+	}
+
+	return http.StatusBadRequest
+}
+
+// sendBodyLimit middleware limits the body of the call with SendBodyLimit.
+func sendBodyLimit(next echo.HandlerFunc) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		if SendBodyLimit > 0 {
+			if c.Request().ContentLength > SendBodyLimit {
+				return c.JSON(
+					http.StatusRequestEntityTooLarge,
+					apimodels.Error{
+						Error: fmt.Sprintf("body is bigger than %d bytes", SendBodyLimit),
+					},
+				)
+			}
+
+			c.Request().Body = http.MaxBytesReader(c.Response(), c.Request().Body, SendBodyLimit)
 		}
 
-		return c.Blob(valueChan.Status, echo.MIMETextPlainCharsetUTF8, valueChan.Data)
+		return next(c)
 	}
 }
 
+// incomingRequest returns the call values for scripts and templates.
+func incomingRequest(c echo.Context, control, endpoint string) *flow.Request {
+	request := flow.NewRequest(c.Request().Method, c.Request().URL, c.Request().Header)
+	request.Host = c.Request().Host
+	request.RemoteIP = c.RealIP()
+	request.RequestID = c.Response().Header().Get(echo.HeaderXRequestID)
+	request.Control = control
+	request.Endpoint = endpoint
+
+	return request
+}
+
 // endpointCheck middleware is checking endpoint.
 func endpointCheck(next echo.HandlerFunc) echo.HandlerFunc {
 	return func(c echo.Context) error {
@@ -236,11 +385,35 @@ func endpointCheck(next echo.HandlerFunc) echo.HandlerFunc {
 			)
 		}
 
+		// webhook signature, verified calls not need a token
+		if endpointSpec.Signature != nil {
+			if code, err := verifySignature(c, endpointSpec.Signature); err != nil {
+				return c.JSON(
+					code,
+					apimodels.Error{
+						Error: err.Error(),
+					},
+				)
+			}
+
+			endpointSpec.Public = true
+		}
+
+		c.Set(keyQueue, endpointSpec.Queue)
+
+		rateLimit := middlewares.EndpointRateLimit
+		if endpointSpec.RateLimit != nil {
+			rateLimit = *endpointSpec.RateLimit
+		}
+
+		c.Set(keyRateLimit, rateLimit)
+
 		// public check
 		if !endpointSpec.Public {
 			return next(c)
 		}
 
+		c.Set(keyPublic, true)
 		c.Set(authecho.DisableRoleCheckKey, true)
 		c.Set(authecho.DisableScopeCheckKey, true)
 		c.Set(authecho.DisableControlCheckKey, true)
@@ -250,6 +423,22 @@ func endpointCheck(next echo.HandlerFunc) echo.HandlerFunc {
 	}
 }
 
+// endpointRate limits the calls of the endpoint after the authentication,
+// so rejected calls not use the places of the callers.
+func endpointRate(next echo.HandlerFunc) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		rateLimit, _ := c.Get(keyRateLimit).(models.RateLimit)
+
+		key := "endpoint:" + c.QueryParam("control") + "/" + c.QueryParam("endpoint")
+		if ok, wait := middlewares.RateLimiters.Allow(key, rateLimit); !ok {
+			return middlewares.RateLimitExceeded(c, wait)
+		}
+
+		return next(c)
+	}
+}
+
 func Send(e *echo.Group, authMiddleware echo.MiddlewareFunc) {
-	e.Any("/send", send, endpointCheck, authMiddleware, middlewares.UserRole, middlewares.PatToken)
+	e.Any("/send", send, sendBodyLimit, endpointCheck, authMiddleware, middlewares.UserRole, middlewares.PatToken, middlewares.TokenRate, endpointRate)
+	e.GET("/send/result", getSendResult, authMiddleware, middlewares.UserRole, middlewares.PatToken)
 }
@@ -0,0 +1,289 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"syscall"
+
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+	"github.com/rs/zerolog/log"
+	"gorm.io/gorm"
+
+	"github.com/rakunlabs/chore/internal/server/middlewares"
+	"github.com/rakunlabs/chore/pkg/flow"
+	"github.com/rakunlabs/chore/pkg/models"
+	"github.com/rakunlabs/chore/pkg/models/apimodels"
+	"github.com/rakunlabs/chore/pkg/registry"
+	"github.com/rakunlabs/chore/pkg/request"
+)
+
+// HeaderPrefer with respond-async value enables async mode of send.
+var HeaderPrefer = "Prefer"
+
+// CallbackHosts allowed in the callback of async send, empty allows all public hosts.
+// Listed hosts can be in private networks.
+var CallbackHosts []string
+
+var (
+	errResultNotKept = errors.New("result not kept, run is not called as async")
+	errCallbackURL   = errors.New("callback should be http or https url")
+	errCallbackAuth  = errors.New("callback needs an authenticated caller")
+	errCallbackHost  = errors.New("callback host is not allowed")
+)
+
+// SendResult is the state of async call, respond set when the run finished.
+type SendResult struct {
+	ID       uuid.UUID     `json:"id" example:"cf8a07d4-077e-402e-a46b-ac0ed50989ec"`
+	Control  string        `json:"control" example:"deepcore"`
+	Endpoint string        `json:"endpoint" example:"create"`
+	Status   string        `json:"status" example:"running"`
+	Error    string        `json:"error,omitempty"`
+	Respond  *flow.Respond `json:"respond,omitempty"`
+}
+
+func newSendResult(nodesReg *flow.NodesReg) SendResult {
+	info := nodesReg.Info()
+
+	result := SendResult{
+		ID:       info.ID,
+		Control:  info.Control,
+		Endpoint: info.Endpoint,
+		Status:   nodesReg.Status(),
+	}
+
+	if result.Status == models.RunStatusRunning {
+		return result
+	}
+
+	if err := nodesReg.Err(); err != nil {
+		result.Error = err.Error()
+	}
+
+	if v, ok := nodesReg.Result(); ok {
+		result.Respond = &v
+	}
+
+	return result
+}
+
+// asyncOptions returns async mode and callback url of the send.
+func asyncOptions(c echo.Context) (bool, string, error) {
+	async := strings.Contains(c.Request().Header.Get(HeaderPrefer), "respond-async")
+
+	if v := c.QueryParam("async"); v != "" {
+		var err error
+		if async, err = strconv.ParseBool(v); err != nil {
+			return false, "", fmt.Errorf("async parameter: %w", err)
+		}
+	}
+
+	callback := c.QueryParam("callback")
+	if callback == "" {
+		return async, "", nil
+	}
+
+	// public endpoints and webhooks cannot make the server call other addresses
+	if middlewares.Subject(c) == "" {
+		return false, "", errCallbackAuth
+	}
+
+	u, err := url.Parse(callback)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return false, "", errCallbackURL
+	}
+
+	if err := checkCallbackHost(u.Hostname()); err != nil {
+		return false, "", err
+	}
+
+	return true, callback, nil
+}
+
+// callbackListed reports the host is in CallbackHosts.
+func callbackListed(host string) bool {
+	for _, v := range CallbackHosts {
+		if strings.EqualFold(v, host) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// checkCallbackHost rejects hosts not in the list and addresses of the private networks.
+// Resolved addresses of the names are checked again in the connection.
+func checkCallbackHost(host string) error {
+	if callbackListed(host) {
+		return nil
+	}
+
+	if len(CallbackHosts) > 0 || strings.EqualFold(host, "localhost") {
+		return fmt.Errorf("%w: %s", errCallbackHost, host)
+	}
+
+	if ip := net.ParseIP(host); ip != nil && !publicIP(ip) {
+		return fmt.Errorf("%w: %s", errCallbackHost, host)
+	}
+
+	return nil
+}
+
+// publicIP reports the address is not loopback, link-local, private, multicast or unspecified.
+func publicIP(ip net.IP) bool {
+	return !ip.IsLoopback() && !ip.IsLinkLocalUnicast() && !ip.IsLinkLocalMulticast() &&
+		!ip.IsPrivate() && !ip.IsUnspecified() && !ip.IsMulticast()
+}
+
+// callbackDialControl checks the resolved address of the callback before the connection.
+func callbackDialControl(_, address string, _ syscall.RawConn) error {
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		return fmt.Errorf("callback address: %w", err)
+	}
+
+	if ip := net.ParseIP(host); ip == nil || !publicIP(ip) {
+		return fmt.Errorf("%w: %s", errCallbackHost, host)
+	}
+
+	return nil
+}
+
+// sendCallback posts the result of the finished run to the callback url.
+func sendCallback(ctx context.Context, callback string, nodesReg *flow.NodesReg) {
+	logger := log.Ctx(ctx).With().Str("runID", nodesReg.RunID().String()).Str("callback", callback).Logger()
+
+	cfg := request.Config{
+		Log:   &logger,
+		Retry: request.Retry{Enabled: true},
+	}
+
+	// names can resolve to private addresses, only listed hosts can be there
+	if u, err := url.Parse(callback); err != nil || !callbackListed(u.Hostname()) {
+		cfg.DialControl = callbackDialControl
+	}
+
+	client, err := request.NewClient(cfg)
+	if err != nil {
+		logger.Error().Err(err).Msg("cannot create callback client")
+
+		return
+	}
+
+	payload, err := json.Marshal(newSendResult(nodesReg))
+	if err != nil {
+		logger.Error().Err(err).Msg("cannot marshal callback payload")
+
+		return
+	}
+
+	headers := map[string]interface{}{
+		echo.HeaderContentType: echo.MIMEApplicationJSON,
+		HeaderRunID:            nodesReg.RunID().String(),
+	}
+
+	response, err := client.Call(ctx, callback, http.MethodPost, headers, payload)
+	if err != nil {
+		logger.Error().Err(err).Msg("cannot send callback")
+
+		return
+	}
+
+	if response.StatusCode >= http.StatusBadRequest {
+		logger.Error().Int("status", response.StatusCode).Msg("callback not accepted")
+
+		return
+	}
+
+	logger.Info().Msg("callback sent")
+}
+
+// @Summary Get result of the async send
+// @Description Returns 202 while the run is working, after that the respond of the flow as the send returns
+// @Security ApiKeyAuth
+// @Tags run
+// @Router /send/result [get]
+// @Param id query string true "run id"
+// @Success 200 {object} interface{} "respond of the flow"
+// @Success 202 {object} SendResult{} "still running"
+// @failure 400 {object} apimodels.Error{}
+// @failure 403 {object} apimodels.Error{}
+// @failure 404 {object} apimodels.Error{}
+// @failure 412 {object} apimodels.Error{}
+// @failure 500 {object} apimodels.Error{}
+func getSendResult(c echo.Context) error {
+	id, err := uuid.Parse(c.QueryParam("id"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, apimodels.Error{Error: apimodels.ErrRequiredID.Error()})
+	}
+
+	ctx := c.Request().Context()
+	access := middlewares.GetAccess(c)
+
+	c.Response().Header().Set(HeaderRunID, id.String())
+
+	// run record may not be written yet
+	if nodesReg, ok := flow.ActiveRuns.Get(id); ok {
+		control := nodesReg.Info().Control
+
+		if !access.All {
+			allowed, err := allowedControls(ctx, access, []string{control}, false)
+			if err != nil {
+				return c.JSON(http.StatusInternalServerError, apimodels.Error{Error: err.Error()})
+			}
+
+			if _, ok := allowed[control]; !ok {
+				return c.JSON(http.StatusForbidden, apimodels.Error{Error: apimodels.ErrForbidden.Error()})
+			}
+		}
+
+		return c.JSON(http.StatusAccepted, newSendResult(nodesReg))
+	}
+
+	run := models.Run{}
+
+	result := registry.Reg.DB.WithContext(ctx).Model(&models.Run{}).Where("id = ?", id).
+		Scopes(runAccess(ctx, access)).First(&run)
+	if errors.Is(result.Error, gorm.ErrRecordNotFound) {
+		return c.JSON(http.StatusNotFound, apimodels.Error{Error: result.Error.Error()})
+	}
+
+	if result.Error != nil {
+		return c.JSON(http.StatusInternalServerError, apimodels.Error{Error: result.Error.Error()})
+	}
+
+	// running in another instance
+	if run.Status == models.RunStatusRunning {
+		return c.JSON(http.StatusAccepted, SendResult{
+			ID:       run.ID.ID,
+			Control:  run.Control,
+			Endpoint: run.Endpoint,
+			Status:   run.Status,
+		})
+	}
+
+	if run.ResultStatus == 0 && !run.ResultError {
+		return c.JSON(http.StatusNotFound, apimodels.Error{Error: errResultNotKept.Error()})
+	}
+
+	v := flow.Respond{
+		Status:  run.ResultStatus,
+		Data:    run.Result,
+		IsError: run.ResultError,
+	}
+
+	if len(run.ResultHeader) > 0 {
+		if err := json.Unmarshal(run.ResultHeader, &v.Header); err != nil {
+			return c.JSON(http.StatusInternalServerError, apimodels.Error{Error: err.Error()})
+		}
+	}
+
+	return respond(c, v)
+}
@@ -0,0 +1,54 @@
+package api
+
+import (
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/labstack/echo/v4"
+
+	"github.com/rakunlabs/chore/pkg/models/apimodels"
+)
+
+func TestSendBodyLimit(t *testing.T) {
+	defer func(v int64) { SendBodyLimit = v }(SendBodyLimit)
+
+	SendBodyLimit = 8
+
+	// reads the body like send
+	handler := sendBodyLimit(func(c echo.Context) error {
+		if _, err := io.ReadAll(c.Request().Body); err != nil {
+			return c.JSON(bodyStatus(err), apimodels.Error{Error: err.Error()})
+		}
+
+		return c.NoContent(http.StatusOK)
+	})
+
+	tests := []struct {
+		name          string
+		body          string
+		contentLength int64
+		status        int
+	}{
+		{name: "in limit", body: "12345678", contentLength: 8, status: http.StatusOK},
+		{name: "content length over limit", body: "123456789", contentLength: 9, status: http.StatusRequestEntityTooLarge},
+		{name: "unknown length over limit", body: "123456789", contentLength: -1, status: http.StatusRequestEntityTooLarge},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c, rec := testContext(http.MethodPost, "/send", "")
+			c.Request().Body = io.NopCloser(strings.NewReader(tt.body))
+			c.Request().ContentLength = tt.contentLength
+
+			if err := handler(c); err != nil {
+				t.Fatal(err)
+			}
+
+			if rec.Code != tt.status {
+				t.Errorf("status = %d, want %d; %s", rec.Code, tt.status, rec.Body.String())
+			}
+		})
+	}
+}
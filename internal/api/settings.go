@@ -1,8 +1,10 @@
 package api
 
 import (
+	"encoding/json"
 	"errors"
 	"net/http"
+	"strings"
 
 	"github.com/labstack/echo/v4"
 	"gorm.io/gorm"
@@ -19,7 +21,7 @@ import (
 // @Description Get whole settings
 // @Security ApiKeyAuth
 // @Router /settings [get]
-// @Param namespace query string true "get by namespace (email, oauth2)"
+// @Param namespace query string true "get by namespace (email, oauth2, webhook, tls, signer)"
 // @Param name query string false "name like email-1"
 // @Success 200 {object} apimodels.Data{}
 // @failure 400 {object} apimodels.Error{}
@@ -69,8 +71,9 @@ func getSettings(c echo.Context) error {
 // @Security ApiKeyAuth
 // @Router /settings [patch]
 // @Param payload body models.Settings{} false "send part of the settings object"
-// @Param namespace query string true "get by namespace (email, oauth2)"
+// @Param namespace query string true "get by namespace (email, oauth2, webhook, tls, signer)"
 // @Param name query string false "name like email-1"
+// @Param groups query string false "group names of the controls using it 'group1,group2', empty clears"
 // @Success 204 "No Content"
 // @failure 400 {object} apimodels.Error{}
 // @failure 409 {object} apimodels.Error{}
@@ -96,6 +99,23 @@ func patchSettings(c echo.Context) error {
 		},
 	}
 
+	// groups are kept when not in the query
+	if c.QueryParams().Has("groups") {
+		groups := []string{}
+		for _, g := range strings.Split(c.QueryParam("groups"), ",") {
+			if g = strings.TrimSpace(g); g != "" {
+				groups = append(groups, g)
+			}
+		}
+
+		var err error
+
+		bodyModel.Groups.Groups, err = json.Marshal(groups)
+		if err != nil {
+			return c.JSON(http.StatusInternalServerError, apimodels.Error{Error: err.Error()})
+		}
+	}
+
 	ctx := utils.Context(c)
 	query := registry.Reg.DB.WithContext(ctx).Model(&models.Settings{})
 
@@ -124,7 +144,7 @@ func patchSettings(c echo.Context) error {
 // @Description Replace with new data
 // @Security ApiKeyAuth
 // @Router /settings [delete]
-// @Param namespace query string true "get by namespace (email, oauth2)"
+// @Param namespace query string true "get by namespace (email, oauth2, webhook, tls, signer)"
 // @Param name query string false "name like email-1"
 // @Success 204 "No Content"
 // @failure 400 {object} apimodels.Error{}
@@ -0,0 +1,76 @@
+package api
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/rs/zerolog/log"
+
+	"github.com/rakunlabs/chore/pkg/models"
+	"github.com/rakunlabs/chore/pkg/registry"
+	"github.com/rakunlabs/chore/pkg/sec"
+)
+
+// verifySignature checks the webhook signature of the call and keeps the body for the next handlers.
+// Returns status code with the error.
+func verifySignature(c echo.Context, signature *models.EndpointSignature) (int, error) {
+	if signature.Secret == "" {
+		return http.StatusInternalServerError, fmt.Errorf("signature secret is empty")
+	}
+
+	settings := models.Settings{}
+
+	query := registry.Reg.DB.WithContext(c.Request().Context()).Model(&models.Settings{}).
+		Where("namespace = ?", models.SettingsWebhook).Where("name = ?", signature.Secret)
+	if result := query.First(&settings); result.Error != nil {
+		return http.StatusInternalServerError, fmt.Errorf("webhook secret %s: %w", signature.Secret, result.Error)
+	}
+
+	secret, _ := settings.Data["secret"].(string)
+
+	var tolerance time.Duration
+	if signature.Tolerance != "" {
+		var err error
+
+		tolerance, err = time.ParseDuration(signature.Tolerance)
+		if err != nil {
+			return http.StatusInternalServerError, fmt.Errorf("signature tolerance: %w", err)
+		}
+	}
+
+	body, err := io.ReadAll(c.Request().Body)
+	if err != nil {
+		return bodyStatus(err), err
+	}
+
+	c.Request().Body = io.NopCloser(bytes.NewReader(body))
+
+	err = sec.Signature{
+		Preset:          signature.Preset,
+		Type:            signature.Type,
+		Secret:          []byte(secret),
+		Header:          signature.Header,
+		Prefix:          signature.Prefix,
+		Encoding:        signature.Encoding,
+		TimestampHeader: signature.TimestampHeader,
+		Payload:         signature.Payload,
+		Tolerance:       tolerance,
+	}.Verify(c.Request().Header, body, time.Now())
+
+	if errors.Is(err, sec.ErrSignature) || errors.Is(err, sec.ErrSignatureMissing) || errors.Is(err, sec.ErrTimestamp) {
+		log.Ctx(c.Request().Context()).Warn().Err(err).Str("remote", c.RealIP()).Msg("webhook signature rejected")
+
+		return http.StatusUnauthorized, err
+	}
+
+	if err != nil {
+		return http.StatusInternalServerError, err
+	}
+
+	return http.StatusOK, nil
+}
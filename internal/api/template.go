@@ -59,7 +59,20 @@ func listTemplates(c echo.Context) error {
 	// Table(reg.DB.Config.NamingStrategy.JoinTableName("folders"))
 
 	ctx := c.Request().Context()
-	query := registry.Reg.DB.WithContext(ctx).Model(&models.Folder{}).Select("item", "name")
+
+	// show only accessible templates, folders always listed
+	access := middlewares.GetAccess(c)
+	filter := func(db *gorm.DB) *gorm.DB {
+		if access.All {
+			return db
+		}
+
+		readable := registry.Reg.DB.WithContext(ctx).Model(&models.Template{}).Select("name").Scopes(access.Scope(false))
+
+		return db.Where("dtype = ? OR name IN (?)", true, readable)
+	}
+
+	query := registry.Reg.DB.WithContext(ctx).Model(&models.Folder{}).Select("item", "name").Scopes(filter)
 
 	if meta.Limit >= 0 {
 		query = query.Limit(meta.Limit)
@@ -75,7 +88,7 @@ func listTemplates(c echo.Context) error {
 	}
 
 	// get counts
-	registry.Reg.DB.WithContext(ctx).Model(&models.Folder{}).Count(&meta.Count)
+	registry.Reg.DB.WithContext(ctx).Model(&models.Folder{}).Scopes(filter).Count(&meta.Count)
 
 	return c.JSON(http.StatusOK,
 		apimodels.DataMeta{
@@ -113,7 +126,7 @@ func getTemplate(c echo.Context) error {
 	getData := TemplatePureID{}
 
 	ctx := c.Request().Context()
-	query := registry.Reg.DB.WithContext(ctx).Model(&models.Template{})
+	query := registry.Reg.DB.WithContext(ctx).Model(&models.Template{}).Scopes(middlewares.GetAccess(c).Scope(false))
 
 	if id != "" {
 		query = query.Where("id = ?", id)
@@ -170,6 +183,7 @@ func postTemplate(c echo.Context) error {
 
 	template := new(models.Template)
 	template.Content = base64.StdEncoding.EncodeToString(body)
+	template.Author = middlewares.Subject(c)
 
 	name := c.QueryParam("name")
 	if name == "" {
@@ -188,6 +202,10 @@ func postTemplate(c echo.Context) error {
 		}
 	}
 
+	if !middlewares.GetAccess(c).CanAssign(template.Groups.Groups, nil) {
+		return c.JSON(http.StatusForbidden, apimodels.Error{Error: apimodels.ErrGroupAssign.Error()})
+	}
+
 	template.ID.ID, err = uuid.NewUUID()
 	if err != nil {
 		return c.JSON(http.StatusInternalServerError, apimodels.Error{Error: err.Error()})
@@ -232,6 +250,7 @@ func postTemplate(c echo.Context) error {
 // @Accept plain
 // @Success 204 "No Content"
 // @failure 400 {object} apimodels.Error{}
+// @failure 403 {object} apimodels.Error{}
 // @failure 500 {object} apimodels.Error{}
 func putTemplate(c echo.Context) error {
 	body, err := io.ReadAll(c.Request().Body)
@@ -241,6 +260,7 @@ func putTemplate(c echo.Context) error {
 
 	template := new(models.Template)
 	template.Content = base64.StdEncoding.EncodeToString(body)
+	template.Author = middlewares.Subject(c)
 
 	name := c.QueryParam("name")
 	if name == "" {
@@ -265,7 +285,31 @@ func putTemplate(c echo.Context) error {
 	}
 
 	ctx := utils.Context(c)
-	result := registry.Reg.DB.WithContext(ctx).Clauses(
+
+	// check access of the existing record
+	prev := models.Template{}
+	result := registry.Reg.DB.WithContext(ctx).Model(&models.Template{}).Where("name = ?", template.Name).Limit(1).Find(&prev)
+	if result.Error != nil {
+		return c.JSON(http.StatusInternalServerError, apimodels.Error{Error: result.Error.Error()})
+	}
+
+	access := middlewares.GetAccess(c)
+
+	exist := result.RowsAffected > 0
+	if exist && !access.Can(prev.Groups.Groups, true) {
+		return c.JSON(http.StatusForbidden, apimodels.Error{Error: apimodels.ErrForbidden.Error()})
+	}
+
+	// upsert writes all columns, keep the groups when they are not in the request
+	if exist && !c.QueryParams().Has("groups") {
+		template.Groups = prev.Groups
+	}
+
+	if !access.CanAssign(template.Groups.Groups, prev.Groups.Groups) {
+		return c.JSON(http.StatusForbidden, apimodels.Error{Error: apimodels.ErrGroupAssign.Error()})
+	}
+
+	result = registry.Reg.DB.WithContext(ctx).Clauses(
 		clause.OnConflict{
 			UpdateAll: true,
 			Columns:   []clause.Column{{Name: "name"}},
@@ -275,6 +319,10 @@ func putTemplate(c echo.Context) error {
 		return c.JSON(http.StatusInternalServerError, apimodels.Error{Error: result.Error.Error()})
 	}
 
+	if exist && prev.Content != template.Content {
+		saveTemplateRevision(ctx, &prev)
+	}
+
 	// create folder
 	folderMap := utils.FolderFile(template.Name)
 
@@ -322,11 +370,25 @@ func patchTemplate(c echo.Context) error {
 			Name:    name,
 			Content: base64.StdEncoding.EncodeToString(body),
 		},
+		Author: middlewares.Subject(c),
 	}
 
 	ctx := utils.Context(c)
+	access := middlewares.GetAccess(c)
+
+	prev := models.Template{}
+
+	result := registry.Reg.DB.WithContext(ctx).Where("name = ?", name).Scopes(access.Scope(true)).First(&prev)
+	if errors.Is(result.Error, gorm.ErrRecordNotFound) {
+		return c.JSON(http.StatusNotFound, apimodels.Error{Error: result.Error.Error()})
+	}
+
+	if result.Error != nil {
+		return c.JSON(http.StatusInternalServerError, apimodels.Error{Error: result.Error.Error()})
+	}
+
 	// save new value
-	result := registry.Reg.DB.WithContext(ctx).Where("name = ?", name).Updates(&data)
+	result = registry.Reg.DB.WithContext(ctx).Where("name = ?", name).Scopes(access.Scope(true)).Updates(&data)
 
 	// check write error
 	if result.Error != nil && errors.Is(result.Error, gorm.ErrDuplicatedKey) {
@@ -341,6 +403,14 @@ func patchTemplate(c echo.Context) error {
 		return c.JSON(http.StatusInternalServerError, apimodels.Error{Error: result.Error.Error()})
 	}
 
+	if result.RowsAffected == 0 {
+		return c.JSON(http.StatusNotFound, apimodels.Error{Error: apimodels.ErrNotFound.Error()})
+	}
+
+	if prev.Content != data.Content {
+		saveTemplateRevision(ctx, &prev)
+	}
+
 	// // update from folder table
 	// if prevValues.Name != body["name"].(string) {
 	// 	reg.DB.WithContext(c.UserContext()).Where("name = ?", prevValues.Name).Delete(&models.Folder{})
@@ -370,6 +440,7 @@ func patchTemplate(c echo.Context) error {
 // @Param name query string false "get by name"
 // @Success 204 "No Content"
 // @failure 400 {object} apimodels.Error{}
+// @failure 403 {object} apimodels.Error{}
 // @failure 404 {object} apimodels.Error{}
 // @failure 500 {object} apimodels.Error{}
 func deleteTemplate(c echo.Context) error {
@@ -381,7 +452,21 @@ func deleteTemplate(c echo.Context) error {
 	}
 
 	ctx := utils.Context(c)
-	query := registry.Reg.DB.WithContext(ctx)
+	access := middlewares.GetAccess(c)
+
+	// folder deletion must not leave other groups' templates without folder
+	if !access.All && name != "" && name[len(name)-1] == '/' {
+		var total, writable int64
+
+		registry.Reg.DB.WithContext(ctx).Model(&models.Template{}).Where("name LIKE ?", name+"%").Count(&total)
+		registry.Reg.DB.WithContext(ctx).Model(&models.Template{}).Where("name LIKE ?", name+"%").Scopes(access.Scope(true)).Count(&writable)
+
+		if total != writable {
+			return c.JSON(http.StatusForbidden, apimodels.Error{Error: apimodels.ErrForbidden.Error()})
+		}
+	}
+
+	query := registry.Reg.DB.WithContext(ctx).Scopes(access.Scope(true))
 	if id != "" {
 		query = query.Where("id = ?", id)
 	}
@@ -394,6 +479,12 @@ func deleteTemplate(c echo.Context) error {
 		}
 	}
 
+	// keep deleted templates as revision
+	prevs := []models.Template{}
+	if result := query.Session(&gorm.Session{}).Find(&prevs); result.Error != nil {
+		return c.JSON(http.StatusInternalServerError, apimodels.Error{Error: result.Error.Error()})
+	}
+
 	// delete directly in DB
 	result := query.Unscoped().Delete(&models.Template{})
 
@@ -405,6 +496,10 @@ func deleteTemplate(c echo.Context) error {
 		return c.JSON(http.StatusInternalServerError, apimodels.Error{Error: result.Error.Error()})
 	}
 
+	for i := range prevs {
+		saveTemplateRevision(ctx, &prevs[i])
+	}
+
 	// delete from folder table
 	query = registry.Reg.DB.WithContext(ctx)
 	if name[len(name)-1] == '/' {
@@ -0,0 +1,75 @@
+package api
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/google/uuid"
+	"gorm.io/datatypes"
+
+	"github.com/rakunlabs/chore/pkg/models"
+	"github.com/rakunlabs/chore/pkg/models/apimodels"
+)
+
+func TestPutTemplateGroups(t *testing.T) {
+	dbConn := testDB(t)
+
+	tests := []struct {
+		name   string
+		query  string
+		status int
+		groups string
+	}{
+		{
+			name:   "without groups",
+			status: http.StatusNoContent,
+			groups: `["team1"]`,
+		},
+		{
+			name:   "with groups",
+			query:  "&groups=team1,team1:read",
+			status: http.StatusNoContent,
+			groups: `["team1","team1:read"]`,
+		},
+		{
+			name:   "not member of groups",
+			query:  "&groups=team2",
+			status: http.StatusForbidden,
+			groups: `["team1"]`,
+		},
+	}
+
+	for i, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			name := "templates/" + string(rune('a'+i))
+
+			template := models.Template{}
+			template.ID.ID = uuid.New()
+			template.Name = name
+			template.Content = "aGVsbG8="
+			template.Groups = apimodels.Groups{Groups: datatypes.JSON(`["team1"]`)}
+
+			if result := dbConn.Create(&template); result.Error != nil {
+				t.Fatal(result.Error)
+			}
+
+			c, rec := testContext(http.MethodPut, "/template?name="+name+tt.query, "hello world", "team1")
+			if err := putTemplate(c); err != nil {
+				t.Fatal(err)
+			}
+
+			if rec.Code != tt.status {
+				t.Fatalf("putTemplate() status = %d, want %d; %s", rec.Code, tt.status, rec.Body.String())
+			}
+
+			got := models.Template{}
+			if result := dbConn.Where("name = ?", name).First(&got); result.Error != nil {
+				t.Fatal(result.Error)
+			}
+
+			if string(got.Groups.Groups) != tt.groups {
+				t.Errorf("groups = %s, want %s", got.Groups.Groups, tt.groups)
+			}
+		})
+	}
+}
@@ -31,16 +31,22 @@ var LoadConfig = Load{
 }
 
 var Application = struct {
-	Env      string   `cfg:"env"`
-	Host     string   `cfg:"host"`
-	Port     string   `cfg:"port"`
-	LogLevel string   `cfg:"log_level"`
-	Secret   string   `cfg:"secret"    log:"false"`
-	BasePath string   `cfg:"base_path"`
-	User     User     `cfg:"user"`
-	Store    Store    `cfg:"store"`
-	Migrate  Store    `cfg:"migrate"`
-	Template Template `cfg:"template"`
+	Env       string    `cfg:"env"`
+	Host      string    `cfg:"host"`
+	Port      string    `cfg:"port"`
+	LogLevel  string    `cfg:"log_level"`
+	Secret    string    `cfg:"secret"    log:"false"`
+	BasePath  string    `cfg:"base_path"`
+	User      User      `cfg:"user"`
+	Store     Store     `cfg:"store"`
+	Migrate   Store     `cfg:"migrate"`
+	Template  Template  `cfg:"template"`
+	History   History   `cfg:"history"`
+	Scheduler Scheduler `cfg:"scheduler"`
+	Queue     Queue     `cfg:"queue"`
+	Limit     Limit     `cfg:"limit"`
+	RateLimit RateLimit `cfg:"rate_limit"`
+	Callback  Callback  `cfg:"callback"`
 
 	AuthProviders map[string]*providers.Generic `cfg:"auth_providers"`
 
@@ -67,6 +73,25 @@ var Application = struct {
 	Template: Template{
 		Trust: false,
 	},
+	History: History{
+		PayloadLimit: 4096,
+		ResultLimit:  10 << 20,
+	},
+	Limit: Limit{
+		BodySize:  32 << 20,
+		BodySpool: 1 << 20,
+	},
+	Scheduler: Scheduler{
+		Lease:          30 * time.Second,
+		ReloadInterval: time.Minute,
+	},
+	Queue: Queue{
+		Workers:      4,
+		MaxAttempts:  3,
+		Backoff:      10 * time.Second,
+		Lease:        time.Minute,
+		PollInterval: 5 * time.Second,
+	},
 }
 
 // User settings will use if doesn't have any user on database.
@@ -95,3 +120,75 @@ type Store struct {
 type Template struct {
 	Trust bool `cfg:"trust"`
 }
+
+// History of the control flow runs.
+type History struct {
+	Disabled bool `cfg:"disabled"`
+	// PayloadLimit is max recorded size of node input and output, -1 for no limit.
+	PayloadLimit int `cfg:"payload_limit"`
+	// ResultLimit is max spooled respond body kept for async calls, bigger ones are recorded as error; -1 for no limit.
+	ResultLimit int64 `cfg:"result_limit"`
+}
+
+// Scheduler of the schedule nodes, replicas share a lease in the database and only the owner runs them.
+type Scheduler struct {
+	Disabled bool `cfg:"disabled"`
+	// Lease of the running instance, extended while running; other replicas take it after expired.
+	Lease time.Duration `cfg:"lease"`
+	// ReloadInterval reads the schedules from the database to get changes of other replicas.
+	ReloadInterval time.Duration `cfg:"reload_interval"`
+}
+
+// Queue keeps /send calls in the database and runs them with workers in all replicas.
+type Queue struct {
+	Enabled bool `cfg:"enabled"`
+	// Workers is the count of concurrent jobs in this instance, 0 only adds jobs.
+	Workers     int `cfg:"workers"`
+	MaxAttempts int `cfg:"max_attempts"`
+	// Backoff is the wait before the first retry, doubles each time.
+	Backoff time.Duration `cfg:"backoff"`
+	// Lease of the running job, extended while running; expired jobs are taken by other workers.
+	Lease        time.Duration `cfg:"lease"`
+	PollInterval time.Duration `cfg:"poll_interval"`
+}
+
+// Limit of the concurrent works, 0 is unlimited and excess works wait.
+type Limit struct {
+	// Runs is the max running flows in this instance, runs started inside of other runs are not counted.
+	Runs int `cfg:"runs"`
+	// ControlRuns is the max running flows of one control.
+	ControlRuns int `cfg:"control_runs"`
+	// Controls overrides ControlRuns with control name.
+	Controls map[string]int `cfg:"controls"`
+	// ForParallel is the max running values of a for loop, node's parallel value overrides it.
+	ForParallel int `cfg:"for_parallel"`
+	// ResponseSize is the max response body of request nodes in bytes, node's value overrides it.
+	ResponseSize int64 `cfg:"response_size"`
+	// ResponseSpool writes bigger response bodies to temp files, node's value overrides it.
+	ResponseSpool int64 `cfg:"response_spool"`
+	// BodySize is the max body of /send calls in bytes, bigger calls get 413.
+	BodySize int64 `cfg:"body_size"`
+	// BodySpool writes bigger uploaded files of /send calls to temp files.
+	BodySpool int64 `cfg:"body_spool"`
+}
+
+// RateLimit defaults of /send, endpoint and token values override them.
+type RateLimit struct {
+	// Endpoint is the limit of each control endpoint for all callers.
+	Endpoint RateLimitValue `cfg:"endpoint"`
+	// Token is the limit of each personal access token.
+	Token RateLimitValue `cfg:"token"`
+}
+
+// Callback of the async sends, only authenticated callers can set it.
+type Callback struct {
+	// Hosts allowed in the callback url, empty allows all public hosts.
+	// Listed hosts can be in private networks.
+	Hosts []string `cfg:"hosts"`
+}
+
+// RateLimitValue is a token bucket, rate is requests per second, 0 is unlimited.
+type RateLimitValue struct {
+	Rate  float64 `cfg:"rate"`
+	Burst int     `cfg:"burst"`
+}
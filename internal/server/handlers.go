@@ -26,8 +26,10 @@ import (
 	"github.com/rakunlabs/chore/internal/config"
 	"github.com/rakunlabs/chore/internal/server/claims"
 	"github.com/rakunlabs/chore/internal/server/middlewares"
+	"github.com/rakunlabs/chore/pkg/queue"
 	"github.com/rakunlabs/chore/pkg/registry"
 	"github.com/rakunlabs/chore/pkg/request"
+	"github.com/rakunlabs/chore/pkg/scheduler"
 )
 
 // @description Storage and Send API
@@ -56,6 +58,10 @@ func setHandlers(e *echo.Group, authMiddleware echo.MiddlewareFunc) error {
 	api.Token(v1, authMiddleware)
 	api.Control(v1, authMiddleware)
 	api.Settings(v1, authMiddleware)
+	api.History(v1, authMiddleware)
+	api.Job(v1, authMiddleware)
+	api.Revision(v1, authMiddleware)
+	api.Bundle(v1, authMiddleware)
 	api.Info(v1)
 	run.API(v1, authMiddleware)
 
@@ -126,6 +132,23 @@ func Set(ctx context.Context, wg *sync.WaitGroup, db *gorm.DB) (*echo.Echo, erro
 
 	request.InitGlobalRegistry(ctx).Start(wg)
 
+	if !config.Application.Scheduler.Disabled {
+		scheduler.InitGlobalScheduler(ctx, registry.Reg, scheduler.Config{
+			Lease:          config.Application.Scheduler.Lease,
+			ReloadInterval: config.Application.Scheduler.ReloadInterval,
+		}).Start(wg)
+	}
+
+	if config.Application.Queue.Enabled {
+		queue.InitGlobalQueue(ctx, registry.Reg, queue.Config{
+			Workers:      config.Application.Queue.Workers,
+			MaxAttempts:  config.Application.Queue.MaxAttempts,
+			Backoff:      config.Application.Queue.Backoff,
+			Lease:        config.Application.Queue.Lease,
+			PollInterval: config.Application.Queue.PollInterval,
+		}).Start(wg)
+	}
+
 	e.HideBanner = true
 
 	e.Logger = lecho.From(log.With().Str("component", "server").Logger())
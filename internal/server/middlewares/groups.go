@@ -0,0 +1,39 @@
+package middlewares
+
+import (
+	"strings"
+
+	"github.com/labstack/echo/v4"
+	"github.com/worldline-go/auth/pkg/authecho"
+
+	"github.com/rakunlabs/chore/internal/server/claims"
+	"github.com/rakunlabs/chore/pkg/models"
+)
+
+// RolePrefix is added to user's groups in the token roles.
+var RolePrefix = "chore_"
+
+// GetAccess returns the access information of the request from the token.
+func GetAccess(c echo.Context) models.Access {
+	if v, ok := c.Get(authecho.KeyAuthNoop).(bool); ok && v {
+		return models.Access{All: true}
+	}
+
+	claim, ok := c.Get(authecho.KeyClaims).(*claims.Custom)
+	if !ok {
+		return models.Access{}
+	}
+
+	if claim.HasRole(AdminRoleKey) {
+		return models.Access{All: true}
+	}
+
+	access := models.Access{}
+	for role := range claim.RoleSet {
+		if group, ok := strings.CutPrefix(role, RolePrefix); ok && group != "" {
+			access.Groups = append(access.Groups, group)
+		}
+	}
+
+	return access
+}
@@ -84,3 +84,13 @@ func IDFromBody(c echo.Context) string {
 func IDFromQuery(c echo.Context) string {
 	return c.QueryParam("id")
 }
+
+// Subject returns the token subject of the request, empty for noop authentication.
+func Subject(c echo.Context) string {
+	claim, ok := c.Get(authecho.KeyClaims).(*claims.Custom)
+	if !ok {
+		return ""
+	}
+
+	return claim.Subject
+}
@@ -1,14 +1,12 @@
 package middlewares
 
 import (
-	"errors"
 	"net/http"
 
 	"github.com/labstack/echo/v4"
 	"github.com/rakunlabs/chore/internal/server/claims"
 	"github.com/rakunlabs/chore/pkg/models"
 	"github.com/rakunlabs/chore/pkg/registry"
-	"gorm.io/gorm"
 )
 
 func PatTokenExist(c echo.Context, claim *claims.Custom) error {
@@ -20,18 +18,20 @@ func PatTokenExist(c echo.Context, claim *claims.Custom) error {
 		return echo.NewHTTPError(http.StatusUnauthorized, "token id not found")
 	}
 
-	var count int64
+	token := models.Token{}
 	query := registry.Reg.DB.WithContext(c.Request().Context()).
-		Model(&models.Token{}).Where("id = ?", claim.TokenID)
+		Model(&models.Token{}).Select("rate_limit_rate", "rate_limit_burst").Where("id = ?", claim.TokenID)
 
-	result := query.Count(&count)
+	result := query.Limit(1).Find(&token)
 
-	if errors.Is(result.Error, gorm.ErrRecordNotFound) || count == 0 {
-		return echo.NewHTTPError(http.StatusUnauthorized, "pat token not exist")
-	}
 	if result.Error != nil {
 		return echo.NewHTTPError(http.StatusInternalServerError, result.Error.Error())
 	}
+	if result.RowsAffected == 0 {
+		return echo.NewHTTPError(http.StatusUnauthorized, "pat token not exist")
+	}
+
+	c.Set(KeyTokenRateLimit, token.RateLimit)
 
 	return nil
 }
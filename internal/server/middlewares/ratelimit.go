@@ -0,0 +1,137 @@
+package middlewares
+
+import (
+	"math"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/worldline-go/auth/pkg/authecho"
+	"golang.org/x/time/rate"
+
+	"github.com/rakunlabs/chore/internal/server/claims"
+	"github.com/rakunlabs/chore/pkg/models"
+	"github.com/rakunlabs/chore/pkg/models/apimodels"
+)
+
+var (
+	// EndpointRateLimit is used for endpoints without own limit.
+	EndpointRateLimit models.RateLimit
+	// TokenRateLimit is used for tokens without own limit.
+	TokenRateLimit models.RateLimit
+	// KeyTokenRateLimit holds the limit of the personal access token, set in PatTokenExist.
+	KeyTokenRateLimit = "token_rate_limit"
+
+	// RateLimiters keeps the buckets in this instance.
+	RateLimiters = NewLimiters()
+
+	// limiterIdle is the time to forget an unused bucket.
+	limiterIdle = 10 * time.Minute
+)
+
+type limiterEntry struct {
+	limiter  *rate.Limiter
+	lastSeen time.Time
+}
+
+// Limiters hold token buckets with a key.
+type Limiters struct {
+	limiters  map[string]*limiterEntry
+	lastSweep time.Time
+	mutex     sync.Mutex
+}
+
+func NewLimiters() *Limiters {
+	return &Limiters{
+		limiters:  make(map[string]*limiterEntry),
+		lastSweep: time.Now(),
+	}
+}
+
+// Allow takes one request from the bucket of the key, returns the wait time when bucket is empty.
+func (l *Limiters) Allow(key string, limit models.RateLimit) (bool, time.Duration) {
+	if !limit.Enabled() {
+		return true, 0
+	}
+
+	burst := limit.Burst
+	if burst < 1 {
+		burst = int(math.Max(1, math.Ceil(limit.Rate)))
+	}
+
+	now := time.Now()
+
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	l.sweep(now)
+
+	entry, ok := l.limiters[key]
+	if !ok {
+		entry = &limiterEntry{limiter: rate.NewLimiter(rate.Limit(limit.Rate), burst)}
+		l.limiters[key] = entry
+	}
+
+	// limit changed in endpoint or token
+	if entry.limiter.Limit() != rate.Limit(limit.Rate) || entry.limiter.Burst() != burst {
+		entry.limiter.SetLimitAt(now, rate.Limit(limit.Rate))
+		entry.limiter.SetBurstAt(now, burst)
+	}
+
+	entry.lastSeen = now
+
+	reservation := entry.limiter.ReserveN(now, 1)
+	if delay := reservation.DelayFrom(now); delay > 0 {
+		reservation.CancelAt(now)
+
+		return false, delay
+	}
+
+	return true, 0
+}
+
+// sweep removes unused buckets, they are full after idle time.
+func (l *Limiters) sweep(now time.Time) {
+	if now.Sub(l.lastSweep) < time.Minute {
+		return
+	}
+
+	l.lastSweep = now
+
+	for key, entry := range l.limiters {
+		refill := time.Duration(float64(entry.limiter.Burst()) / float64(entry.limiter.Limit()) * float64(time.Second))
+		if idle := now.Sub(entry.lastSeen); idle > limiterIdle && idle > refill {
+			delete(l.limiters, key)
+		}
+	}
+}
+
+// RateLimitExceeded returns 429 with the Retry-After seconds.
+func RateLimitExceeded(c echo.Context, wait time.Duration) error {
+	c.Response().Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(wait.Seconds()))))
+
+	return c.JSON(http.StatusTooManyRequests, apimodels.Error{Error: "rate limit exceeded"})
+}
+
+// TokenRate limits requests of the personal access tokens, comes after PatToken.
+func TokenRate(next echo.HandlerFunc) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		claim, ok := c.Get(authecho.KeyClaims).(*claims.Custom)
+		if !ok || claim.TokenType != models.TypePersonalAccessToken || claim.TokenID == "" {
+			return next(c)
+		}
+
+		limit, _ := c.Get(KeyTokenRateLimit).(models.RateLimit)
+		if !limit.Enabled() {
+			limit = TokenRateLimit
+		}
+
+		if ok, wait := RateLimiters.Allow("token:"+claim.TokenID, limit); !ok {
+			return RateLimitExceeded(c, wait)
+		}
+
+		return next(c)
+	}
+}
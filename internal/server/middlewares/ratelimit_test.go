@@ -0,0 +1,87 @@
+package middlewares
+
+import (
+	"testing"
+
+	"github.com/rakunlabs/chore/pkg/models"
+)
+
+func TestLimitersAllow(t *testing.T) {
+	tests := []struct {
+		name    string
+		limit   models.RateLimit
+		calls   int
+		allowed int
+	}{
+		{
+			name:    "unlimited",
+			limit:   models.RateLimit{},
+			calls:   10,
+			allowed: 10,
+		},
+		{
+			name:    "burst",
+			limit:   models.RateLimit{Rate: 1, Burst: 3},
+			calls:   5,
+			allowed: 3,
+		},
+		{
+			name:    "default burst is rate",
+			limit:   models.RateLimit{Rate: 2},
+			calls:   5,
+			allowed: 2,
+		},
+		{
+			name:    "low rate has one burst",
+			limit:   models.RateLimit{Rate: 0.1},
+			calls:   3,
+			allowed: 1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			l := NewLimiters()
+
+			allowed := 0
+			for i := 0; i < tt.calls; i++ {
+				ok, wait := l.Allow("test", tt.limit)
+				if ok {
+					allowed++
+
+					continue
+				}
+
+				if wait <= 0 {
+					t.Errorf("rejected call has wait %v", wait)
+				}
+			}
+
+			if allowed != tt.allowed {
+				t.Errorf("allowed = %d, want %d", allowed, tt.allowed)
+			}
+		})
+	}
+}
+
+func TestLimitersKeys(t *testing.T) {
+	l := NewLimiters()
+	limit := models.RateLimit{Rate: 1, Burst: 1}
+
+	if ok, _ := l.Allow("token:1", limit); !ok {
+		t.Fatal("first call of token:1 rejected")
+	}
+
+	if ok, _ := l.Allow("token:2", limit); !ok {
+		t.Fatal("token:2 should have own bucket")
+	}
+
+	if ok, _ := l.Allow("token:1", limit); ok {
+		t.Fatal("second call of token:1 allowed")
+	}
+
+	// removed limit of the endpoint or token
+	if ok, _ := l.Allow("token:1", models.RateLimit{}); !ok {
+		t.Fatal("call without limit rejected")
+	}
+}
@@ -0,0 +1,40 @@
+package db
+
+import (
+	"fmt"
+
+	"github.com/glebarez/sqlite"
+	"github.com/rs/zerolog/log"
+	gorm_zerolog "github.com/wei840222/gorm-zerolog"
+	"gorm.io/gorm"
+	"gorm.io/gorm/schema"
+)
+
+// SQLiteDB opens pure go sqlite database, schema option is not used.
+func SQLiteDB(cfg map[string]interface{}) (*gorm.DB, error) {
+	dsn, _ := cfg["dsn"].(string)
+	if dsn == "" {
+		fileName, _ := cfg["fileName"].(string)
+		if fileName == "" {
+			return nil, fmt.Errorf("sqlite file_name or db_data_source is required")
+		}
+
+		// foreign keys and wait instead of failing on concurrent writes
+		dsn = fmt.Sprintf("file:%s?_pragma=foreign_keys(1)&_pragma=busy_timeout(5000)&_pragma=journal_mode(WAL)", fileName)
+	}
+
+	gLog := gorm_zerolog.NewWithLogger(log.With().Str("component", "sqlite").Logger())
+	gLog.SkipErrRecordNotFound = true
+
+	db, err := gorm.Open(sqlite.Open(dsn), &gorm.Config{
+		NamingStrategy: schema.NamingStrategy{
+			SingularTable: false,
+		},
+		Logger: gLog,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("sqlite connection; %w", err)
+	}
+
+	return db, nil
+}
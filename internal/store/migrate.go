@@ -29,6 +29,7 @@ func AutoMigrate(ctx context.Context, dbConn *gorm.DB) error {
 			"schema":   choiceExist(config.Application.Migrate.Schema, config.Application.Store.Schema),
 			"timeZone": choiceExist(config.Application.Migrate.TimeZone, config.Application.Store.TimeZone),
 			"dsn":      choiceExist(config.Application.Migrate.DBDataSource, config.Application.Store.DBDataSource),
+			"fileName": choiceExist(config.Application.Migrate.FileName, config.Application.Store.FileName),
 		})
 		if err != nil {
 			return fmt.Errorf("cannot open db: %w", err)
@@ -12,5 +12,11 @@ var Models = []interface{}{
 	&models.Token{},
 	&models.Control{},
 	&models.Settings{},
+	&models.Run{},
+	&models.RunNode{},
+	&models.ControlRevision{},
+	&models.TemplateRevision{},
+	&models.Job{},
+	&models.Lease{},
 	// &models.Test{},
 }
@@ -10,13 +10,20 @@ import (
 )
 
 func OpenConnection(typeDB string, cfg map[string]interface{}) (*gorm.DB, error) {
-	switch strings.ToLower(typeDB) { //nolint:gocritic // newer types in future
+	switch strings.ToLower(typeDB) {
 	case "postgres":
 		gormDB, err := db.PostgresDB(cfg)
 		if err != nil {
 			return nil, fmt.Errorf("cannot open conneection to db; %w", err)
 		}
 
+		return gormDB, nil
+	case "sqlite":
+		gormDB, err := db.SQLiteDB(cfg)
+		if err != nil {
+			return nil, fmt.Errorf("cannot open conneection to db; %w", err)
+		}
+
 		return gormDB, nil
 	}
 
@@ -0,0 +1,104 @@
+package store
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/google/uuid"
+	"gorm.io/datatypes"
+
+	"github.com/rakunlabs/chore/pkg/models"
+	"github.com/rakunlabs/chore/pkg/models/apimodels"
+)
+
+func TestOpenConnectionSQLite(t *testing.T) {
+	dbConn, err := OpenConnection("sqlite", map[string]interface{}{
+		"fileName": filepath.Join(t.TempDir(), "chore.db"),
+	})
+	if err != nil {
+		t.Fatalf("OpenConnection() error = %v", err)
+	}
+
+	if err := dbConn.AutoMigrate(Models...); err != nil {
+		t.Fatalf("AutoMigrate() error = %v", err)
+	}
+
+	controls := []models.Control{
+		{ControlPureContent: models.ControlPureContent{ControlPure: models.ControlPure{Name: "open"}}},
+		{ControlPureContent: models.ControlPureContent{ControlPure: models.ControlPure{
+			Name: "team1", Groups: apimodels.Groups{Groups: datatypes.JSON(`["team1"]`)},
+		}}},
+		{ControlPureContent: models.ControlPureContent{ControlPure: models.ControlPure{
+			Name: "team2-read", Groups: apimodels.Groups{Groups: datatypes.JSON(`["team2:read"]`)},
+		}}},
+	}
+
+	for i := range controls {
+		controls[i].ID.ID = uuid.New()
+	}
+
+	if result := dbConn.Create(&controls); result.Error != nil {
+		t.Fatalf("Create() error = %v", result.Error)
+	}
+
+	// uuid columns queried with string values in handlers
+	got := models.Control{}
+	if result := dbConn.Where("id = ?", controls[1].ID.ID.String()).First(&got); result.Error != nil || got.Name != "team1" {
+		t.Fatalf("First() by id = %v, error = %v", got.Name, result.Error)
+	}
+
+	tests := []struct {
+		name   string
+		access models.Access
+		write  bool
+		want   []string
+	}{
+		{
+			name:   "admin",
+			access: models.Access{All: true},
+			want:   []string{"open", "team1", "team2-read"},
+		},
+		{
+			name:   "no groups",
+			access: models.Access{},
+			want:   []string{"open"},
+		},
+		{
+			name:   "team2 read",
+			access: models.Access{Groups: []string{"team2"}},
+			want:   []string{"open", "team2-read"},
+		},
+		{
+			name:   "team2 write",
+			access: models.Access{Groups: []string{"team2"}},
+			write:  true,
+			want:   []string{"open"},
+		},
+		{
+			name:   "team1 write",
+			access: models.Access{Groups: []string{"team1"}},
+			write:  true,
+			want:   []string{"open", "team1"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var names []string
+
+			result := dbConn.Model(&models.Control{}).Scopes(tt.access.Scope(tt.write)).Order("name").Pluck("name", &names)
+			if result.Error != nil {
+				t.Fatalf("Scope() error = %v", result.Error)
+			}
+
+			if len(names) != len(tt.want) {
+				t.Fatalf("Scope() = %v, want %v", names, tt.want)
+			}
+
+			for i := range names {
+				if names[i] != tt.want[i] {
+					t.Errorf("Scope() = %v, want %v", names, tt.want)
+				}
+			}
+		})
+	}
+}
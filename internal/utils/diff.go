@@ -0,0 +1,180 @@
+package utils
+
+import (
+	"strings"
+)
+
+// Diff returns line based difference of the texts.
+//
+// Lines start with "  " for unchanged, "- " for removed and "+ " for added lines.
+// It uses Myers' algorithm with the middle snake, memory is linear with the line counts.
+func Diff(from, to string) string {
+	d := differ{
+		a: splitLines(from),
+		b: splitLines(to),
+	}
+
+	d.diff(0, len(d.a), 0, len(d.b))
+
+	return d.sb.String()
+}
+
+type differ struct {
+	a, b []string
+	sb   strings.Builder
+}
+
+func (d *differ) write(prefix string, lines []string) {
+	for _, line := range lines {
+		d.sb.WriteString(prefix + line + "\n")
+	}
+}
+
+// diff writes the difference of a[a0:a1] and b[b0:b1].
+func (d *differ) diff(a0, a1, b0, b1 int) {
+	// common prefix
+	prefix := 0
+	for a0+prefix < a1 && b0+prefix < b1 && d.a[a0+prefix] == d.b[b0+prefix] {
+		prefix++
+	}
+
+	d.write("  ", d.a[a0:a0+prefix])
+	a0 += prefix
+	b0 += prefix
+
+	// common suffix written at the end
+	suffix := 0
+	for a1-suffix > a0 && b1-suffix > b0 && d.a[a1-suffix-1] == d.b[b1-suffix-1] {
+		suffix++
+	}
+
+	a1 -= suffix
+	b1 -= suffix
+
+	switch {
+	case a0 == a1:
+		d.write("+ ", d.b[b0:b1])
+	case b0 == b1:
+		d.write("- ", d.a[a0:a1])
+	default:
+		if x, y, ok := d.bisect(a0, a1, b0, b1); ok {
+			d.diff(a0, x, b0, y)
+			d.diff(x, a1, y, b1)
+		} else {
+			d.write("- ", d.a[a0:a1])
+			d.write("+ ", d.b[b0:b1])
+		}
+	}
+
+	d.write("  ", d.a[a1:a1+suffix])
+}
+
+// bisect finds the middle snake of a[a0:a1] and b[b0:b1] walking forward and backward at the same time.
+// Returns the split point to diff both parts separately.
+func (d *differ) bisect(a0, a1, b0, b1 int) (int, int, bool) {
+	n, m := a1-a0, b1-b0
+
+	maxD := (n + m + 1) / 2
+	offset := maxD
+	length := 2*maxD + 2
+
+	v1 := make([]int, length)
+	v2 := make([]int, length)
+
+	for i := range v1 {
+		v1[i] = -1
+		v2[i] = -1
+	}
+
+	v1[offset+1] = 0
+	v2[offset+1] = 0
+
+	delta := n - m
+	// odd delta collides in the forward path
+	front := delta%2 != 0
+
+	var k1start, k1end, k2start, k2end int
+
+	for step := 0; step <= maxD; step++ {
+		// forward path
+		for k1 := -step + k1start; k1 <= step-k1end; k1 += 2 {
+			k1Offset := offset + k1
+
+			var x1 int
+			if k1 == -step || (k1 != step && v1[k1Offset-1] < v1[k1Offset+1]) {
+				x1 = v1[k1Offset+1]
+			} else {
+				x1 = v1[k1Offset-1] + 1
+			}
+
+			y1 := x1 - k1
+			for x1 < n && y1 < m && d.a[a0+x1] == d.b[b0+y1] {
+				x1++
+				y1++
+			}
+
+			v1[k1Offset] = x1
+
+			switch {
+			case x1 > n:
+				k1end += 2
+			case y1 > m:
+				k1start += 2
+			case front:
+				k2Offset := offset + delta - k1
+				if k2Offset >= 0 && k2Offset < length && v2[k2Offset] != -1 {
+					if x1 >= n-v2[k2Offset] {
+						return a0 + x1, b0 + y1, true
+					}
+				}
+			}
+		}
+
+		// reverse path
+		for k2 := -step + k2start; k2 <= step-k2end; k2 += 2 {
+			k2Offset := offset + k2
+
+			var x2 int
+			if k2 == -step || (k2 != step && v2[k2Offset-1] < v2[k2Offset+1]) {
+				x2 = v2[k2Offset+1]
+			} else {
+				x2 = v2[k2Offset-1] + 1
+			}
+
+			y2 := x2 - k2
+			for x2 < n && y2 < m && d.a[a1-x2-1] == d.b[b1-y2-1] {
+				x2++
+				y2++
+			}
+
+			v2[k2Offset] = x2
+
+			switch {
+			case x2 > n:
+				k2end += 2
+			case y2 > m:
+				k2start += 2
+			case !front:
+				k1Offset := offset + delta - k2
+				if k1Offset >= 0 && k1Offset < length && v1[k1Offset] != -1 {
+					x1 := v1[k1Offset]
+					y1 := offset + x1 - k1Offset
+
+					if x1 >= n-x2 {
+						return a0 + x1, b0 + y1, true
+					}
+				}
+			}
+		}
+	}
+
+	return 0, 0, false
+}
+
+func splitLines(v string) []string {
+	if v == "" {
+		return nil
+	}
+
+	return strings.Split(strings.TrimSuffix(v, "\n"), "\n")
+}
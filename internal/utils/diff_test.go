@@ -0,0 +1,85 @@
+package utils
+
+import (
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func TestDiff(t *testing.T) {
+	tests := []struct {
+		name string
+		from string
+		to   string
+		want string
+	}{
+		{
+			name: "empty",
+			want: "",
+		},
+		{
+			name: "same",
+			from: "a\nb\n",
+			to:   "a\nb",
+			want: "  a\n  b\n",
+		},
+		{
+			name: "added",
+			to:   "a\nb",
+			want: "+ a\n+ b\n",
+		},
+		{
+			name: "changed",
+			from: "a\nb\nc",
+			to:   "a\nx\nc\nd",
+			want: "  a\n- b\n+ x\n  c\n+ d\n",
+		},
+		{
+			name: "moved",
+			from: "a\nb\nc\nd",
+			to:   "c\nd\na\nb",
+			want: "- a\n- b\n  c\n  d\n+ a\n+ b\n",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Diff(tt.from, tt.to); got != tt.want {
+				t.Errorf("Diff() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDiffLarge(t *testing.T) {
+	var from, to []string
+	for i := range 20000 {
+		line := strconv.Itoa(i)
+		if i%7 != 0 {
+			from = append(from, line)
+		}
+		if i%5 != 0 {
+			to = append(to, line)
+		}
+	}
+
+	var gotFrom, gotTo []string
+	for _, line := range strings.Split(strings.TrimSuffix(Diff(strings.Join(from, "\n"), strings.Join(to, "\n")), "\n"), "\n") {
+		switch line[:2] {
+		case "  ":
+			gotFrom = append(gotFrom, line[2:])
+			gotTo = append(gotTo, line[2:])
+		case "- ":
+			gotFrom = append(gotFrom, line[2:])
+		case "+ ":
+			gotTo = append(gotTo, line[2:])
+		}
+	}
+
+	if strings.Join(gotFrom, "\n") != strings.Join(from, "\n") {
+		t.Error("Diff() removed lines do not build the source")
+	}
+
+	if strings.Join(gotTo, "\n") != strings.Join(to, "\n") {
+		t.Error("Diff() added lines do not build the target")
+	}
+}
@@ -0,0 +1,107 @@
+package flow
+
+import (
+	"errors"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+var ErrRunCanceled = errors.New("run canceled")
+
+// ActiveRuns hold running flows in this instance.
+var ActiveRuns = &Runs{runs: make(map[uuid.UUID]*NodesReg)}
+
+// RunInfo is live information of a running flow.
+type RunInfo struct {
+	ID        uuid.UUID  `json:"id"`
+	ParentID  *uuid.UUID `json:"parent_id,omitempty"`
+	Control   string     `json:"control"`
+	Endpoint  string     `json:"endpoint"`
+	Method    string     `json:"method"`
+	StartedAt time.Time  `json:"started_at"`
+	// Jobs is the count of running branches.
+	Jobs int64 `json:"jobs"`
+	// Waiting for the run limits.
+	Waiting bool `json:"waiting"`
+}
+
+type Runs struct {
+	runs  map[uuid.UUID]*NodesReg
+	mutex sync.RWMutex
+}
+
+func (a *Runs) add(reg *NodesReg) {
+	a.mutex.Lock()
+	defer a.mutex.Unlock()
+
+	a.runs[reg.runID] = reg
+}
+
+func (a *Runs) remove(reg *NodesReg) {
+	a.mutex.Lock()
+	defer a.mutex.Unlock()
+
+	delete(a.runs, reg.runID)
+}
+
+// List returns running flows, oldest first.
+func (a *Runs) List() []RunInfo {
+	a.mutex.RLock()
+	defer a.mutex.RUnlock()
+
+	infos := make([]RunInfo, 0, len(a.runs))
+	for _, reg := range a.runs {
+		infos = append(infos, reg.Info())
+	}
+
+	sort.Slice(infos, func(i, j int) bool {
+		return infos[i].StartedAt.Before(infos[j].StartedAt)
+	})
+
+	return infos
+}
+
+// Get returns the running flow.
+func (a *Runs) Get(id uuid.UUID) (*NodesReg, bool) {
+	a.mutex.RLock()
+	defer a.mutex.RUnlock()
+
+	reg, ok := a.runs[id]
+
+	return reg, ok
+}
+
+// Info returns live information of the run.
+func (r *NodesReg) Info() RunInfo {
+	r.mutexCount.Lock()
+	jobs := r.totalCount
+	r.mutexCount.Unlock()
+
+	return RunInfo{
+		ID:        r.runID,
+		ParentID:  r.parentID,
+		Control:   r.controlName,
+		Endpoint:  r.startName,
+		Method:    r.method,
+		StartedAt: r.startedAt,
+		Jobs:      jobs,
+		Waiting:   r.waiting.Load(),
+	}
+}
+
+// Cancel stops the run, running nodes get canceled context and waiting nodes released.
+// Runs started inside of this run also canceled.
+func (r *NodesReg) Cancel() {
+	r.cancelOnce.Do(func() {
+		r.AddError(ErrRunCanceled)
+
+		if r.cancel != nil {
+			r.cancel()
+		}
+
+		r.Clear()
+	})
+}
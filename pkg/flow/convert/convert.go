@@ -1,6 +1,9 @@
 package convert
 
-import "strings"
+import (
+	"strconv"
+	"strings"
+)
 
 func GetBoolean(value interface{}) bool {
 	switch v := value.(type) {
@@ -13,6 +16,24 @@ func GetBoolean(value interface{}) bool {
 	}
 }
 
+// GetInt accepts number or numeric string, empty is 0.
+func GetInt(value interface{}) (int, error) {
+	switch v := value.(type) {
+	case float64:
+		return int(v), nil
+	case int:
+		return v, nil
+	case string:
+		if v = strings.TrimSpace(v); v == "" {
+			return 0, nil
+		}
+
+		return strconv.Atoi(v) //nolint:wrapcheck // caller adds the key
+	default:
+		return 0, nil
+	}
+}
+
 func GetList(value interface{}) []string {
 	switch v := value.(type) {
 	case []string:
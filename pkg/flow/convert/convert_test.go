@@ -91,3 +91,30 @@ func TestGetBoolean(t *testing.T) {
 		})
 	}
 }
+
+func TestGetInt(t *testing.T) {
+	tests := []struct {
+		name    string
+		value   interface{}
+		want    int
+		wantErr bool
+	}{
+		{name: "number", value: float64(5), want: 5},
+		{name: "string", value: " 10 ", want: 10},
+		{name: "empty", value: "", want: 0},
+		{name: "nil", value: nil, want: 0},
+		{name: "wrong", value: "many", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := GetInt(tt.value)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("GetInt() error = %v, wantErr %v", err, tt.wantErr)
+			}
+
+			if got != tt.want {
+				t.Errorf("GetInt() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,263 @@
+package flow
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"mime/multipart"
+	"os"
+	"strings"
+
+	"github.com/rakunlabs/chore/pkg/email"
+)
+
+// UploadSpoolSize writes uploaded files bigger than this to temp files, 0 keeps them in memory.
+var UploadSpoolSize int64
+
+// File is an uploaded file of the call, forwarded as attachment with email and request nodes.
+type File struct {
+	Field       string `json:"field"`
+	Name        string `json:"name"`
+	ContentType string `json:"content_type"`
+	Data        []byte `json:"-"`
+	// Path is the content spooled to the disk, Data is empty.
+	Path string `json:"-"`
+	// Size of the spooled content.
+	Size int64 `json:"-"`
+}
+
+// Open returns the reader of the content.
+func (f File) Open() (io.ReadCloser, error) {
+	if f.Path == "" {
+		return io.NopCloser(bytes.NewReader(f.Data)), nil
+	}
+
+	return os.Open(f.Path) //nolint:wrapcheck // path error is clear
+}
+
+// Len returns size of the content.
+func (f File) Len() int64 {
+	if f.Path != "" {
+		return f.Size
+	}
+
+	return int64(len(f.Data))
+}
+
+// Bytes returns the content, spooled content is read from the disk.
+func (f File) Bytes() ([]byte, error) {
+	if f.Path == "" {
+		return f.Data, nil
+	}
+
+	return os.ReadFile(f.Path) //nolint:wrapcheck // path error is clear
+}
+
+// IsMultipart reports the content type is multipart/form-data.
+func IsMultipart(contentType string) bool {
+	mediaType, _, err := mime.ParseMediaType(contentType)
+
+	return err == nil && mediaType == "multipart/form-data"
+}
+
+// ReadMultipart sets the files of the multipart body to the request and returns fields as json object.
+// Files bigger than UploadSpoolSize are written to temp files, remove them with RemoveFiles.
+// Body returns back when it is not multipart.
+func (r *Request) ReadMultipart(body io.Reader) ([]byte, error) {
+	if r == nil || !IsMultipart(r.Header["content-type"]) {
+		data, err := io.ReadAll(body)
+		if err != nil {
+			return nil, fmt.Errorf("cannot read body: %w", err)
+		}
+
+		return data, nil
+	}
+
+	_, params, _ := mime.ParseMediaType(r.Header["content-type"])
+
+	reader := multipart.NewReader(body, params["boundary"])
+
+	fields := make(map[string]interface{})
+	r.Files = nil
+
+	for {
+		part, err := reader.NextPart()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+
+		if err != nil {
+			r.RemoveFiles()
+
+			return nil, fmt.Errorf("cannot read multipart: %w", err)
+		}
+
+		if part.FileName() != "" {
+			file, err := readFilePart(part)
+			if err != nil {
+				r.RemoveFiles()
+
+				return nil, fmt.Errorf("cannot read multipart %s: %w", part.FormName(), err)
+			}
+
+			r.Files = append(r.Files, file)
+
+			continue
+		}
+
+		data, err := io.ReadAll(part)
+		if err != nil {
+			r.RemoveFiles()
+
+			return nil, fmt.Errorf("cannot read multipart %s: %w", part.FormName(), err)
+		}
+
+		// multiple values of same field is a list
+		switch v := fields[part.FormName()].(type) {
+		case nil:
+			fields[part.FormName()] = string(data)
+		case string:
+			fields[part.FormName()] = []string{v, string(data)}
+		case []string:
+			fields[part.FormName()] = append(v, string(data))
+		}
+	}
+
+	return json.Marshal(fields) //nolint:wrapcheck // no need
+}
+
+// readFilePart reads the file in memory, bigger than UploadSpoolSize is copied to a temp file.
+func readFilePart(part *multipart.Part) (File, error) {
+	file := File{
+		Field:       part.FormName(),
+		Name:        part.FileName(),
+		ContentType: part.Header.Get("Content-Type"),
+	}
+
+	if UploadSpoolSize <= 0 {
+		data, err := io.ReadAll(part)
+		file.Data = data
+
+		return file, err //nolint:wrapcheck // wrapped by caller
+	}
+
+	// small files stay in memory
+	var buf bytes.Buffer
+	if _, err := io.CopyN(&buf, part, UploadSpoolSize+1); err != nil {
+		if !errors.Is(err, io.EOF) {
+			return file, err //nolint:wrapcheck // wrapped by caller
+		}
+
+		file.Data = buf.Bytes()
+
+		return file, nil
+	}
+
+	f, err := os.CreateTemp("", "chore-upload-*")
+	if err != nil {
+		return file, fmt.Errorf("cannot create temp file: %w", err)
+	}
+
+	file.Size, err = io.Copy(f, io.MultiReader(&buf, part))
+	if errClose := f.Close(); err == nil {
+		err = errClose
+	}
+
+	if err != nil {
+		os.Remove(f.Name())
+
+		return file, err //nolint:wrapcheck // wrapped by caller
+	}
+
+	file.Path = f.Name()
+
+	return file, nil
+}
+
+// RemoveFiles removes the spooled files of the request.
+func (r *Request) RemoveFiles() {
+	for _, f := range r.Files {
+		if f.Path != "" {
+			os.Remove(f.Path)
+		}
+	}
+}
+
+// RemoveFilesAfter removes the spooled files of the request when the run ends.
+func (r *Request) RemoveFilesAfter(nodesReg *NodesReg) {
+	for _, f := range r.Files {
+		if f.Path != "" {
+			go func() {
+				<-nodesReg.Done()
+				r.RemoveFiles()
+			}()
+
+			return
+		}
+	}
+}
+
+// Attachments returns the files as email attachments, every call has new readers.
+// Spooled files are opened on the first read and closed at the end.
+func Attachments(files []File) []email.Attach {
+	attachments := make([]email.Attach, 0, len(files))
+	for _, f := range files {
+		var content io.Reader = bytes.NewReader(f.Data)
+		if f.Path != "" {
+			content = &fileReader{path: f.Path}
+		}
+
+		attachments = append(attachments, email.Attach{
+			FileName: f.Name,
+			Content:  content,
+		})
+	}
+
+	return attachments
+}
+
+// fileReader opens the file when reading starts, not to keep descriptors of unused attachments.
+type fileReader struct {
+	path string
+	file *os.File
+	err  error
+}
+
+func (r *fileReader) Read(p []byte) (int, error) {
+	if r.err != nil {
+		return 0, r.err
+	}
+
+	if r.file == nil {
+		r.file, r.err = os.Open(r.path)
+		if r.err != nil {
+			return 0, r.err
+		}
+	}
+
+	n, err := r.file.Read(p)
+	if err != nil {
+		r.file.Close()
+		r.err = err
+	}
+
+	return n, err //nolint:wrapcheck // reader error
+}
+
+// filesInfo returns files without content for scripts and templates.
+func filesInfo(files []File) []interface{} {
+	info := make([]interface{}, 0, len(files))
+	for _, f := range files {
+		info = append(info, map[string]interface{}{
+			"field":        f.Field,
+			"name":         f.Name,
+			"content_type": strings.TrimSpace(f.ContentType),
+			"size":         f.Len(),
+		})
+	}
+
+	return info
+}
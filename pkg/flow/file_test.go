@@ -0,0 +1,147 @@
+package flow
+
+import (
+	"bytes"
+	"mime/multipart"
+	"net/http"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestRequestReadMultipart(t *testing.T) {
+	var buf bytes.Buffer
+
+	writer := multipart.NewWriter(&buf)
+	_ = writer.WriteField("name", "deepcore")
+	_ = writer.WriteField("tag", "a")
+	_ = writer.WriteField("tag", "b")
+
+	part, _ := writer.CreateFormFile("report", "report.csv")
+	_, _ = part.Write([]byte("id,value\n1,2\n"))
+	_ = writer.Close()
+
+	tests := []struct {
+		name        string
+		contentType string
+		body        []byte
+		want        string
+		wantFiles   []File
+		wantErr     bool
+	}{
+		{
+			name:        "json",
+			contentType: "application/json",
+			body:        []byte(`{"name":"deepcore"}`),
+			want:        `{"name":"deepcore"}`,
+		},
+		{
+			name:        "multipart",
+			contentType: writer.FormDataContentType(),
+			body:        buf.Bytes(),
+			want:        `{"name":"deepcore","tag":["a","b"]}`,
+			wantFiles: []File{{
+				Field:       "report",
+				Name:        "report.csv",
+				ContentType: "application/octet-stream",
+				Data:        []byte("id,value\n1,2\n"),
+			}},
+		},
+		{
+			name:        "broken multipart",
+			contentType: "multipart/form-data; boundary=xyz",
+			body:        []byte("--xyz\r\nbroken"),
+			wantErr:     true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := NewRequest(http.MethodPost, nil, http.Header{"Content-Type": []string{tt.contentType}})
+
+			got, err := r.ReadMultipart(bytes.NewReader(tt.body))
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ReadMultipart() error = %v, wantErr %v", err, tt.wantErr)
+			}
+
+			if tt.wantErr {
+				return
+			}
+
+			if string(got) != tt.want {
+				t.Errorf("ReadMultipart() = %s, want %s", got, tt.want)
+			}
+
+			if !reflect.DeepEqual(r.Files, tt.wantFiles) {
+				t.Errorf("ReadMultipart() files = %+v, want %+v", r.Files, tt.wantFiles)
+			}
+		})
+	}
+}
+
+func TestRequestReadMultipartSpool(t *testing.T) {
+	defer func(v int64) { UploadSpoolSize = v }(UploadSpoolSize)
+
+	UploadSpoolSize = 4
+
+	var buf bytes.Buffer
+
+	writer := multipart.NewWriter(&buf)
+	small, _ := writer.CreateFormFile("small", "small.txt")
+	_, _ = small.Write([]byte("abcd"))
+	big, _ := writer.CreateFormFile("big", "big.txt")
+	_, _ = big.Write([]byte("abcde"))
+	_ = writer.Close()
+
+	r := NewRequest(http.MethodPost, nil, http.Header{"Content-Type": []string{writer.FormDataContentType()}})
+
+	if _, err := r.ReadMultipart(&buf); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(r.Files) != 2 {
+		t.Fatalf("files = %d, want 2", len(r.Files))
+	}
+
+	if r.Files[0].Path != "" || string(r.Files[0].Data) != "abcd" {
+		t.Errorf("small file = %+v, want in memory", r.Files[0])
+	}
+
+	if r.Files[1].Path == "" || r.Files[1].Data != nil || r.Files[1].Len() != 5 {
+		t.Fatalf("big file = %+v, want spooled", r.Files[1])
+	}
+
+	if data, err := r.Files[1].Bytes(); err != nil || string(data) != "abcde" {
+		t.Errorf("big file content = %s, %v", data, err)
+	}
+
+	r.RemoveFiles()
+
+	if _, err := os.Stat(r.Files[1].Path); !os.IsNotExist(err) {
+		t.Errorf("spooled file is not removed: %v", err)
+	}
+}
+
+func TestKeepRespondFile(t *testing.T) {
+	defer func(v int64) { ResultLimit = v }(ResultLimit)
+
+	ResultLimit = 4
+
+	path := filepath.Join(t.TempDir(), "body")
+	if err := os.WriteFile(path, []byte("data"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	respond := Respond{Status: http.StatusOK, File: &File{Path: path, Size: 4}}
+
+	if got := keepRespondFile(respond); string(got.Data) != "data" || got.Status != http.StatusOK {
+		t.Errorf("keepRespondFile() = %s %d, want data %d", got.Data, got.Status, http.StatusOK)
+	}
+
+	ResultLimit = 3
+
+	if got := keepRespondFile(respond); !got.IsError || got.Status != http.StatusInsufficientStorage {
+		t.Errorf("keepRespondFile() = %s %d, want error %d", got.Data, got.Status, http.StatusInsufficientStorage)
+	}
+}
@@ -0,0 +1,198 @@
+package flow
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/rs/zerolog/log"
+
+	"github.com/rakunlabs/chore/pkg/models"
+	"github.com/rakunlabs/chore/pkg/models/apimodels"
+)
+
+var (
+	// HistoryDisabled stops recording runs to the database.
+	HistoryDisabled = false
+	// HistoryPayloadLimit is the max recorded size of node input and output.
+	HistoryPayloadLimit = 4096
+	// historyBatchSize for inserting node records, full batches are written during the run.
+	historyBatchSize = 100
+)
+
+const CtxRunID ContextType = "run_id"
+
+// RunID returns unique id of this run.
+func (r *NodesReg) RunID() uuid.UUID {
+	return r.runID
+}
+
+// historyEnabled reports the run record is written, async runs keep it even history disabled.
+func (r *NodesReg) historyEnabled() bool {
+	return (!HistoryDisabled || r.keepResult) && r.appStore != nil && r.appStore.DB != nil
+}
+
+// historyStart records the run as running.
+func (r *NodesReg) historyStart(ctx context.Context) {
+	r.startedAt = time.Now()
+
+	if !r.historyEnabled() {
+		return
+	}
+
+	run := models.Run{
+		RunPure: models.RunPure{
+			Control:   r.controlName,
+			Endpoint:  r.startName,
+			Method:    r.method,
+			Status:    models.RunStatusRunning,
+			ParentID:  r.parentID,
+			StartedAt: r.startedAt,
+		},
+		ID: apimodels.ID{ID: r.runID},
+	}
+
+	if result := r.appStore.DB.WithContext(context.WithoutCancel(ctx)).Create(&run); result.Error != nil {
+		log.Ctx(ctx).Warn().Err(result.Error).Msg("cannot record run history")
+	}
+}
+
+// historyFinish closes the run record and writes remaining node records.
+func (r *NodesReg) historyFinish(ctx context.Context, runErr error) {
+	if !r.historyEnabled() {
+		return
+	}
+
+	ctx = context.WithoutCancel(ctx)
+
+	finishedAt := time.Now()
+
+	errStr := ""
+	if runErr != nil {
+		errStr = runErr.Error()
+	}
+
+	values := map[string]interface{}{
+		"status":      RunStatus(runErr),
+		"error":       errStr,
+		"finished_at": finishedAt,
+		"duration":    finishedAt.Sub(r.startedAt).Milliseconds(),
+	}
+
+	if r.keepResult {
+		r.mutex.RLock()
+		respond := r.result
+		r.mutex.RUnlock()
+
+		if respond != nil {
+			header, _ := json.Marshal(respond.Header)
+
+			values["result_status"] = respond.Status
+			values["result_header"] = header
+			values["result_error"] = respond.IsError
+			values["result"] = respond.Data
+		}
+	}
+
+	result := r.appStore.DB.WithContext(ctx).Model(&models.Run{}).Where("id = ?", r.runID).Updates(values)
+	if result.Error != nil {
+		log.Ctx(ctx).Warn().Err(result.Error).Msg("cannot update run history")
+	}
+
+	r.historyMutex.Lock()
+	records := r.historyNodes
+	r.historyNodes = nil
+	r.historyMutex.Unlock()
+
+	r.historyWrite(ctx, records)
+}
+
+// historyWrite inserts node records.
+func (r *NodesReg) historyWrite(ctx context.Context, records []models.RunNode) {
+	if len(records) == 0 {
+		return
+	}
+
+	if result := r.appStore.DB.WithContext(ctx).CreateInBatches(records, historyBatchSize); result.Error != nil {
+		log.Ctx(ctx).Warn().Err(result.Error).Msg("cannot record node history")
+	}
+}
+
+// historyFailed records a run which could not start.
+func (r *NodesReg) historyFailed(ctx context.Context, err error) {
+	r.historyStart(ctx)
+	r.historyFinish(ctx, err)
+}
+
+// historyNode keeps execution information of one node and writes them when the batch is full.
+func (r *NodesReg) historyNode(ctx context.Context, node Noder, input, output NodeRet, startedAt time.Time, err error) {
+	if HistoryDisabled || !r.historyEnabled() {
+		return
+	}
+
+	record := models.RunNode{
+		RunNodePure: models.RunNodePure{
+			RunID:     r.runID,
+			NodeID:    node.NodeID(),
+			Type:      node.GetType(),
+			StartedAt: startedAt,
+			Duration:  time.Since(startedAt).Milliseconds(),
+		},
+		ID: apimodels.ID{ID: uuid.New()},
+	}
+
+	if input != nil {
+		record.Input = truncatePayload(input.GetBinaryData())
+	}
+
+	if output != nil {
+		record.Output = truncatePayload(output.GetBinaryData())
+
+		if v, ok := output.(NodeRetSelection); ok {
+			record.Selection, _ = json.Marshal(v.GetSelection())
+		}
+	}
+
+	if err != nil {
+		record.Error = err.Error()
+	}
+
+	r.historyMutex.Lock()
+	r.historyNodes = append(r.historyNodes, record)
+
+	var records []models.RunNode
+	if len(r.historyNodes) >= historyBatchSize {
+		records = r.historyNodes
+		r.historyNodes = make([]models.RunNode, 0, historyBatchSize)
+	}
+	r.historyMutex.Unlock()
+
+	r.historyWrite(context.WithoutCancel(ctx), records)
+}
+
+// Err combines errors of the run.
+func (r *NodesReg) Err() error {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	return errors.Join(r.errors...)
+}
+
+// truncatePayload cuts the payload and keeps it storable as text.
+func truncatePayload(v []byte) string {
+	truncated := false
+	if HistoryPayloadLimit >= 0 && len(v) > HistoryPayloadLimit {
+		v = v[:HistoryPayloadLimit]
+		truncated = true
+	}
+
+	ret := strings.ToValidUTF8(strings.ReplaceAll(string(v), "\x00", ""), "\uFFFD")
+	if truncated {
+		ret += "...(truncated)"
+	}
+
+	return ret
+}
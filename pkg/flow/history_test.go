@@ -0,0 +1,97 @@
+package flow
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/rakunlabs/chore/internal/store"
+	"github.com/rakunlabs/chore/pkg/models"
+	"github.com/rakunlabs/chore/pkg/registry"
+)
+
+func TestTruncatePayload(t *testing.T) {
+	defer func(v int) { HistoryPayloadLimit = v }(HistoryPayloadLimit)
+
+	HistoryPayloadLimit = 4
+
+	tests := []struct {
+		name string
+		data []byte
+		want string
+	}{
+		{
+			name: "short",
+			data: []byte("abc"),
+			want: "abc",
+		},
+		{
+			name: "long",
+			data: []byte("abcdef"),
+			want: "abcd...(truncated)",
+		},
+		{
+			name: "invalid utf8",
+			data: []byte("a\x00\xffb"),
+			want: "a�b",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := truncatePayload(tt.data); got != tt.want {
+				t.Errorf("truncatePayload() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+type historyTestNode struct {
+	Noder
+}
+
+func (historyTestNode) NodeID() string  { return "1" }
+func (historyTestNode) GetType() string { return "test" }
+
+func TestHistoryNodeBatch(t *testing.T) {
+	defer func(v int) { historyBatchSize = v }(historyBatchSize)
+
+	historyBatchSize = 2
+
+	dbConn, err := store.OpenConnection("sqlite", map[string]interface{}{
+		"fileName": filepath.Join(t.TempDir(), "chore.db"),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := dbConn.AutoMigrate(store.Models...); err != nil {
+		t.Fatal(err)
+	}
+
+	ctx := context.Background()
+	reg := NewNodesReg("try", "test", "POST", &registry.Registry{DB: dbConn}, models.Access{})
+
+	count := func() int64 {
+		var v int64
+		dbConn.Model(&models.RunNode{}).Where("run_id = ?", reg.RunID()).Count(&v)
+
+		return v
+	}
+
+	reg.historyStart(ctx)
+
+	for i, want := range []int64{0, 2, 2} {
+		reg.historyNode(ctx, historyTestNode{}, nil, nil, time.Now(), nil)
+
+		if got := count(); got != want {
+			t.Errorf("node %d records = %d, want %d", i, got, want)
+		}
+	}
+
+	reg.historyFinish(ctx, nil)
+
+	if got := count(); got != 3 {
+		t.Errorf("finished records = %d, want 3", got)
+	}
+}
@@ -0,0 +1,171 @@
+package flow
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+)
+
+// MaxParallel is the default running values of the for loop at the same time, 0 is unlimited.
+var MaxParallel = 0
+
+const ctxParallelSlots ContextType = "parallel_slots"
+
+// RunLimits hold the places of the concurrent runs, excess runs wait.
+var RunLimits = &Limits{}
+
+type Limits struct {
+	global       chan struct{}
+	controls     map[string]chan struct{}
+	controlLimit int
+	overrides    map[string]int
+	mutex        sync.Mutex
+}
+
+// Set changes the limits, 0 is unlimited.
+// Runs already waiting keep the previous limits.
+func (l *Limits) Set(runs, controlRuns int, controls map[string]int) {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	l.global = nil
+	if runs > 0 {
+		l.global = make(chan struct{}, runs)
+	}
+
+	l.controls = make(map[string]chan struct{})
+	l.controlLimit = controlRuns
+	l.overrides = controls
+}
+
+func (l *Limits) get(control string) (chan struct{}, chan struct{}) {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	limit := l.controlLimit
+	if v, ok := l.overrides[control]; ok {
+		limit = v
+	}
+
+	if limit <= 0 {
+		return l.global, nil
+	}
+
+	sem, ok := l.controls[control]
+	if !ok {
+		sem = make(chan struct{}, limit)
+		l.controls[control] = sem
+	}
+
+	return l.global, sem
+}
+
+// acquire waits places of control and process, release after the run.
+func (l *Limits) acquire(ctx context.Context, control string) (func(), error) {
+	global, sem := l.get(control)
+
+	// control first, waiting runs not hold the global place
+	for _, s := range []chan struct{}{sem, global} {
+		if s == nil {
+			continue
+		}
+
+		select {
+		case s <- struct{}{}:
+		case <-ctx.Done():
+			if sem != nil && s == global {
+				<-sem
+			}
+
+			return nil, ctx.Err()
+		}
+	}
+
+	return func() {
+		if global != nil {
+			<-global
+		}
+
+		if sem != nil {
+			<-sem
+		}
+	}, nil
+}
+
+// waitRunLimit waits the run limits, runs started inside of other runs not wait to prevent deadlock.
+func (r *NodesReg) waitRunLimit(ctx context.Context) (func(), error) {
+	if r.parentID != nil {
+		return func() {}, nil
+	}
+
+	r.waiting.Store(true)
+	defer r.waiting.Store(false)
+
+	return RunLimits.acquire(ctx, r.controlName)
+}
+
+// parallelSlot holds a place of the for loop until all branches of that value finished.
+type parallelSlot struct {
+	count   int64
+	release func()
+}
+
+func (s *parallelSlot) add() {
+	atomic.AddInt64(&s.count, 1)
+}
+
+func (s *parallelSlot) done() {
+	if atomic.AddInt64(&s.count, -1) == 0 {
+		s.release()
+	}
+}
+
+// slotsAdd counts new branch in the for loop places of the context.
+func slotsAdd(ctx context.Context) {
+	slots, _ := ctx.Value(ctxParallelSlots).([]*parallelSlot)
+	for _, s := range slots {
+		s.add()
+	}
+}
+
+// slotsDone releases the branch in the for loop places of the context.
+func slotsDone(ctx context.Context) {
+	slots, _ := ctx.Value(ctxParallelSlots).([]*parallelSlot)
+	for _, s := range slots {
+		s.done()
+	}
+}
+
+// branchParallel runs the values with limited places, waiting is counted as stuck.
+func branchParallel(ctx context.Context, nexts []Connection, reg *NodesReg, datas [][]byte, limit int) {
+	sem := make(chan struct{}, limit)
+
+	slots, _ := ctx.Value(ctxParallelSlots).([]*parallelSlot)
+
+	for i := range datas {
+		select {
+		case sem <- struct{}{}:
+		default:
+			reg.UpdateStuck(CountStuckIncrease, true)
+
+			select {
+			case sem <- struct{}{}:
+			case <-ctx.Done():
+				reg.UpdateStuck(CountStuckDecrease, false)
+
+				return
+			}
+
+			reg.UpdateStuck(CountStuckDecrease, false)
+		}
+
+		// keep parent slots, nested loops hold them too
+		slot := &parallelSlot{count: 1, release: func() { <-sem }}
+		ctxSlot := context.WithValue(ctx, ctxParallelSlots, append(slots[:len(slots):len(slots)], slot))
+
+		branch(ctxSlot, nexts, reg, &nodeRetOutput{datas[i]})
+
+		// release when no branch started
+		slot.done()
+	}
+}
@@ -5,6 +5,7 @@ import (
 	"sync"
 
 	"github.com/rakunlabs/chore/pkg/email"
+	"github.com/rakunlabs/chore/pkg/models"
 	"github.com/rakunlabs/chore/pkg/registry"
 
 	"gorm.io/gorm"
@@ -85,11 +86,16 @@ type NodeAttachments interface {
 	GetAttachments() []email.Attach
 }
 
+// NodeFiles returns attachments with content, readers of NodeAttachments are usable once.
+type NodeFiles interface {
+	GetFiles() []File
+}
+
 // Noder for nodes like script, endpoint.
 type Noder interface {
 	GetType() string
 	Run(context.Context, *sync.WaitGroup, *registry.Registry, NodeRet, string) (NodeRet, error)
-	Fetch(context.Context, *gorm.DB) error
+	Fetch(context.Context, *gorm.DB, models.Access) error
 	IsFetched() bool
 	Validate(context.Context) error
 	ActiveInput(string, map[string]struct{})
@@ -109,6 +115,26 @@ type NoderEndpoint interface {
 	Tags() []string
 }
 
+// NoderSchedule for start nodes triggered by time.
+type NoderSchedule interface {
+	// Schedule return cron specification.
+	Schedule() string
+	// Payload is the first value of the flow.
+	Payload() []byte
+	NodeID() string
+}
+
+// NoderErrorHandler for start nodes triggered with NodeError when a node fails in the run.
+type NoderErrorHandler interface {
+	IsErrorHandler() bool
+}
+
+// NoderParallel for nodes returning NodeRetDatas to limit running branches at the same time.
+type NoderParallel interface {
+	// Parallel is the max count of running values, 0 uses MaxParallel.
+	Parallel() int
+}
+
 // nodeRetOutput struct for path.
 type nodeRetOutput struct {
 	output []byte
@@ -0,0 +1,66 @@
+package nodes
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/rakunlabs/chore/pkg/flow"
+	"github.com/rakunlabs/chore/pkg/models"
+	"github.com/rakunlabs/chore/pkg/registry"
+)
+
+func TestCancelRun(t *testing.T) {
+	defer func(v bool) { flow.HistoryDisabled = v }(flow.HistoryDisabled)
+
+	flow.HistoryDisabled = true
+
+	// retry waits an hour, only cancel can stop it
+	content := `{
+		"1": {"name": "endpoint", "data": {"endpoint": "test", "methods": "POST"}, "outputs": {"output_1": {"connections": [{"node": "2", "output": "input_1"}]}}},
+		"2": {"name": "forLoop", "data": {"for": "throw new Error('boom')", "retries": "1", "backoff": "1h"}, "inputs": {"input_1": {"connections": [{"node": "1"}]}}, "outputs": {"output_1": {"connections": [{"node": "3", "output": "input_1"}]}}},
+		"3": {"name": "respond", "data": {}, "inputs": {"input_1": {"connections": [{"node": "2"}]}}}
+	}`
+
+	wg := &sync.WaitGroup{}
+
+	nodesReg, err := flow.StartFlow(context.Background(), wg, "try", "test", "POST", []byte(content), &registry.Registry{}, models.Access{}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for i := 0; ; i++ {
+		if _, ok := flow.ActiveRuns.Get(nodesReg.RunID()); ok {
+			break
+		}
+
+		if i > 100 {
+			t.Fatal("run not found in active runs")
+		}
+
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	nodesReg.Cancel()
+
+	select {
+	case respond := <-nodesReg.GetChan():
+		if !respond.IsError {
+			t.Errorf("respond should be error, got %s", respond.Data)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("run not canceled")
+	}
+
+	wg.Wait()
+
+	if !errors.Is(nodesReg.Err(), flow.ErrRunCanceled) {
+		t.Errorf("run error = %v, want %v", nodesReg.Err(), flow.ErrRunCanceled)
+	}
+
+	if _, ok := flow.ActiveRuns.Get(nodesReg.RunID()); ok {
+		t.Error("run still in active runs")
+	}
+}
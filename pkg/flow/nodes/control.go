@@ -34,6 +34,7 @@ func (r *ControlRet) GetBinaryData() []byte {
 var (
 	_ flow.NodeRetRespondData = (*ControlRet)(nil)
 	_ flow.NodeRet            = (*ControlRet)(nil)
+	_ flow.NoderReference     = (*Control)(nil)
 )
 
 // Control node has one input and one output.
@@ -59,7 +60,7 @@ func (n *Control) Run(ctx context.Context, wg *sync.WaitGroup, reg *registry.Reg
 
 	log.Ctx(ctx).Info().Msgf("internal call control=[%s] endpoint=[%s]", n.control.Name, n.endpointName)
 
-	nodesReg, err := flow.StartFlow(ctx, wg, n.control.Name, n.endpointName, n.methodName, content, reg, value.GetBinaryData())
+	nodesReg, err := flow.StartFlow(ctx, wg, n.control.Name, n.endpointName, n.methodName, content, reg, models.ResourceAccess(n.control.Groups.Groups), value.GetBinaryData())
 	if errors.Is(err, flow.ErrEndpointNotFound) {
 		return nil, fmt.Errorf("endpoint not found %s; %w", n.endpointName, err)
 	}
@@ -86,8 +87,8 @@ func (n *Control) GetType() string {
 	return controlType
 }
 
-func (n *Control) Fetch(ctx context.Context, db *gorm.DB) error {
-	query := db.WithContext(ctx).Where("name = ?", n.controlName)
+func (n *Control) Fetch(ctx context.Context, db *gorm.DB, access models.Access) error {
+	query := db.WithContext(ctx).Where("name = ?", n.controlName).Scopes(access.Scope(false))
 	result := query.First(&n.control)
 
 	if errors.Is(result.Error, gorm.ErrRecordNotFound) {
@@ -109,9 +110,17 @@ func (n *Control) IsRespond() bool {
 }
 
 func (n *Control) Validate(_ context.Context) error {
+	if n.controlName == "" {
+		return fmt.Errorf("control is empty")
+	}
+
 	return nil
 }
 
+func (n *Control) References() []flow.Reference {
+	return []flow.Reference{{Type: flow.ReferenceControl, Name: n.controlName, Field: "control"}}
+}
+
 func (n *Control) Next(i int) []flow.Connection {
 	return n.outputs[i]
 }
@@ -36,6 +36,8 @@ func (r *EmailRet) GetBinaryData() []byte {
 	return r.output
 }
 
+var _ flow.NoderReference = (*Email)(nil)
+
 // Email node has one input.
 type Email struct {
 	reg                *flow.NodesReg
@@ -53,6 +55,7 @@ type Email struct {
 	fetched            bool
 	checked            bool
 	disabled           bool
+	attachFiles        bool
 	nodeID             string
 	tags               []string
 }
@@ -128,6 +131,10 @@ func (n *Email) Run(ctx context.Context, _ *sync.WaitGroup, reg *registry.Regist
 		requestValues = transfer.BytesToData(n.inputHolder.value)
 	}
 
+	if requestValues != nil {
+		requestValues = flow.WithTemplateRequest(ctx, requestValues)
+	}
+
 	for key, value := range n.values {
 		payload := value
 
@@ -159,6 +166,13 @@ func (n *Email) Run(ctx context.Context, _ *sync.WaitGroup, reg *registry.Regist
 		attachments = v.GetAttachments()
 	}
 
+	// uploaded files of the call
+	if request := flow.GetRequest(ctx); n.attachFiles && request != nil {
+		if _, direct := value.(*EndpointRet); !direct {
+			attachments = append(attachments, flow.Attachments(request.Files)...)
+		}
+	}
+
 	if err := n.client.Send(value.GetBinaryData(), headers, attachments); err != nil {
 		return nil, fmt.Errorf("failed to send email: values %v, err %w", headers, err)
 	}
@@ -170,10 +184,15 @@ func (n *Email) GetType() string {
 	return emailType
 }
 
-func (n *Email) Fetch(ctx context.Context, db *gorm.DB) error {
+func (n *Email) References() []flow.Reference {
+	return []flow.Reference{{Type: flow.ReferenceSettings, Namespace: "email", Name: "email-1"}}
+}
+
+func (n *Email) Fetch(ctx context.Context, db *gorm.DB, access models.Access) error {
 	getData := map[string]interface{}{}
 
-	query := db.WithContext(ctx).Model(&models.Settings{}).Select("data").Where("namespace = ?", "email").Where("name = ?", "email-1")
+	query := db.WithContext(ctx).Model(&models.Settings{}).Select("data").Where("namespace = ?", "email").Where("name = ?", "email-1").
+		Scopes(access.Scope(false))
 	result := query.First(&getData)
 
 	if result.Error != nil {
@@ -282,11 +301,12 @@ func NewEmail(_ context.Context, reg *flow.NodesReg, data flow.NodeData, nodeID
 	tags := convert.GetList(data.Data["tags"])
 
 	return &Email{
-		reg:    reg,
-		values: values,
-		inputs: inputs,
-		nodeID: nodeID,
-		tags:   tags,
+		reg:         reg,
+		values:      values,
+		inputs:      inputs,
+		attachFiles: convert.GetBoolean(data.Data["attach_files"]),
+		nodeID:      nodeID,
+		tags:        tags,
 	}, nil
 }
 
@@ -2,12 +2,17 @@ package nodes
 
 import (
 	"context"
+	"fmt"
 	"strings"
 	"sync"
+	"time"
 
+	"github.com/rakunlabs/chore/pkg/email"
 	"github.com/rakunlabs/chore/pkg/flow"
 	"github.com/rakunlabs/chore/pkg/flow/convert"
+	"github.com/rakunlabs/chore/pkg/models"
 	"github.com/rakunlabs/chore/pkg/registry"
+	"github.com/rakunlabs/chore/pkg/sec"
 
 	"gorm.io/gorm"
 )
@@ -15,13 +20,40 @@ import (
 var endpointType = "endpoint"
 
 type EndpointRet struct {
-	output []byte
+	output  []byte
+	request *flow.Request
 }
 
 func (r *EndpointRet) GetBinaryData() []byte {
 	return r.output
 }
 
+func (r *EndpointRet) GetRequest() *flow.Request {
+	return r.request
+}
+
+func (r *EndpointRet) GetAttachments() []email.Attach {
+	if r.request == nil || len(r.request.Files) == 0 {
+		return nil
+	}
+
+	return flow.Attachments(r.request.Files)
+}
+
+func (r *EndpointRet) GetFiles() []flow.File {
+	if r.request == nil {
+		return nil
+	}
+
+	return r.request.Files
+}
+
+var (
+	_ flow.NodeRetRequest  = (*EndpointRet)(nil)
+	_ flow.NodeAttachments = (*EndpointRet)(nil)
+	_ flow.NodeFiles       = (*EndpointRet)(nil)
+)
+
 // Endpoint node has one output.
 type Endpoint struct {
 	endpoint string
@@ -32,20 +64,28 @@ type Endpoint struct {
 	public   bool
 	nodeID   string
 	tags     []string
+
+	signaturePreset    string
+	signatureSecret    string
+	signatureHeader    string
+	signatureTolerance string
 }
 
-var _ flow.NoderEndpoint = (*Endpoint)(nil)
+var (
+	_ flow.NoderEndpoint  = (*Endpoint)(nil)
+	_ flow.NoderReference = (*Endpoint)(nil)
+)
 
 // Run get values from active input nodes and it will not run until last input comes.
-func (n *Endpoint) Run(_ context.Context, _ *sync.WaitGroup, _ *registry.Registry, value flow.NodeRet, _ string) (flow.NodeRet, error) {
-	return &EndpointRet{output: value.GetBinaryData()}, nil
+func (n *Endpoint) Run(ctx context.Context, _ *sync.WaitGroup, _ *registry.Registry, value flow.NodeRet, _ string) (flow.NodeRet, error) {
+	return &EndpointRet{output: value.GetBinaryData(), request: flow.GetRequest(ctx)}, nil
 }
 
 func (n *Endpoint) GetType() string {
 	return endpointType
 }
 
-func (n *Endpoint) Fetch(ctx context.Context, db *gorm.DB) error {
+func (n *Endpoint) Fetch(ctx context.Context, db *gorm.DB, _ models.Access) error {
 	return nil
 }
 
@@ -58,9 +98,43 @@ func (n *Endpoint) IsRespond() bool {
 }
 
 func (n *Endpoint) Validate(_ context.Context) error {
+	if n.endpoint == "" {
+		return fmt.Errorf("endpoint is empty")
+	}
+
+	if len(n.methods) == 0 {
+		return fmt.Errorf("methods is empty")
+	}
+
+	if n.signaturePreset != "" && !sec.IsPreset(n.signaturePreset) {
+		return fmt.Errorf("unknown signature preset %s", n.signaturePreset)
+	}
+
+	if (n.signaturePreset != "" || n.signatureHeader != "") && n.signatureSecret == "" {
+		return fmt.Errorf("signature secret is empty")
+	}
+
+	if n.signatureSecret != "" && n.signaturePreset == "" && n.signatureHeader == "" {
+		return fmt.Errorf("signature preset or header is empty")
+	}
+
+	if n.signatureTolerance != "" {
+		if _, err := time.ParseDuration(n.signatureTolerance); err != nil {
+			return fmt.Errorf("signature tolerance: %w", err)
+		}
+	}
+
 	return nil
 }
 
+func (n *Endpoint) References() []flow.Reference {
+	if n.signatureSecret == "" {
+		return nil
+	}
+
+	return []flow.Reference{{Type: flow.ReferenceSettings, Namespace: models.SettingsWebhook, Name: n.signatureSecret, Field: "signature_secret"}}
+}
+
 func (n *Endpoint) Next(i int) []flow.Connection {
 	return n.outputs[i]
 }
@@ -111,6 +185,11 @@ func NewEndpoint(_ context.Context, _ *flow.NodesReg, data flow.NodeData, nodeID
 
 	tags := convert.GetList(data.Data["tags"])
 
+	signaturePreset, _ := data.Data["signature_preset"].(string)
+	signatureSecret, _ := data.Data["signature_secret"].(string)
+	signatureHeader, _ := data.Data["signature_header"].(string)
+	signatureTolerance, _ := data.Data["signature_tolerance"].(string)
+
 	return &Endpoint{
 		outputs:  outputs,
 		endpoint: endpoint,
@@ -118,6 +197,11 @@ func NewEndpoint(_ context.Context, _ *flow.NodesReg, data flow.NodeData, nodeID
 		public:   public,
 		nodeID:   nodeID,
 		tags:     tags,
+
+		signaturePreset:    signaturePreset,
+		signatureSecret:    signatureSecret,
+		signatureHeader:    signatureHeader,
+		signatureTolerance: signatureTolerance,
 	}, nil
 }
 
@@ -9,6 +9,7 @@ import (
 
 	"github.com/rakunlabs/chore/pkg/flow"
 	"github.com/rakunlabs/chore/pkg/flow/convert"
+	"github.com/rakunlabs/chore/pkg/models"
 	"github.com/rakunlabs/chore/pkg/registry"
 	"github.com/rakunlabs/chore/pkg/script/js"
 	"github.com/rakunlabs/chore/pkg/transfer"
@@ -26,8 +27,11 @@ type ForLoop struct {
 	disabled   bool
 	nodeID     string
 	tags       []string
+	parallel   int
 }
 
+var _ flow.NoderParallel = (*ForLoop)(nil)
+
 type ForRet struct {
 	output [][]byte
 }
@@ -79,7 +83,7 @@ func (n *ForLoop) GetType() string {
 	return forLoopType
 }
 
-func (n *ForLoop) Fetch(_ context.Context, _ *gorm.DB) error {
+func (n *ForLoop) Fetch(_ context.Context, _ *gorm.DB, _ models.Access) error {
 	return nil
 }
 
@@ -131,6 +135,10 @@ func (n *ForLoop) Tags() []string {
 	return n.tags
 }
 
+func (n *ForLoop) Parallel() int {
+	return n.parallel
+}
+
 func NewForLoop(_ context.Context, _ *flow.NodesReg, data flow.NodeData, nodeID string) (flow.Noder, error) {
 	// add outputs with order
 	outputs := flow.PrepareOutputs(data.Outputs)
@@ -138,11 +146,17 @@ func NewForLoop(_ context.Context, _ *flow.NodesReg, data flow.NodeData, nodeID
 	expression, _ := data.Data["for"].(string)
 	tags := convert.GetList(data.Data["tags"])
 
+	parallel, err := convert.GetInt(data.Data["parallel"])
+	if err != nil || parallel < 0 {
+		return nil, fmt.Errorf("parallel should be a positive number: %v", data.Data["parallel"])
+	}
+
 	return &ForLoop{
 		outputs:    outputs,
 		expression: expression,
 		nodeID:     nodeID,
 		tags:       tags,
+		parallel:   parallel,
 	}, nil
 }
 
@@ -6,6 +6,7 @@ import (
 
 	"github.com/rakunlabs/chore/pkg/flow"
 	"github.com/rakunlabs/chore/pkg/flow/convert"
+	"github.com/rakunlabs/chore/pkg/models"
 	"github.com/rakunlabs/chore/pkg/registry"
 
 	"gorm.io/gorm"
@@ -43,7 +44,7 @@ func (n *Hub) GetType() string {
 	return hubType
 }
 
-func (n *Hub) Fetch(ctx context.Context, db *gorm.DB) error {
+func (n *Hub) Fetch(ctx context.Context, db *gorm.DB, _ models.Access) error {
 	return nil
 }
 
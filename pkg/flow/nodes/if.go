@@ -10,6 +10,7 @@ import (
 
 	"github.com/rakunlabs/chore/pkg/flow"
 	"github.com/rakunlabs/chore/pkg/flow/convert"
+	"github.com/rakunlabs/chore/pkg/models"
 	"github.com/rakunlabs/chore/pkg/registry"
 	"github.com/rakunlabs/chore/pkg/script/js"
 	"github.com/rakunlabs/chore/pkg/transfer"
@@ -83,7 +84,7 @@ func (n *IfCase) GetType() string {
 	return ifCaseType
 }
 
-func (n *IfCase) Fetch(_ context.Context, _ *gorm.DB) error {
+func (n *IfCase) Fetch(_ context.Context, _ *gorm.DB, _ models.Access) error {
 	return nil
 }
 
@@ -0,0 +1,157 @@
+package nodes
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/rakunlabs/chore/pkg/flow"
+	"github.com/rakunlabs/chore/pkg/models"
+	"github.com/rakunlabs/chore/pkg/registry"
+)
+
+// slowNode counts values between start and end marked nodes.
+type slowNode struct {
+	flow.Noder
+	mark    string
+	running *int64
+	max     *int64
+}
+
+func (n *slowNode) Run(ctx context.Context, wg *sync.WaitGroup, reg *registry.Registry, value flow.NodeRet, input string) (flow.NodeRet, error) {
+	if n.mark == "start" {
+		running := atomic.AddInt64(n.running, 1)
+
+		for {
+			maxRunning := atomic.LoadInt64(n.max)
+			if running <= maxRunning || atomic.CompareAndSwapInt64(n.max, maxRunning, running) {
+				break
+			}
+		}
+	}
+
+	time.Sleep(10 * time.Millisecond)
+
+	if n.mark == "end" {
+		atomic.AddInt64(n.running, -1)
+	}
+
+	return n.Noder.Run(ctx, wg, reg, value, input)
+}
+
+func registerSlow(running, maxRunning *int64) {
+	flow.NodeTypes["testSlow"] = func(ctx context.Context, reg *flow.NodesReg, data flow.NodeData, nodeID string) (flow.Noder, error) {
+		node, err := NewLog(ctx, reg, data, nodeID)
+		if err != nil {
+			return nil, err
+		}
+
+		mark, _ := data.Data["mark"].(string)
+
+		return &slowNode{Noder: node, mark: mark, running: running, max: maxRunning}, nil
+	}
+}
+
+func TestForParallel(t *testing.T) {
+	defer func(v bool) { flow.HistoryDisabled = v }(flow.HistoryDisabled)
+
+	flow.HistoryDisabled = true
+
+	var running, maxRunning int64
+
+	registerSlow(&running, &maxRunning)
+	defer delete(flow.NodeTypes, "testSlow")
+
+	// value passes two nodes, place is hold until the end
+	content := `{
+		"1": {"name": "endpoint", "data": {"endpoint": "test", "methods": "POST"}, "outputs": {"output_1": {"connections": [{"node": "2", "output": "input_1"}]}}},
+		"2": {"name": "forLoop", "data": {"for": "[1, 2, 3, 4, 5, 6]", "parallel": "2"}, "inputs": {"input_1": {"connections": [{"node": "1"}]}}, "outputs": {"output_1": {"connections": [{"node": "3", "output": "input_1"}]}}},
+		"3": {"name": "testSlow", "data": {"mark": "start"}, "inputs": {"input_1": {"connections": [{"node": "2"}]}}, "outputs": {"output_1": {"connections": [{"node": "4", "output": "input_1"}]}}},
+		"4": {"name": "testSlow", "data": {"mark": "end"}, "inputs": {"input_1": {"connections": [{"node": "3"}]}}, "outputs": {"output_1": {"connections": []}}}
+	}`
+
+	wg := &sync.WaitGroup{}
+
+	nodesReg, err := flow.StartFlow(context.Background(), wg, "try", "test", "POST", []byte(content), &registry.Registry{}, models.Access{}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	<-nodesReg.Done()
+	wg.Wait()
+
+	if err := nodesReg.Err(); err != nil {
+		t.Fatal(err)
+	}
+
+	if running != 0 {
+		t.Errorf("running = %d, all values should be finished", running)
+	}
+
+	if maxRunning > 2 {
+		t.Errorf("max running = %d, want at most 2", maxRunning)
+	}
+}
+
+func TestRunLimits(t *testing.T) {
+	defer func(v bool) { flow.HistoryDisabled = v }(flow.HistoryDisabled)
+	defer flow.RunLimits.Set(0, 0, nil)
+
+	flow.HistoryDisabled = true
+
+	content := `{
+		"1": {"name": "endpoint", "data": {"endpoint": "test", "methods": "POST"}, "outputs": {"output_1": {"connections": [{"node": "2", "output": "input_1"}]}}},
+		"2": {"name": "testSlow", "data": {"mark": "start"}, "inputs": {"input_1": {"connections": [{"node": "1"}]}}, "outputs": {"output_1": {"connections": [{"node": "3", "output": "input_1"}]}}},
+		"3": {"name": "testSlow", "data": {"mark": "end"}, "inputs": {"input_1": {"connections": [{"node": "2"}]}}, "outputs": {"output_1": {"connections": []}}}
+	}`
+
+	tests := []struct {
+		name        string
+		runs        int
+		controlRuns int
+		controls    map[string]int
+		want        int64
+	}{
+		{name: "runs", runs: 1, want: 1},
+		{name: "control runs", controlRuns: 2, want: 2},
+		{name: "control override", controlRuns: 3, controls: map[string]int{"try": 1}, want: 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var running, maxRunning int64
+
+			registerSlow(&running, &maxRunning)
+			defer delete(flow.NodeTypes, "testSlow")
+
+			flow.RunLimits.Set(tt.runs, tt.controlRuns, tt.controls)
+
+			wg := &sync.WaitGroup{}
+
+			regs := make([]*flow.NodesReg, 0, 5)
+
+			for i := 0; i < 5; i++ {
+				nodesReg, err := flow.StartFlow(context.Background(), wg, "try", "test", "POST", []byte(content), &registry.Registry{}, models.Access{}, nil)
+				if err != nil {
+					t.Fatal(err)
+				}
+
+				regs = append(regs, nodesReg)
+			}
+
+			wg.Wait()
+
+			for _, nodesReg := range regs {
+				if err := nodesReg.Err(); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			if maxRunning > tt.want {
+				t.Errorf("max running = %d, want at most %d", maxRunning, tt.want)
+			}
+		})
+	}
+}
@@ -6,6 +6,7 @@ import (
 
 	"github.com/rakunlabs/chore/pkg/flow"
 	"github.com/rakunlabs/chore/pkg/flow/convert"
+	"github.com/rakunlabs/chore/pkg/models"
 	"github.com/rakunlabs/chore/pkg/registry"
 
 	"github.com/rs/zerolog"
@@ -73,7 +74,7 @@ func (n *Log) GetType() string {
 	return logType
 }
 
-func (n *Log) Fetch(ctx context.Context, db *gorm.DB) error {
+func (n *Log) Fetch(ctx context.Context, db *gorm.DB, _ models.Access) error {
 	return nil
 }
 
@@ -0,0 +1,114 @@
+package nodes
+
+import (
+	"context"
+	"sync"
+
+	"github.com/rakunlabs/chore/pkg/flow"
+	"github.com/rakunlabs/chore/pkg/flow/convert"
+	"github.com/rakunlabs/chore/pkg/models"
+	"github.com/rakunlabs/chore/pkg/registry"
+
+	"gorm.io/gorm"
+)
+
+var onErrorType = "onError"
+
+type OnErrorRet struct {
+	output []byte
+}
+
+func (r *OnErrorRet) GetBinaryData() []byte {
+	return r.output
+}
+
+// OnError node has one output, it starts when any node fails in the run with flow.NodeError value.
+// Use tags to select endpoints.
+type OnError struct {
+	outputs  [][]flow.Connection
+	checked  bool
+	disabled bool
+	nodeID   string
+	tags     []string
+}
+
+var _ flow.NoderErrorHandler = (*OnError)(nil)
+
+func (n *OnError) Run(_ context.Context, _ *sync.WaitGroup, _ *registry.Registry, value flow.NodeRet, _ string) (flow.NodeRet, error) {
+	return &OnErrorRet{output: value.GetBinaryData()}, nil
+}
+
+func (n *OnError) GetType() string {
+	return onErrorType
+}
+
+func (n *OnError) Fetch(_ context.Context, _ *gorm.DB, _ models.Access) error {
+	return nil
+}
+
+func (n *OnError) IsFetched() bool {
+	return true
+}
+
+func (n *OnError) IsRespond() bool {
+	return false
+}
+
+func (n *OnError) IsErrorHandler() bool {
+	return true
+}
+
+func (n *OnError) Validate(_ context.Context) error {
+	return nil
+}
+
+func (n *OnError) Next(i int) []flow.Connection {
+	return n.outputs[i]
+}
+
+func (n *OnError) NextCount() int {
+	return len(n.outputs)
+}
+
+func (n *OnError) Check() {
+	n.checked = true
+}
+
+func (n *OnError) IsChecked() bool {
+	return n.checked
+}
+
+func (n *OnError) IsDisabled() bool {
+	return n.disabled
+}
+
+func (n *OnError) ActiveInput(_ string, tags map[string]struct{}) {
+	if !convert.IsTagsEnabled(n.tags, tags) {
+		n.disabled = true
+	}
+}
+
+func (n *OnError) Tags() []string {
+	return n.tags
+}
+
+func (n *OnError) NodeID() string {
+	return n.nodeID
+}
+
+func NewOnError(_ context.Context, _ *flow.NodesReg, data flow.NodeData, nodeID string) (flow.Noder, error) {
+	outputs := flow.PrepareOutputs(data.Outputs)
+
+	tags := convert.GetList(data.Data["tags"])
+
+	return &OnError{
+		outputs: outputs,
+		nodeID:  nodeID,
+		tags:    tags,
+	}, nil
+}
+
+//nolint:gochecknoinits // moduler nodes
+func init() {
+	flow.NodeTypes[onErrorType] = NewOnError
+}
@@ -0,0 +1,66 @@
+package nodes
+
+import (
+	"context"
+	"encoding/json"
+	"sync"
+	"testing"
+
+	"github.com/go-test/deep"
+
+	"github.com/rakunlabs/chore/pkg/flow"
+	"github.com/rakunlabs/chore/pkg/models"
+	"github.com/rakunlabs/chore/pkg/registry"
+)
+
+func TestOnError(t *testing.T) {
+	defer func(v bool) { flow.HistoryDisabled = v }(flow.HistoryDisabled)
+
+	flow.HistoryDisabled = true
+
+	content := `{
+		"1": {"name": "endpoint", "data": {"endpoint": "test", "methods": "POST"}, "outputs": {"output_1": {"connections": [{"node": "2", "output": "input_1"}]}}},
+		"2": {"name": "forLoop", "data": {"for": "throw new Error('boom')"}, "inputs": {"input_1": {"connections": [{"node": "1"}]}}, "outputs": {"output_1": {"connections": [{"node": "3", "output": "input_1"}]}}},
+		"3": {"name": "respond", "data": {}, "inputs": {"input_1": {"connections": [{"node": "2"}]}}},
+		"4": {"name": "onError", "data": {}, "outputs": {"output_1": {"connections": [{"node": "5", "output": "input_1"}]}}},
+		"5": {"name": "respond", "data": {"status": "503"}, "inputs": {"input_1": {"connections": [{"node": "4"}]}}}
+	}`
+
+	wg := &sync.WaitGroup{}
+
+	nodesReg, err := flow.StartFlow(context.Background(), wg, "try", "test", "POST", []byte(content), &registry.Registry{}, models.Access{}, []byte(`{"name":"deepcore"}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	respond := <-nodesReg.GetChan()
+
+	wg.Wait()
+
+	if respond.Status != 503 || respond.IsError {
+		t.Errorf("respond status = %d, isError = %v", respond.Status, respond.IsError)
+	}
+
+	got := flow.NodeError{}
+	if err := json.Unmarshal(respond.Data, &got); err != nil {
+		t.Fatal(err)
+	}
+
+	got.Message = ""
+
+	want := flow.NodeError{
+		Control:  "try",
+		Endpoint: "test",
+		NodeID:   "2",
+		Type:     "forLoop",
+		Input:    map[string]interface{}{"name": "deepcore"},
+	}
+
+	if diff := deep.Equal(got, want); diff != nil {
+		t.Error(diff)
+	}
+
+	if nodesReg.Err() == nil {
+		t.Error("run error expected")
+	}
+}
@@ -7,10 +7,12 @@ import (
 	"fmt"
 	"mime"
 	"net/http"
+	"os"
 	"strconv"
 	"strings"
 	"sync"
 
+	"github.com/rakunlabs/chore/pkg/email"
 	"github.com/rakunlabs/chore/pkg/flow"
 	"github.com/rakunlabs/chore/pkg/flow/convert"
 	"github.com/rakunlabs/chore/pkg/models"
@@ -49,9 +51,25 @@ func (r *RequestRet) GetRespondData() flow.Respond {
 	return r.respond
 }
 
+// GetFiles returns the spooled body.
+func (r *RequestRet) GetFiles() []flow.File {
+	if r.respond.File == nil {
+		return nil
+	}
+
+	return []flow.File{*r.respond.File}
+}
+
+func (r *RequestRet) GetAttachments() []email.Attach {
+	return flow.Attachments(r.GetFiles())
+}
+
 var (
 	_ flow.NodeRetRespondData = &RequestRet{}
 	_ flow.NodeRetSelection   = &RequestRet{}
+	_ flow.NodeFiles          = &RequestRet{}
+	_ flow.NodeAttachments    = &RequestRet{}
+	_ flow.NoderReference     = (*Request)(nil)
 )
 
 type retryRaw struct {
@@ -76,6 +94,8 @@ type Request struct {
 	feedbackWait       bool
 	headers            map[string]interface{}
 	oauth2             request.AuthConfig
+	tls                request.TLSConfig
+	signer             request.Signer
 	retryRaw           retryRaw
 	url                string
 	addHeadersRaw      string
@@ -92,7 +112,14 @@ type Request struct {
 	skipVerify         bool
 	retryDisabled      bool
 	oauth2Name         string
+	tlsName            string
+	signerName         string
 	proxy              string
+	uploadField        string
+	bodyMode           string
+	pagination         pagination
+	maxResponseSize    int64
+	spoolSize          int64
 	stuckContext       context.Context
 	log                *zerolog.Logger
 	client             *request.Client
@@ -172,6 +199,8 @@ func (n *Request) Run(ctx context.Context, _ *sync.WaitGroup, reg *registry.Regi
 		requestValues = transfer.BytesToData(n.inputHolder.value)
 	}
 
+	requestValues = flow.WithTemplateRequest(ctx, requestValues)
+
 	// if requestValues != nil {
 	// render url
 	var buf bytes.Buffer
@@ -219,19 +248,35 @@ func (n *Request) Run(ctx context.Context, _ *sync.WaitGroup, reg *registry.Regi
 		payload = value.GetBinaryData()
 	}
 
+	payload, bodyFile, contentType, err := n.body(ctx, value, payload)
+	if err != nil {
+		return nil, err
+	}
+
+	if contentType != "" {
+		setContentType(headers, contentType)
+	}
+
 	if n.client == nil {
 		return nil, fmt.Errorf("http client not set")
 	}
 
-	response, err := n.client.Call(
-		ctx,
-		rendered.url,
-		rendered.method,
-		headers,
-		payload,
-	)
+	// spooled multipart body is sent from the file
+	send := func(url string) (*request.ClientResponse, error) {
+		if bodyFile != "" {
+			return n.client.CallFile(ctx, url, rendered.method, headers, bodyFile)
+		}
+
+		return n.client.Call(ctx, url, rendered.method, headers, payload)
+	}
+
+	response, err := send(rendered.url)
 	if err != nil {
-		// return nil, fmt.Errorf("failed to send request: %w", err)
+		// node with retries gets the error back to retry, otherwise failed calls go to the false output
+		if n.reg != nil && n.reg.Policy(n.nodeID).Retries > 0 {
+			return nil, fmt.Errorf("failed to send request: %w", err)
+		}
+
 		return &RequestRet{
 			respond: flow.Respond{
 				Header: nil,
@@ -242,6 +287,27 @@ func (n *Request) Run(ctx context.Context, _ *sync.WaitGroup, reg *registry.Regi
 		}, nil
 	}
 
+	respond := responseRespond(response)
+
+	if response.StatusCode >= 100 && response.StatusCode < 400 && n.pagination.mode != "" {
+		return n.pages(response, rendered.url, send)
+	}
+
+	if response.StatusCode >= 100 && response.StatusCode < 400 {
+		return &RequestRet{
+			respond:   respond,
+			selection: []int{1, 2},
+		}, nil
+	}
+
+	return &RequestRet{
+		respond:   respond,
+		selection: []int{0, 2},
+	}, nil
+}
+
+// responseRespond returns respond with first values of the headers and file name of the content disposition.
+func responseRespond(response *request.ClientResponse) flow.Respond {
 	header := make(map[string]interface{})
 	for k, v := range response.Header {
 		header[k] = v[0]
@@ -255,38 +321,71 @@ func (n *Request) Run(ctx context.Context, _ *sync.WaitGroup, reg *registry.Regi
 		}
 	}
 
-	if response.StatusCode >= 100 && response.StatusCode < 400 {
-		return &RequestRet{
-			respond: flow.Respond{
-				Header:   header,
-				FileName: fileName,
-				Data:     response.Body,
-				Status:   response.StatusCode,
-			},
-			selection: []int{1, 2},
-		}, nil
+	respond := flow.Respond{
+		Header:   header,
+		FileName: fileName,
+		Data:     response.Body,
+		Status:   response.StatusCode,
 	}
 
-	return &RequestRet{
-		respond: flow.Respond{
-			Header:   header,
-			FileName: fileName,
-			Data:     response.Body,
-			Status:   response.StatusCode,
-		},
-		selection: []int{0, 2},
-	}, nil
+	if response.File != "" {
+		name := fileName
+		if name == "" {
+			name = "response"
+		}
+
+		contentType, _ := header["Content-Type"].(string)
+
+		respond.File = &flow.File{
+			Name:        name,
+			ContentType: contentType,
+			Path:        response.File,
+			Size:        response.Size,
+		}
+	}
+
+	return respond
+}
+
+// tempFile creates a file removed at the end of the run.
+func (n *Request) tempFile(pattern string) (*os.File, error) {
+	if n.reg == nil {
+		return nil, fmt.Errorf("temp file is usable in a run")
+	}
+
+	return n.reg.TempFile(pattern)
 }
 
 func (n *Request) GetType() string {
 	return requestType
 }
 
-func (n *Request) Fetch(ctx context.Context, db *gorm.DB) error {
+func (n *Request) References() []flow.Reference {
+	refs := []flow.Reference{}
+	if n.auth != "" {
+		refs = append(refs, flow.Reference{Type: flow.ReferenceAuth, Name: n.auth, Field: "auth"})
+	}
+
+	if n.oauth2Name != "" {
+		refs = append(refs, flow.Reference{Type: flow.ReferenceSettings, Namespace: "oauth2", Name: n.oauth2Name, Field: "oauth2"})
+	}
+
+	if n.tlsName != "" {
+		refs = append(refs, flow.Reference{Type: flow.ReferenceSettings, Namespace: models.SettingsTLS, Name: n.tlsName, Field: "tls"})
+	}
+
+	if n.signerName != "" {
+		refs = append(refs, flow.Reference{Type: flow.ReferenceSettings, Namespace: models.SettingsSigner, Name: n.signerName, Field: "signer"})
+	}
+
+	return refs
+}
+
+func (n *Request) Fetch(ctx context.Context, db *gorm.DB, access models.Access) error {
 	if n.auth != "" {
 		getData := models.AuthPure{}
 
-		query := db.WithContext(ctx).Model(&models.Auth{}).Where("name = ?", n.auth)
+		query := db.WithContext(ctx).Model(&models.Auth{}).Where("name = ?", n.auth).Scopes(access.Scope(false))
 		result := query.First(&getData)
 
 		if result.Error != nil {
@@ -303,7 +402,8 @@ func (n *Request) Fetch(ctx context.Context, db *gorm.DB) error {
 		}
 
 		data := map[string]interface{}{}
-		query := db.WithContext(ctx).Model(&models.Settings{}).Where("namespace = ?", "oauth2").Where("name = ?", n.oauth2Name)
+		query := db.WithContext(ctx).Model(&models.Settings{}).Where("namespace = ?", "oauth2").Where("name = ?", n.oauth2Name).
+			Scopes(access.Scope(false))
 		result := query.First(&data)
 		if result.Error != nil {
 			return fmt.Errorf("request fetch failed: %w", result.Error)
@@ -315,9 +415,56 @@ func (n *Request) Fetch(ctx context.Context, db *gorm.DB) error {
 			return fmt.Errorf("request fetch failed: %w", err)
 		}
 
+		authConfig.Name = n.oauth2Name
+		if authConfig.Grant == request.GrantRefreshToken {
+			authConfig.OnRefreshToken = saveRefreshToken(db, n.oauth2Name, n.log)
+		}
+
 		n.oauth2 = authConfig
 	}
 
+	// get client certificate and CA bundle
+	if n.tlsName != "" {
+		settings := models.Settings{}
+
+		query := db.WithContext(ctx).Model(&models.Settings{}).Where("namespace = ?", models.SettingsTLS).Where("name = ?", n.tlsName).
+			Scopes(access.Scope(false))
+		if result := query.First(&settings); result.Error != nil {
+			return fmt.Errorf("request fetch failed: tls %s: %w", n.tlsName, result.Error)
+		}
+
+		n.tls.Cert, _ = settings.Data["cert"].(string)
+		n.tls.Key, _ = settings.Data["key"].(string)
+		n.tls.CA, _ = settings.Data["ca"].(string)
+		n.tls.ServerName, _ = settings.Data["server_name"].(string)
+	}
+
+	// get signer of the requests
+	if n.signerName != "" {
+		settings := models.Settings{}
+
+		query := db.WithContext(ctx).Model(&models.Settings{}).Where("namespace = ?", models.SettingsSigner).Where("name = ?", n.signerName).
+			Scopes(access.Scope(false))
+		if result := query.First(&settings); result.Error != nil {
+			return fmt.Errorf("request fetch failed: signer %s: %w", n.signerName, result.Error)
+		}
+
+		signerData, err := json.Marshal(settings.Data)
+		if err != nil {
+			return fmt.Errorf("request fetch failed: %w", err)
+		}
+
+		signerConfig := request.SignerConfig{}
+		if err := json.Unmarshal(signerData, &signerConfig); err != nil {
+			return fmt.Errorf("request fetch failed: signer %s: %w", n.signerName, err)
+		}
+
+		n.signer, err = signerConfig.Signer()
+		if err != nil {
+			return fmt.Errorf("request fetch failed: signer %s: %w", n.signerName, err)
+		}
+	}
+
 	// fill retry values
 	retryCodes, err := getCodes(n.retryRaw.Codes)
 	if err != nil {
@@ -329,6 +476,16 @@ func (n *Request) Fetch(ctx context.Context, db *gorm.DB) error {
 		return err
 	}
 
+	spoolSize := n.spoolSize
+	if spoolSize == 0 {
+		spoolSize = request.DefaultSpoolSize
+	}
+
+	// pages are parsed in memory, size limit covers all of them
+	if n.pagination.mode != "" {
+		spoolSize = -1
+	}
+
 	n.client, err = request.NewClient(request.Config{ //nolint:contextcheck // application context using
 		SkipVerify: n.skipVerify,
 		Log:        n.log,
@@ -337,8 +494,16 @@ func (n *Request) Fetch(ctx context.Context, db *gorm.DB) error {
 			EnabledStatusCodes:  retryCodes,
 			DisabledStatusCodes: retryDeCodes,
 		},
-		Auth:  n.oauth2,
-		Proxy: n.proxy,
+		Auth:   n.oauth2,
+		TLS:    n.tls,
+		Signer: n.signer,
+		Proxy:  n.proxy,
+
+		MaxResponseSize: n.responseLimit(),
+		SpoolSize:       spoolSize,
+		Spool: func() (*os.File, error) {
+			return n.tempFile("chore-response-*")
+		},
 	})
 	if err != nil {
 		return fmt.Errorf("failed to create http client: %w", err)
@@ -349,6 +514,36 @@ func (n *Request) Fetch(ctx context.Context, db *gorm.DB) error {
 	return nil
 }
 
+// responseLimit returns the max response size of the node, 0 or less is unlimited.
+func (n *Request) responseLimit() int64 {
+	if n.maxResponseSize == 0 {
+		return request.DefaultMaxResponseSize
+	}
+
+	return n.maxResponseSize
+}
+
+// saveRefreshToken keeps the rotated refresh token in the oauth2 settings for the next runs.
+func saveRefreshToken(db *gorm.DB, name string, log *zerolog.Logger) func(string) {
+	return func(refreshToken string) {
+		settings := models.Settings{}
+
+		query := db.Model(&models.Settings{}).Where("namespace = ?", "oauth2").Where("name = ?", name)
+		if result := query.First(&settings); result.Error != nil {
+			log.Error().Err(result.Error).Str("oauth2", name).Msg("cannot get settings to save refresh token")
+
+			return
+		}
+
+		settings.Data["refresh_token"] = refreshToken
+
+		query = db.Model(&models.Settings{}).Where("namespace = ?", "oauth2").Where("name = ?", name)
+		if result := query.Update("data", settings.Data); result.Error != nil {
+			log.Error().Err(result.Error).Str("oauth2", name).Msg("cannot save refresh token")
+		}
+	}
+}
+
 func (n *Request) IsFetched() bool {
 	return n.fetched
 }
@@ -367,6 +562,18 @@ func (n *Request) Validate(ctx context.Context) error {
 		return fmt.Errorf("url is empty")
 	}
 
+	if !bodyModeValid(n.bodyMode) {
+		return fmt.Errorf("unknown body mode %s", n.bodyMode)
+	}
+
+	if err := n.pagination.validate(); err != nil {
+		return err
+	}
+
+	if n.pagination.mode != "" && n.spoolSize > 0 {
+		return fmt.Errorf("spool size cannot be used with pagination, pages are kept in memory")
+	}
+
 	n.stuckContext = n.reg.GetStuctCancel(ctx)
 
 	return nil
@@ -442,10 +649,42 @@ func NewRequest(ctx context.Context, reg *flow.NodesReg, data flow.NodeData, nod
 	retryDisabled := convert.GetBoolean(data.Data["retry_disabled"])
 
 	oauth2Name, _ := data.Data["oauth2"].(string)
+	tlsName, _ := data.Data["tls"].(string)
+	signerName, _ := data.Data["signer"].(string)
 	proxy, _ := data.Data["proxy"].(string)
+	uploadField, _ := data.Data["upload_field"].(string)
+	bodyMode, _ := data.Data["body_mode"].(string)
 
 	tags := convert.GetList(data.Data["tags"])
 
+	page := pagination{
+		each: convert.GetBoolean(data.Data["pagination_each"]),
+	}
+	page.mode, _ = data.Data["pagination"].(string)
+	page.param, _ = data.Data["pagination_param"].(string)
+	page.cursor, _ = data.Data["pagination_cursor"].(string)
+	page.items, _ = data.Data["pagination_items"].(string)
+
+	for key, v := range map[string]*int{
+		"pagination_start": &page.start,
+		"pagination_size":  &page.size,
+		"pagination_max":   &page.maxPages,
+	} {
+		var err error
+		if *v, err = convert.GetInt(data.Data[key]); err != nil {
+			return nil, fmt.Errorf("%s should be a number: %v", key, data.Data[key])
+		}
+	}
+
+	// sizes in bytes, -1 disables the default
+	var sizes [2]int
+	for i, key := range []string{"max_response_size", "spool_size"} {
+		var err error
+		if sizes[i], err = convert.GetInt(data.Data[key]); err != nil || sizes[i] < -1 {
+			return nil, fmt.Errorf("%s should be bytes: %v", key, data.Data[key])
+		}
+	}
+
 	l := log.Ctx(ctx).With().Str("component", requestType).Logger()
 
 	return &Request{
@@ -467,7 +706,15 @@ func NewRequest(ctx context.Context, reg *flow.NodesReg, data flow.NodeData, nod
 		nodeID:        nodeID,
 		tags:          tags,
 		oauth2Name:    oauth2Name,
+		tlsName:       tlsName,
+		signerName:    signerName,
 		proxy:         proxy,
+		uploadField:   uploadField,
+		bodyMode:      bodyMode,
+		pagination:    page,
+
+		maxResponseSize: int64(sizes[0]),
+		spoolSize:       int64(sizes[1]),
 	}, nil
 }
 
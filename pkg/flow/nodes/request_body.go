@@ -0,0 +1,234 @@
+package nodes
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net/textproto"
+	"net/url"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/rakunlabs/chore/pkg/flow"
+	"github.com/rakunlabs/chore/pkg/transfer"
+)
+
+const (
+	BodyModeRaw       = "raw"
+	BodyModeForm      = "form"
+	BodyModeMultipart = "multipart"
+
+	// defaultFileField is the field of the files in multipart body.
+	defaultFileField = "file"
+)
+
+var quoteEscaper = strings.NewReplacer("\\", "\\\\", `"`, "\\\"")
+
+// bodyModeValid reports the body mode is known, empty is raw.
+func bodyModeValid(mode string) bool {
+	switch mode {
+	case "", BodyModeRaw, BodyModeForm, BodyModeMultipart:
+		return true
+	}
+
+	return false
+}
+
+// body returns the payload with the body mode and content type, empty content type keeps headers.
+// Multipart with spooled files is written to the returned file instead of the payload.
+func (n *Request) body(ctx context.Context, value flow.NodeRet, payload []byte) ([]byte, string, string, error) {
+	mode := n.bodyMode
+	// upload field without mode sends the files of the call
+	if mode == "" && n.uploadField != "" {
+		mode = BodyModeMultipart
+	}
+
+	switch mode {
+	case BodyModeForm:
+		return formBody(payload), "", "application/x-www-form-urlencoded", nil
+	case BodyModeMultipart:
+		files, err := valueFiles(value)
+		if err != nil {
+			return nil, "", "", err
+		}
+
+		// files of the call, directly connected endpoint already has them
+		if request := flow.GetRequest(ctx); n.uploadField != "" && request != nil {
+			if _, direct := value.(*EndpointRet); !direct {
+				files = append(files, request.Files...)
+			}
+		}
+
+		field := n.uploadField
+		if field == "" {
+			field = defaultFileField
+		}
+
+		for _, f := range files {
+			if f.Path == "" {
+				continue
+			}
+
+			file, err := n.tempFile("chore-upload-*")
+			if err != nil {
+				return nil, "", "", err
+			}
+
+			contentType, err := multipartBody(file, payload, field, files)
+			if errClose := file.Close(); err == nil && errClose != nil {
+				err = fmt.Errorf("cannot write multipart: %w", errClose)
+			}
+
+			return nil, file.Name(), contentType, err
+		}
+
+		var buf bytes.Buffer
+
+		contentType, err := multipartBody(&buf, payload, field, files)
+
+		return buf.Bytes(), "", contentType, err
+	}
+
+	return payload, "", "", nil
+}
+
+// valueFiles returns attachments of the previous node like script's setAttachment.
+func valueFiles(value flow.NodeRet) ([]flow.File, error) {
+	if v, ok := value.(flow.NodeFiles); ok {
+		return v.GetFiles(), nil
+	}
+
+	v, ok := value.(flow.NodeAttachments)
+	if !ok {
+		return nil, nil
+	}
+
+	attachments := v.GetAttachments()
+	files := make([]flow.File, 0, len(attachments))
+
+	for _, a := range attachments {
+		data, err := io.ReadAll(a.Content)
+		if err != nil {
+			return nil, fmt.Errorf("cannot read attachment %s: %w", a.FileName, err)
+		}
+
+		files = append(files, flow.File{
+			Name:        a.FileName,
+			ContentType: mime.TypeByExtension(filepath.Ext(a.FileName)),
+			Data:        data,
+		})
+	}
+
+	return files, nil
+}
+
+// payloadFields returns keys of the object payload with string values, lists are multiple values.
+func payloadFields(payload []byte) url.Values {
+	values, ok := transfer.BytesToData(payload).(map[string]interface{})
+	if !ok {
+		return nil
+	}
+
+	fields := make(url.Values, len(values))
+	for k, v := range values {
+		if list, ok := v.([]interface{}); ok {
+			for _, item := range list {
+				fields.Add(k, string(transfer.DataToBytes(item)))
+			}
+
+			continue
+		}
+
+		fields.Set(k, string(transfer.DataToBytes(v)))
+	}
+
+	return fields
+}
+
+// formBody encodes the object payload, other payloads are already encoded.
+func formBody(payload []byte) []byte {
+	fields := payloadFields(payload)
+	if fields == nil {
+		return payload
+	}
+
+	return []byte(fields.Encode())
+}
+
+// multipartBody writes multipart/form-data body and returns content type.
+// Keys of the object payload are fields and files are added with the field name.
+func multipartBody(w io.Writer, payload []byte, field string, files []flow.File) (string, error) {
+	writer := multipart.NewWriter(w)
+
+	fields := payloadFields(payload)
+
+	keys := make([]string, 0, len(fields))
+	for k := range fields {
+		keys = append(keys, k)
+	}
+
+	sort.Strings(keys)
+
+	for _, k := range keys {
+		for _, v := range fields[k] {
+			if err := writer.WriteField(k, v); err != nil {
+				return "", fmt.Errorf("cannot write field %s: %w", k, err)
+			}
+		}
+	}
+
+	for _, f := range files {
+		contentType := f.ContentType
+		if contentType == "" {
+			contentType = "application/octet-stream"
+		}
+
+		header := make(textproto.MIMEHeader)
+		header.Set("Content-Disposition", fmt.Sprintf(`form-data; name="%s"; filename="%s"`,
+			quoteEscaper.Replace(field), quoteEscaper.Replace(f.Name)))
+		header.Set("Content-Type", contentType)
+
+		part, err := writer.CreatePart(header)
+		if err != nil {
+			return "", fmt.Errorf("cannot create file part %s: %w", f.Name, err)
+		}
+
+		if err := copyFile(part, f); err != nil {
+			return "", fmt.Errorf("cannot write file %s: %w", f.Name, err)
+		}
+	}
+
+	if err := writer.Close(); err != nil {
+		return "", fmt.Errorf("cannot close multipart: %w", err)
+	}
+
+	return writer.FormDataContentType(), nil
+}
+
+func copyFile(w io.Writer, f flow.File) error {
+	r, err := f.Open()
+	if err != nil {
+		return err //nolint:wrapcheck // caller adds the name
+	}
+
+	defer r.Close()
+
+	_, err = io.Copy(w, r)
+
+	return err //nolint:wrapcheck // caller adds the name
+}
+
+// setContentType replaces content type header with any case.
+func setContentType(headers map[string]interface{}, contentType string) {
+	for k := range headers {
+		if strings.EqualFold(k, "Content-Type") {
+			delete(headers, k)
+		}
+	}
+
+	headers["Content-Type"] = contentType
+}
@@ -0,0 +1,133 @@
+package nodes
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"reflect"
+	"testing"
+
+	"github.com/rakunlabs/chore/pkg/flow"
+)
+
+func TestRequestBody(t *testing.T) {
+	report := flow.File{Name: "report.csv", ContentType: "text/csv", Data: []byte("id\n1\n")}
+	upload := flow.File{Field: "doc", Name: "doc.pdf", ContentType: "application/pdf", Data: []byte("%PDF")}
+
+	ctx := context.WithValue(context.Background(), flow.CtxRequest, &flow.Request{Files: []flow.File{upload}})
+
+	tests := []struct {
+		name            string
+		node            *Request
+		value           flow.NodeRet
+		payload         []byte
+		wantBody        string
+		wantContentType string
+		wantFields      string
+		wantFiles       []string
+	}{
+		{
+			name:     "raw",
+			node:     &Request{},
+			value:    &EndpointRet{},
+			payload:  []byte(`{"title":"weekly"}`),
+			wantBody: `{"title":"weekly"}`,
+		},
+		{
+			name:            "form object",
+			node:            &Request{bodyMode: BodyModeForm},
+			value:           &EndpointRet{},
+			payload:         []byte(`{"title":"weekly report","tags":["a","b"],"count":2}`),
+			wantBody:        "count=2&tags=a&tags=b&title=weekly+report",
+			wantContentType: "application/x-www-form-urlencoded",
+		},
+		{
+			name:            "form encoded",
+			node:            &Request{bodyMode: BodyModeForm},
+			value:           &EndpointRet{},
+			payload:         []byte("title=weekly"),
+			wantBody:        "title=weekly",
+			wantContentType: "application/x-www-form-urlencoded",
+		},
+		{
+			name:       "multipart with script attachments",
+			node:       &Request{bodyMode: BodyModeMultipart},
+			value:      &ScriptRet{attachments: []flow.File{report}},
+			payload:    []byte(`{"title":"weekly","count":2}`),
+			wantFields: `{"count":"2","title":"weekly"}`,
+			wantFiles:  []string{"file/report.csv"},
+		},
+		{
+			name:       "multipart with files of the call",
+			node:       &Request{bodyMode: BodyModeMultipart, uploadField: "attachment"},
+			value:      &ScriptRet{attachments: []flow.File{report}},
+			wantFields: `{}`,
+			wantFiles:  []string{"attachment/report.csv", "attachment/doc.pdf"},
+		},
+		{
+			name:       "upload field without mode",
+			node:       &Request{uploadField: "file"},
+			value:      &EndpointRet{request: &flow.Request{Files: []flow.File{upload}}},
+			wantFields: `{}`,
+			wantFiles:  []string{"file/doc.pdf"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			body, _, contentType, err := tt.node.body(ctx, tt.value, tt.payload)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if tt.wantFiles == nil {
+				if string(body) != tt.wantBody || contentType != tt.wantContentType {
+					t.Errorf("body() = %s %q, want %s %q", body, contentType, tt.wantBody, tt.wantContentType)
+				}
+
+				return
+			}
+
+			// read back with the parser of the incoming calls
+			r := flow.NewRequest(http.MethodPost, nil, http.Header{"Content-Type": []string{contentType}})
+
+			fields, err := r.ReadMultipart(bytes.NewReader(body))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if string(fields) != tt.wantFields {
+				t.Errorf("fields = %s, want %s", fields, tt.wantFields)
+			}
+
+			files := make([]string, 0, len(r.Files))
+			for _, f := range r.Files {
+				files = append(files, f.Field+"/"+f.Name)
+			}
+
+			if !reflect.DeepEqual(files, tt.wantFiles) {
+				t.Errorf("files = %v, want %v", files, tt.wantFiles)
+			}
+		})
+	}
+}
+
+func TestEndpointAttachments(t *testing.T) {
+	request := &flow.Request{Files: []flow.File{{Name: "a.txt", Data: []byte("a")}}}
+	ctx := context.WithValue(context.Background(), flow.CtxRequest, request)
+
+	node, err := NewEndpoint(ctx, nil, flow.NodeData{}, "1")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ret, err := node.Run(ctx, nil, nil, &EndpointRet{output: []byte("x")}, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	attachments := ret.(flow.NodeAttachments).GetAttachments()
+	if len(attachments) != 1 || attachments[0].FileName != "a.txt" {
+		t.Errorf("attachments = %+v", attachments)
+	}
+}
@@ -0,0 +1,99 @@
+package nodes
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+
+	"github.com/google/uuid"
+	"gorm.io/datatypes"
+
+	"github.com/rakunlabs/chore/internal/store"
+	"github.com/rakunlabs/chore/pkg/flow"
+	"github.com/rakunlabs/chore/pkg/models"
+	"github.com/rakunlabs/chore/pkg/models/apimodels"
+	"github.com/rakunlabs/chore/pkg/registry"
+)
+
+func TestRequestFetchGroups(t *testing.T) {
+	defer func(v bool) { flow.HistoryDisabled = v }(flow.HistoryDisabled)
+
+	flow.HistoryDisabled = true
+
+	dbConn, err := store.OpenConnection("sqlite", map[string]interface{}{
+		"fileName": filepath.Join(t.TempDir(), "chore.db"),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := dbConn.AutoMigrate(store.Models...); err != nil {
+		t.Fatal(err)
+	}
+
+	auth := models.Auth{
+		AuthPure: models.AuthPure{
+			Name:   "team2-secret",
+			Groups: apimodels.Groups{Groups: datatypes.JSON(`["team2"]`)},
+		},
+	}
+	auth.ID.ID = uuid.New()
+
+	if result := dbConn.Create(&auth); result.Error != nil {
+		t.Fatal(result.Error)
+	}
+
+	content := `{
+		"1": {"name": "endpoint", "data": {"endpoint": "test", "methods": "POST"}, "outputs": {"output_1": {"connections": [{"node": "2", "output": "input_1"}]}}},
+		"2": {"name": "request", "data": {"url": "http://localhost", "auth": "team2-secret"}, "inputs": {"input_1": {"connections": [{"node": "1"}]}}}
+	}`
+
+	tests := []struct {
+		name    string
+		groups  string
+		wantErr bool
+	}{
+		{name: "open control", groups: `[]`, wantErr: true},
+		{name: "other team", groups: `["team1"]`, wantErr: true},
+		{name: "read only group", groups: `["team2:read"]`, wantErr: true},
+		{name: "same team", groups: `["team2"]`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			access := models.ResourceAccess(datatypes.JSON(tt.groups))
+
+			nodesReg, err := flow.DataToNode(context.Background(), "try", "test", "POST", mustParse(t, content), &registry.Registry{DB: dbConn}, access)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			defer nodesReg.Clear()
+
+			if err := flow.VisitAndFetch(context.Background(), nodesReg); (err != nil) != tt.wantErr {
+				t.Errorf("VisitAndFetch() error = %v, wantErr %v", err, tt.wantErr)
+			}
+
+			// missing references only warn in the save
+			validation := flow.Validate(context.Background(), []byte(content), &registry.Registry{DB: dbConn}, access)
+			if validation.HasError() {
+				t.Errorf("Validate() errors = %v", validation.Errors)
+			}
+
+			if (len(validation.Warnings) > 0) != tt.wantErr {
+				t.Errorf("Validate() warnings = %v, wantErr %v", validation.Warnings, tt.wantErr)
+			}
+		})
+	}
+}
+
+func mustParse(t *testing.T, content string) flow.NodesData {
+	t.Helper()
+
+	datas, err := flow.ParseData([]byte(content))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return datas
+}
@@ -0,0 +1,359 @@
+package nodes
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/rakunlabs/chore/pkg/flow"
+	"github.com/rakunlabs/chore/pkg/request"
+	"github.com/rakunlabs/chore/pkg/transfer"
+)
+
+const (
+	// PaginationLink follows the rel="next" of the Link header.
+	PaginationLink = "link"
+	// PaginationCursor sets the cursor field of the body to the query param, full URL cursor is used directly.
+	PaginationCursor = "cursor"
+	// PaginationPage increases the page number query param by one.
+	PaginationPage = "page"
+	// PaginationOffset increases the offset query param by the page size.
+	PaginationOffset = "offset"
+
+	// DefaultMaxPages is the max page count when the node has no limit.
+	DefaultMaxPages = 100
+)
+
+// pagination of the request node, empty mode is a single request.
+type pagination struct {
+	mode string
+	// param is the query param of the cursor, page or offset.
+	param string
+	// cursor is the path of the next cursor in the body like meta.next_cursor.
+	cursor string
+	// items is the path of the list in the body, empty is the body itself.
+	items string
+	// start is the first page number or offset, default page is 1.
+	start int
+	// size is the page size to increase offset.
+	size     int
+	maxPages int
+	// each sends pages to separate branches, otherwise items are one array.
+	each bool
+}
+
+func (p pagination) validate() error {
+	switch p.mode {
+	case "", PaginationLink:
+	case PaginationCursor:
+		if p.cursor == "" {
+			return fmt.Errorf("pagination cursor path is empty")
+		}
+
+		if p.param == "" {
+			return fmt.Errorf("pagination param is empty")
+		}
+	case PaginationPage:
+		if p.param == "" {
+			return fmt.Errorf("pagination param is empty")
+		}
+	case PaginationOffset:
+		if p.param == "" {
+			return fmt.Errorf("pagination param is empty")
+		}
+
+		if p.size <= 0 {
+			return fmt.Errorf("pagination offset needs page size")
+		}
+	default:
+		return fmt.Errorf("unknown pagination %s", p.mode)
+	}
+
+	if p.maxPages < 0 || p.size < 0 || p.start < 0 {
+		return fmt.Errorf("pagination numbers should be positive")
+	}
+
+	return nil
+}
+
+// RequestPagesRet holds the pages, every page goes to the selected outputs.
+type RequestPagesRet struct {
+	pages     [][]byte
+	selection []int
+	respond   flow.Respond
+}
+
+func (r *RequestPagesRet) GetBinaryData() []byte {
+	return r.respond.Data
+}
+
+func (r *RequestPagesRet) GetBinaryDatas() [][]byte {
+	return r.pages
+}
+
+func (r *RequestPagesRet) GetSelection() []int {
+	return r.selection
+}
+
+func (r *RequestPagesRet) GetRespondData() flow.Respond {
+	return r.respond
+}
+
+var (
+	_ flow.NodeRetDatas     = (*RequestPagesRet)(nil)
+	_ flow.NodeRetSelection = (*RequestPagesRet)(nil)
+)
+
+// pages returns pages as separate values or items of the pages in one array.
+func (n *Request) pages(
+	first *request.ClientResponse,
+	rawURL string,
+	send func(string) (*request.ClientResponse, error),
+) (flow.NodeRet, error) {
+	responses, ok, err := n.paginate(first, rawURL, send)
+	if err != nil {
+		return nil, err
+	}
+
+	respond := responseRespond(responses[len(responses)-1])
+
+	if !ok {
+		return &RequestRet{
+			respond:   respond,
+			selection: []int{0, 2},
+		}, nil
+	}
+
+	if n.pagination.each {
+		pages := make([][]byte, 0, len(responses))
+		for _, response := range responses {
+			pages = append(pages, response.Body)
+		}
+
+		return &RequestPagesRet{
+			pages:     pages,
+			respond:   respond,
+			selection: []int{1, 2},
+		}, nil
+	}
+
+	respond.Data = n.pagination.concat(responses)
+
+	return &RequestRet{
+		respond:   respond,
+		selection: []int{1, 2},
+	}, nil
+}
+
+// paginate calls next pages after the first response.
+// Returns the failed response with false when a page is not successful.
+func (n *Request) paginate(
+	first *request.ClientResponse,
+	rawURL string,
+	send func(string) (*request.ClientResponse, error),
+) ([]*request.ClientResponse, bool, error) {
+	responses := []*request.ClientResponse{first}
+
+	maxPages := n.pagination.maxPages
+	if maxPages == 0 {
+		maxPages = DefaultMaxPages
+	}
+
+	position := n.pagination.start
+	if n.pagination.mode == PaginationPage && position == 0 {
+		position = 1
+	}
+
+	current := rawURL
+	response := first
+
+	// pages are in memory, limit is for all pages
+	limit := n.responseLimit()
+	size := int64(len(first.Body))
+
+	for len(responses) < maxPages {
+		next, err := n.pagination.next(current, response, &position)
+		if err != nil {
+			return nil, false, err
+		}
+
+		if next == "" {
+			break
+		}
+
+		response, err = send(next)
+		if err == nil {
+			size += int64(len(response.Body))
+			if limit > 0 && size > limit {
+				err = fmt.Errorf("%w of %d bytes in %d pages", request.ErrResponseTooLarge, limit, len(responses)+1)
+			}
+		}
+
+		if err != nil {
+			return []*request.ClientResponse{{
+				Body:       []byte(fmt.Sprint(err)),
+				StatusCode: http.StatusServiceUnavailable,
+			}}, false, nil
+		}
+
+		if response.StatusCode < 100 || response.StatusCode >= 400 {
+			return []*request.ClientResponse{response}, false, nil
+		}
+
+		responses = append(responses, response)
+		current = next
+	}
+
+	return responses, true, nil
+}
+
+// next returns the URL of the next page, empty when there is no more page.
+func (p pagination) next(current string, response *request.ClientResponse, position *int) (string, error) {
+	switch p.mode {
+	case PaginationLink:
+		link := nextLink(response.Header.Values("Link"))
+		if link == "" {
+			return "", nil
+		}
+
+		return resolveURL(current, link)
+	case PaginationCursor:
+		cursor := valuePath(transfer.BytesToData(response.Body), p.cursor)
+		if cursor == nil || cursor == "" {
+			return "", nil
+		}
+
+		value := string(transfer.DataToBytes(cursor))
+		if strings.HasPrefix(value, "http://") || strings.HasPrefix(value, "https://") || strings.HasPrefix(value, "/") {
+			return resolveURL(current, value)
+		}
+
+		return setQuery(current, p.param, value)
+	}
+
+	// page and offset stop with empty page or last page smaller than size
+	count, ok := itemCount(valuePath(transfer.BytesToData(response.Body), p.items))
+	if !ok || count == 0 || (p.size > 0 && count < p.size) {
+		return "", nil
+	}
+
+	if p.mode == PaginationOffset {
+		*position += p.size
+	} else {
+		*position++
+	}
+
+	return setQuery(current, p.param, strconv.Itoa(*position))
+}
+
+// concat returns items of all pages in one array.
+func (p pagination) concat(responses []*request.ClientResponse) []byte {
+	items := []interface{}{}
+
+	for _, response := range responses {
+		switch v := valuePath(transfer.BytesToData(response.Body), p.items).(type) {
+		case []interface{}:
+			items = append(items, v...)
+		case nil:
+		default:
+			items = append(items, v)
+		}
+	}
+
+	data, _ := json.Marshal(items)
+
+	return data
+}
+
+// nextLink returns the rel="next" URL of the Link header values.
+func nextLink(values []string) string {
+	for _, value := range values {
+		for _, link := range strings.Split(value, ",") {
+			parts := strings.Split(link, ";")
+			if len(parts) < 2 {
+				continue
+			}
+
+			for _, param := range parts[1:] {
+				key, v, _ := strings.Cut(strings.TrimSpace(param), "=")
+				if !strings.EqualFold(key, "rel") {
+					continue
+				}
+
+				for _, rel := range strings.Fields(strings.Trim(v, `"`)) {
+					if strings.EqualFold(rel, "next") {
+						return strings.Trim(strings.TrimSpace(parts[0]), "<>")
+					}
+				}
+			}
+		}
+	}
+
+	return ""
+}
+
+func resolveURL(current, ref string) (string, error) {
+	base, err := url.Parse(current)
+	if err != nil {
+		return "", fmt.Errorf("pagination url: %w", err)
+	}
+
+	next, err := base.Parse(ref)
+	if err != nil {
+		return "", fmt.Errorf("pagination next url: %w", err)
+	}
+
+	return next.String(), nil
+}
+
+func setQuery(current, key, value string) (string, error) {
+	u, err := url.Parse(current)
+	if err != nil {
+		return "", fmt.Errorf("pagination url: %w", err)
+	}
+
+	query := u.Query()
+	query.Set(key, value)
+	u.RawQuery = query.Encode()
+
+	return u.String(), nil
+}
+
+// valuePath returns the value in the dot separated path, list indexes are numbers.
+func valuePath(data interface{}, path string) interface{} {
+	if path == "" {
+		return data
+	}
+
+	for _, key := range strings.Split(path, ".") {
+		switch v := data.(type) {
+		case map[string]interface{}:
+			data = v[key]
+		case []interface{}:
+			i, err := strconv.Atoi(key)
+			if err != nil || i < 0 || i >= len(v) {
+				return nil
+			}
+
+			data = v[i]
+		default:
+			return nil
+		}
+	}
+
+	return data
+}
+
+func itemCount(value interface{}) (int, bool) {
+	switch v := value.(type) {
+	case []interface{}:
+		return len(v), true
+	case map[string]interface{}:
+		return len(v), true
+	}
+
+	return 0, false
+}
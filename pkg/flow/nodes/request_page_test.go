@@ -0,0 +1,170 @@
+package nodes
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/rakunlabs/chore/pkg/flow"
+	"github.com/rakunlabs/chore/pkg/request"
+)
+
+func TestRequestPagination(t *testing.T) {
+	// 5 items, 2 items in a page
+	items := []string{"a", "b", "c", "d", "e"}
+	page := func(start int) string {
+		list := "["
+		for i := start; i < start+2 && i < len(items); i++ {
+			if i > start {
+				list += ","
+			}
+
+			list += strconv.Quote(items[i])
+		}
+
+		return list + "]"
+	}
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		query := r.URL.Query()
+
+		switch r.URL.Path {
+		case "/link":
+			start, _ := strconv.Atoi(query.Get("start"))
+			if start+2 < len(items) {
+				w.Header().Set("Link", fmt.Sprintf(`</link?start=%d>; rel="next", </link?start=4>; rel="last"`, start+2))
+			}
+
+			fmt.Fprint(w, page(start))
+		case "/cursor":
+			start, _ := strconv.Atoi(query.Get("cursor"))
+			next := ""
+			if start+2 < len(items) {
+				next = strconv.Itoa(start + 2)
+			}
+
+			fmt.Fprintf(w, `{"values":%s,"meta":{"next":%q}}`, page(start), next)
+		case "/page":
+			n, _ := strconv.Atoi(query.Get("page"))
+			if n == 0 {
+				n = 1
+			}
+
+			fmt.Fprintf(w, `{"values":%s}`, page((n-1)*2))
+		case "/offset":
+			start, _ := strconv.Atoi(query.Get("startAt"))
+			if start >= 4 {
+				w.WriteHeader(http.StatusInternalServerError)
+
+				return
+			}
+
+			fmt.Fprint(w, page(start))
+		}
+	}))
+	defer server.Close()
+
+	client, err := request.NewClient(request.Config{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name          string
+		path          string
+		pagination    pagination
+		maxSize       int64
+		want          string
+		wantPages     []string
+		wantSelection []int
+	}{
+		{
+			name:          "link header",
+			path:          "/link",
+			pagination:    pagination{mode: PaginationLink},
+			want:          `["a","b","c","d","e"]`,
+			wantSelection: []int{1, 2},
+		},
+		{
+			name:          "link pages over max size",
+			path:          "/link",
+			pagination:    pagination{mode: PaginationLink},
+			maxSize:       20,
+			wantSelection: []int{0, 2},
+		},
+		{
+			name:          "cursor each page",
+			path:          "/cursor",
+			pagination:    pagination{mode: PaginationCursor, param: "cursor", cursor: "meta.next", items: "values", each: true},
+			wantPages:     []string{`["a","b"]`, `["c","d"]`, `["e"]`},
+			wantSelection: []int{1, 2},
+		},
+		{
+			name:          "page until empty",
+			path:          "/page",
+			pagination:    pagination{mode: PaginationPage, param: "page", items: "values"},
+			want:          `["a","b","c","d","e"]`,
+			wantSelection: []int{1, 2},
+		},
+		{
+			name:          "page with max pages",
+			path:          "/page",
+			pagination:    pagination{mode: PaginationPage, param: "page", items: "values", maxPages: 2},
+			want:          `["a","b","c","d"]`,
+			wantSelection: []int{1, 2},
+		},
+		{
+			name:          "offset failed page",
+			path:          "/offset",
+			pagination:    pagination{mode: PaginationOffset, param: "startAt", size: 2},
+			wantSelection: []int{0, 2},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			n := &Request{client: client, pagination: tt.pagination, maxResponseSize: tt.maxSize}
+
+			first, err := client.Call(context.Background(), server.URL+tt.path, http.MethodGet, nil, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			send := func(url string) (*request.ClientResponse, error) {
+				return client.Call(context.Background(), url, http.MethodGet, nil, nil)
+			}
+
+			ret, err := n.pages(first, server.URL+tt.path, send)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if got := ret.(flow.NodeRetSelection).GetSelection(); fmt.Sprint(got) != fmt.Sprint(tt.wantSelection) {
+				t.Fatalf("selection = %v, want %v", got, tt.wantSelection)
+			}
+
+			if tt.wantPages != nil {
+				pages := ret.(flow.NodeRetDatas).GetBinaryDatas()
+				if len(pages) != len(tt.wantPages) {
+					t.Fatalf("pages = %d, want %d", len(pages), len(tt.wantPages))
+				}
+
+				for i := range pages {
+					if !strings.HasPrefix(string(pages[i]), `{"values":`+tt.wantPages[i]) {
+						t.Errorf("page %d = %s, want values %s", i, pages[i], tt.wantPages[i])
+					}
+				}
+
+				return
+			}
+
+			if tt.want != "" && string(ret.GetBinaryData()) != tt.want {
+				t.Errorf("data = %s, want %s", ret.GetBinaryData(), tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,79 @@
+package nodes
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+
+	"github.com/rytsh/mugo/pkg/templatex"
+
+	"github.com/rakunlabs/chore/pkg/flow"
+	"github.com/rakunlabs/chore/pkg/models"
+	"github.com/rakunlabs/chore/pkg/registry"
+)
+
+func TestRequestFailedCallRetries(t *testing.T) {
+	defer func(v bool) { flow.HistoryDisabled = v }(flow.HistoryDisabled)
+
+	flow.HistoryDisabled = true
+
+	calls := new(atomic.Int32)
+
+	// closes the connection without a response
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		calls.Add(1)
+
+		conn, _, err := w.(http.Hijacker).Hijack()
+		if err == nil {
+			conn.Close()
+		}
+	}))
+	defer server.Close()
+
+	tests := []struct {
+		name      string
+		policy    string
+		wantCalls int32
+		// false output responds 502, onError node responds 500
+		wantStatus int
+	}{
+		{name: "without retries", wantCalls: 1, wantStatus: http.StatusBadGateway},
+		{name: "with retries", policy: `, "retries": 1`, wantCalls: 2, wantStatus: http.StatusInternalServerError},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			calls.Store(0)
+
+			content := `{
+				"1": {"name": "endpoint", "data": {"endpoint": "test", "methods": "POST"}, "outputs": {"output_1": {"connections": [{"node": "2", "output": "input_2"}]}}},
+				"2": {"name": "request", "data": {"url": "` + server.URL + `", "method": "GET", "retry_disabled": true` + tt.policy + `}, "inputs": {"input_2": {"connections": [{"node": "1"}]}}, "outputs": {"output_1": {"connections": [{"node": "3", "output": "input_1"}]}}},
+				"3": {"name": "respond", "data": {"status": "502"}, "inputs": {"input_1": {"connections": [{"node": "2"}]}}},
+				"4": {"name": "onError", "data": {}, "outputs": {"output_1": {"connections": [{"node": "5", "output": "input_1"}]}}},
+				"5": {"name": "respond", "data": {"status": "500"}, "inputs": {"input_1": {"connections": [{"node": "4"}]}}}
+			}`
+
+			wg := &sync.WaitGroup{}
+
+			nodesReg, err := flow.StartFlow(context.Background(), wg, "try", "test", "POST", []byte(content), &registry.Registry{Template: templatex.New()}, models.Access{}, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			respond := <-nodesReg.GetChan()
+
+			wg.Wait()
+
+			if respond.Status != tt.wantStatus {
+				t.Errorf("respond status = %d, want %d; %s", respond.Status, tt.wantStatus, respond.Data)
+			}
+
+			if got := calls.Load(); got != tt.wantCalls {
+				t.Errorf("calls = %d, want %d", got, tt.wantCalls)
+			}
+		})
+	}
+}
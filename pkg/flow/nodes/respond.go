@@ -12,6 +12,7 @@ import (
 
 	"github.com/rakunlabs/chore/pkg/flow"
 	"github.com/rakunlabs/chore/pkg/flow/convert"
+	"github.com/rakunlabs/chore/pkg/models"
 	"github.com/rakunlabs/chore/pkg/registry"
 )
 
@@ -87,7 +88,7 @@ func (n *Respond) GetType() string {
 	return respondType
 }
 
-func (n *Respond) Fetch(ctx context.Context, db *gorm.DB) error {
+func (n *Respond) Fetch(ctx context.Context, db *gorm.DB, _ models.Access) error {
 	return nil
 }
 
@@ -0,0 +1,89 @@
+package nodes
+
+import (
+	"context"
+	"sync"
+	"testing"
+
+	"github.com/rakunlabs/chore/pkg/flow"
+	"github.com/rakunlabs/chore/pkg/models"
+	"github.com/rakunlabs/chore/pkg/registry"
+)
+
+func TestResult(t *testing.T) {
+	defer func(v bool) { flow.HistoryDisabled = v }(flow.HistoryDisabled)
+
+	flow.HistoryDisabled = true
+
+	tests := []struct {
+		name       string
+		content    string
+		wantStatus int
+		wantData   string
+		wantError  bool
+		wantRun    string
+	}{
+		{
+			name: "respond",
+			content: `{
+				"1": {"name": "endpoint", "data": {"endpoint": "test", "methods": "POST"}, "outputs": {"output_1": {"connections": [{"node": "2", "output": "input_1"}]}}},
+				"2": {"name": "respond", "data": {"status": "201"}, "inputs": {"input_1": {"connections": [{"node": "1"}]}}}
+			}`,
+			wantStatus: 201,
+			wantData:   "deepcore",
+			wantRun:    models.RunStatusSuccess,
+		},
+		{
+			name: "no respond",
+			content: `{
+				"1": {"name": "endpoint", "data": {"endpoint": "test", "methods": "POST"}, "outputs": {"output_1": {"connections": [{"node": "2", "output": "input_1"}]}}},
+				"2": {"name": "log", "data": {}, "inputs": {"input_1": {"connections": [{"node": "1"}]}}, "outputs": {"output_1": {"connections": []}}}
+			}`,
+			wantStatus: 202,
+			wantData:   "Accepted",
+			wantRun:    models.RunStatusSuccess,
+		},
+		{
+			name: "error",
+			content: `{
+				"1": {"name": "endpoint", "data": {"endpoint": "test", "methods": "POST"}, "outputs": {"output_1": {"connections": [{"node": "2", "output": "input_1"}]}}},
+				"2": {"name": "forLoop", "data": {"for": "throw new Error('boom')"}, "inputs": {"input_1": {"connections": [{"node": "1"}]}}}
+			}`,
+			wantError: true,
+			wantRun:   models.RunStatusFailed,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			wg := &sync.WaitGroup{}
+
+			ctx := context.WithValue(context.Background(), flow.CtxKeepResult, true)
+
+			nodesReg, err := flow.StartFlow(ctx, wg, "try", "test", "POST", []byte(tt.content), &registry.Registry{}, models.Access{}, []byte("deepcore"))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			<-nodesReg.Done()
+			wg.Wait()
+
+			got, ok := nodesReg.Result()
+			if !ok {
+				t.Fatal("result not found")
+			}
+
+			if got.IsError != tt.wantError {
+				t.Fatalf("result isError = %v, data = %s", got.IsError, got.Data)
+			}
+
+			if !tt.wantError && (got.Status != tt.wantStatus || string(got.Data) != tt.wantData) {
+				t.Errorf("result = %d %s, want %d %s", got.Status, got.Data, tt.wantStatus, tt.wantData)
+			}
+
+			if status := nodesReg.Status(); status != tt.wantRun {
+				t.Errorf("status = %s, want %s", status, tt.wantRun)
+			}
+		})
+	}
+}
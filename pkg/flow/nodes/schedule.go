@@ -0,0 +1,159 @@
+package nodes
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/rakunlabs/chore/pkg/flow"
+	"github.com/rakunlabs/chore/pkg/flow/convert"
+	"github.com/rakunlabs/chore/pkg/models"
+	"github.com/rakunlabs/chore/pkg/registry"
+	"github.com/rakunlabs/chore/pkg/scheduler"
+
+	"gorm.io/gorm"
+)
+
+var scheduleType = "schedule"
+
+type ScheduleRet struct {
+	output []byte
+}
+
+func (r *ScheduleRet) GetBinaryData() []byte {
+	return r.output
+}
+
+// Schedule node has one output, it starts the flow with cron specification.
+type Schedule struct {
+	cron     string
+	timezone string
+	payload  []byte
+	outputs  [][]flow.Connection
+	checked  bool
+	disabled bool
+	nodeID   string
+	tags     []string
+}
+
+var (
+	_ flow.NoderEndpoint = (*Schedule)(nil)
+	_ flow.NoderSchedule = (*Schedule)(nil)
+)
+
+func (n *Schedule) Run(_ context.Context, _ *sync.WaitGroup, _ *registry.Registry, value flow.NodeRet, _ string) (flow.NodeRet, error) {
+	return &ScheduleRet{output: value.GetBinaryData()}, nil
+}
+
+func (n *Schedule) GetType() string {
+	return scheduleType
+}
+
+func (n *Schedule) Fetch(_ context.Context, _ *gorm.DB, _ models.Access) error {
+	return nil
+}
+
+func (n *Schedule) IsFetched() bool {
+	return true
+}
+
+func (n *Schedule) IsRespond() bool {
+	return false
+}
+
+func (n *Schedule) Validate(_ context.Context) error {
+	if n.cron == "" {
+		return fmt.Errorf("cron is empty")
+	}
+
+	if _, err := scheduler.Parser.Parse(n.Schedule()); err != nil {
+		return fmt.Errorf("cron is not valid: %w", err)
+	}
+
+	return nil
+}
+
+func (n *Schedule) Next(i int) []flow.Connection {
+	return n.outputs[i]
+}
+
+func (n *Schedule) NextCount() int {
+	return len(n.outputs)
+}
+
+func (n *Schedule) Check() {
+	n.checked = true
+}
+
+func (n *Schedule) IsChecked() bool {
+	return n.checked
+}
+
+func (n *Schedule) IsDisabled() bool {
+	return n.disabled
+}
+
+func (n *Schedule) ActiveInput(string, map[string]struct{}) {}
+
+// Endpoint of schedule is the node id, scheduler start the flow with it.
+func (n *Schedule) Endpoint() string {
+	return n.nodeID
+}
+
+func (n *Schedule) Methods() []string {
+	return []string{flow.MethodSchedule}
+}
+
+func (n *Schedule) Schedule() string {
+	if n.cron == "" {
+		return ""
+	}
+
+	if n.timezone != "" {
+		return "CRON_TZ=" + n.timezone + " " + n.cron
+	}
+
+	return n.cron
+}
+
+func (n *Schedule) Payload() []byte {
+	return n.payload
+}
+
+func (n *Schedule) Tags() []string {
+	return n.tags
+}
+
+func (n *Schedule) NodeID() string {
+	return n.nodeID
+}
+
+func NewSchedule(_ context.Context, _ *flow.NodesReg, data flow.NodeData, nodeID string) (flow.Noder, error) {
+	outputs := flow.PrepareOutputs(data.Outputs)
+
+	cron, _ := data.Data["cron"].(string)
+	timezone, _ := data.Data["timezone"].(string)
+	payload, _ := data.Data["payload"].(string)
+
+	tags := convert.GetList(data.Data["tags"])
+
+	var payloadBytes []byte
+	if payload != "" {
+		payloadBytes = []byte(payload)
+	}
+
+	return &Schedule{
+		outputs:  outputs,
+		cron:     strings.TrimSpace(cron),
+		timezone: strings.TrimSpace(timezone),
+		payload:  payloadBytes,
+		nodeID:   nodeID,
+		tags:     tags,
+	}, nil
+}
+
+//nolint:gochecknoinits // moduler nodes
+func init() {
+	flow.NodeTypes[scheduleType] = NewSchedule
+}
@@ -0,0 +1,58 @@
+package nodes
+
+import (
+	"context"
+	"testing"
+
+	"github.com/rakunlabs/chore/pkg/flow"
+)
+
+func TestScheduleSpec(t *testing.T) {
+	tests := []struct {
+		name    string
+		data    map[string]interface{}
+		want    string
+		wantErr bool
+	}{
+		{
+			name: "cron",
+			data: map[string]interface{}{"cron": " 0 9 * * * "},
+			want: "0 9 * * *",
+		},
+		{
+			name: "timezone",
+			data: map[string]interface{}{"cron": "0 9 * * *", "timezone": "Europe/Amsterdam"},
+			want: "CRON_TZ=Europe/Amsterdam 0 9 * * *",
+		},
+		{
+			name:    "unknown timezone",
+			data:    map[string]interface{}{"cron": "0 9 * * *", "timezone": "Mars/Olympus"},
+			want:    "CRON_TZ=Mars/Olympus 0 9 * * *",
+			wantErr: true,
+		},
+		{
+			name:    "empty cron",
+			data:    map[string]interface{}{"timezone": "Europe/Amsterdam"},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			node, err := NewSchedule(context.Background(), nil, flow.NodeData{Data: tt.data}, "1")
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			schedule := node.(*Schedule)
+
+			if got := schedule.Schedule(); got != tt.want {
+				t.Errorf("Schedule() = %q, want %q", got, tt.want)
+			}
+
+			if err := schedule.Validate(context.Background()); (err != nil) != tt.wantErr {
+				t.Errorf("Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
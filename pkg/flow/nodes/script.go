@@ -1,14 +1,16 @@
 package nodes
 
 import (
-	"bytes"
 	"context"
+	"mime"
+	"path/filepath"
 	"sort"
 	"sync"
 
 	"github.com/rakunlabs/chore/pkg/email"
 	"github.com/rakunlabs/chore/pkg/flow"
 	"github.com/rakunlabs/chore/pkg/flow/convert"
+	"github.com/rakunlabs/chore/pkg/models"
 	"github.com/rakunlabs/chore/pkg/registry"
 	"github.com/rakunlabs/chore/pkg/script/js"
 	"github.com/rakunlabs/chore/pkg/transfer"
@@ -28,7 +30,7 @@ type ScriptRet struct {
 	selection    []int
 	output       []byte
 	outputValues []byte
-	attachments  []email.Attach
+	attachments  []flow.File
 }
 
 func (r *ScriptRet) GetBinaryData() []byte {
@@ -44,6 +46,10 @@ func (r *ScriptRet) GetBinaryValues() []byte {
 }
 
 func (r *ScriptRet) GetAttachments() []email.Attach {
+	return flow.Attachments(r.attachments)
+}
+
+func (r *ScriptRet) GetFiles() []flow.File {
 	return r.attachments
 }
 
@@ -51,6 +57,7 @@ var (
 	_ flow.NodeRetSelection = (*ScriptRet)(nil)
 	_ flow.NodeRetValues    = (*ScriptRet)(nil)
 	_ flow.NodeAttachments  = (*ScriptRet)(nil)
+	_ flow.NodeFiles        = (*ScriptRet)(nil)
 )
 
 // Script node has many input and one output.
@@ -137,17 +144,28 @@ func (n *Script) Run(ctx context.Context, _ *sync.WaitGroup, _ *registry.Registr
 	runner.SetFunction("setValue", setValue)
 
 	// value for email attachment
-	var valueAttachment []email.Attach
+	var valueAttachment []flow.File
 	setAttachment := func(name string, v []byte) {
-		valueAttachment = append(valueAttachment, email.Attach{
-			FileName: name,
-			Content:  bytes.NewReader(v),
+		valueAttachment = append(valueAttachment, flow.File{
+			Name:        name,
+			ContentType: mime.TypeByExtension(filepath.Ext(name)),
+			Data:        v,
 		})
 	}
 
 	runner.SetFunction("setAttachment", setAttachment)
 
-	if err := runner.Set("request", n.inputRequest); err != nil {
+	// responds of the inputs with input names and the incoming call
+	requestValues := flow.GetRequest(ctx).Map()
+	if requestValues == nil {
+		requestValues = make(map[string]interface{}, len(n.inputRequest))
+	}
+
+	for k, v := range n.inputRequest {
+		requestValues[k] = v
+	}
+
+	if err := runner.Set("request", requestValues); err != nil {
 		log.Ctx(ctx).Warn().Msgf("cannot set data to script: %v", err)
 	}
 
@@ -178,7 +196,7 @@ func (n *Script) GetType() string {
 	return scriptType
 }
 
-func (n *Script) Fetch(_ context.Context, _ *gorm.DB) error {
+func (n *Script) Fetch(_ context.Context, _ *gorm.DB, _ models.Access) error {
 	return nil
 }
 
@@ -3,6 +3,8 @@ package nodes
 import (
 	"context"
 	"errors"
+	"net/http"
+	"net/url"
 	"sync"
 	"testing"
 
@@ -90,6 +92,42 @@ func TestScript_Run(t *testing.T) {
 				output: []byte(`{"output_1":"node_1","output_2":null,"output_3":"node_3","output_4":"node_4"}`),
 			},
 		},
+		{
+			name: "incoming request",
+			args: args{
+				ctx: context.WithValue(context.Background(), flow.CtxRequest, flow.NewRequest(
+					"POST",
+					&url.URL{Path: "/api/v1/send", RawQuery: "control=try&endpoint=test&filter=open"},
+					http.Header{"X-Github-Event": []string{"push"}},
+				)),
+				data: flow.NodeData{
+					Data: map[string]interface{}{
+						"script": "function main(input_1){return request.method + ' ' + request.header['x-github-event'] + ' ' + request.query.filter}",
+					},
+					Inputs: flow.NodeConnection{
+						"input_1": flow.Connections{
+							[]flow.Connection{
+								{
+									Node: "1",
+								},
+							},
+						},
+					},
+				},
+			},
+			datas: map[string]struct {
+				data  []byte
+				input string
+			}{
+				"1": {
+					data:  []byte("node_1"),
+					input: "input_1",
+				},
+			},
+			want: &ScriptRet{
+				output: []byte(`POST push open`),
+			},
+		},
 		{
 			name: "multiple inputs, selective active",
 			args: args{
@@ -196,7 +234,7 @@ func TestScript_Run(t *testing.T) {
 					tt.args.ctx,
 					wg,
 					nil,
-					&EndpointRet{value.data},
+					&EndpointRet{output: value.data},
 					value.input,
 				)
 				if errors.Is(err, flow.ErrStopGoroutine) {
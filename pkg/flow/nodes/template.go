@@ -27,6 +27,8 @@ func (r *TemplateRet) GetBinaryData() []byte {
 	return r.output
 }
 
+var _ flow.NoderReference = (*Template)(nil)
+
 // Template node has one input and one output.
 type Template struct {
 	templateName string
@@ -41,8 +43,8 @@ type Template struct {
 }
 
 // Run get values from active input nodes and it will not run until last input comes.
-func (n *Template) Run(_ context.Context, _ *sync.WaitGroup, reg *registry.Registry, value flow.NodeRet, _ string) (flow.NodeRet, error) {
-	v := transfer.BytesToData(value.GetBinaryData())
+func (n *Template) Run(ctx context.Context, _ *sync.WaitGroup, reg *registry.Registry, value flow.NodeRet, _ string) (flow.NodeRet, error) {
+	v := flow.WithTemplateRequest(ctx, transfer.BytesToData(value.GetBinaryData()))
 
 	buf := bytes.Buffer{}
 	if err := reg.Template.Execute(templatex.WithIO(&buf), templatex.WithData(v), templatex.WithContent(string(n.content))); err != nil {
@@ -60,14 +62,14 @@ func (n *Template) GetType() string {
 	return templateType
 }
 
-func (n *Template) Fetch(ctx context.Context, db *gorm.DB) error {
+func (n *Template) Fetch(ctx context.Context, db *gorm.DB, access models.Access) error {
 	if n.templateName == "" {
 		return fmt.Errorf("template fetch failed: templateName empty")
 	}
 
 	getData := models.TemplatePure{}
 
-	query := db.WithContext(ctx).Model(&models.Template{}).Where("name = ?", n.templateName)
+	query := db.WithContext(ctx).Model(&models.Template{}).Where("name = ?", n.templateName).Scopes(access.Scope(false))
 	result := query.First(&getData)
 
 	if result.Error != nil {
@@ -95,9 +97,17 @@ func (n *Template) IsRespond() bool {
 }
 
 func (n *Template) Validate(_ context.Context) error {
+	if n.templateName == "" {
+		return fmt.Errorf("template is empty")
+	}
+
 	return nil
 }
 
+func (n *Template) References() []flow.Reference {
+	return []flow.Reference{{Type: flow.ReferenceTemplate, Name: n.templateName, Field: "template"}}
+}
+
 func (n *Template) Next(i int) []flow.Connection {
 	return n.outputs[i]
 }
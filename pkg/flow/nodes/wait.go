@@ -7,6 +7,7 @@ import (
 
 	"github.com/rakunlabs/chore/pkg/flow"
 	"github.com/rakunlabs/chore/pkg/flow/convert"
+	"github.com/rakunlabs/chore/pkg/models"
 	"github.com/rakunlabs/chore/pkg/registry"
 
 	"github.com/rs/zerolog/log"
@@ -116,7 +117,7 @@ func (n *Wait) GetType() string {
 	return waitType
 }
 
-func (n *Wait) Fetch(ctx context.Context, db *gorm.DB) error {
+func (n *Wait) Fetch(ctx context.Context, db *gorm.DB, _ models.Access) error {
 	return nil
 }
 
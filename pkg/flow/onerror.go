@@ -0,0 +1,65 @@
+package flow
+
+import (
+	"context"
+	"encoding/json"
+
+	"github.com/rs/zerolog/log"
+
+	"github.com/rakunlabs/chore/pkg/transfer"
+)
+
+// CtxOnError is set in the branches of error handlers, failures in there not trigger handlers again.
+const CtxOnError ContextType = "onError"
+
+// NodeError is the value of the error handler nodes.
+type NodeError struct {
+	Control  string      `json:"control"`
+	Endpoint string      `json:"endpoint"`
+	NodeID   string      `json:"node_id"`
+	Type     string      `json:"type"`
+	Message  string      `json:"message"`
+	Input    interface{} `json:"input"`
+}
+
+// onError starts the error handler nodes with failed node information.
+func (r *NodesReg) onError(ctx context.Context, node Noder, value NodeRet, err error) {
+	if len(r.errorStarts) == 0 {
+		return
+	}
+
+	if inHandler, _ := ctx.Value(CtxOnError).(bool); inHandler {
+		log.Ctx(ctx).Warn().Msg("node failed in error handler, skipping handlers")
+
+		return
+	}
+
+	nodeError := NodeError{
+		Control:  r.controlName,
+		Endpoint: r.startName,
+		NodeID:   node.NodeID(),
+		Type:     node.GetType(),
+		Message:  err.Error(),
+	}
+
+	if value != nil {
+		nodeError.Input = transfer.BytesToData(value.GetBinaryData())
+	}
+
+	payload, errMarshal := json.Marshal(nodeError)
+	if errMarshal != nil && value != nil {
+		// input has not json compatible keys
+		nodeError.Input = string(value.GetBinaryData())
+		payload, errMarshal = json.Marshal(nodeError)
+	}
+
+	if errMarshal != nil {
+		log.Ctx(ctx).Error().Err(errMarshal).Msg("cannot marshal node error")
+
+		return
+	}
+
+	ctx = context.WithValue(ctx, CtxOnError, true)
+
+	branch(ctx, r.errorStarts, r, &nodeRetOutput{payload})
+}
@@ -5,6 +5,7 @@ import (
 	"encoding/json"
 	"fmt"
 
+	"github.com/rakunlabs/chore/pkg/models"
 	"github.com/rakunlabs/chore/pkg/registry"
 )
 
@@ -22,8 +23,9 @@ func DataToNode(
 	controlName, startName, method string,
 	datas NodesData,
 	appStore *registry.Registry,
+	access models.Access,
 ) (*NodesReg, error) {
-	reg := NewNodesReg(controlName, startName, method, appStore)
+	reg := NewNodesReg(controlName, startName, method, appStore, access)
 
 	for nodeNumber := range datas {
 		createFunc := NodeTypes[datas[nodeNumber].Name]
@@ -36,6 +38,13 @@ func DataToNode(
 			return nil, err
 		}
 
+		policy, err := ParsePolicy(datas[nodeNumber].Data)
+		if err != nil {
+			return nil, fmt.Errorf("node %s: %w", nodeNumber, err)
+		}
+
+		reg.policies[nodeNumber] = policy
+
 		reg.Set(nodeNumber, node)
 	}
 
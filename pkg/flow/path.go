@@ -1,13 +1,12 @@
 package flow
 
 import (
-	"bytes"
 	"context"
 	"errors"
 	"fmt"
-	"net/http"
 	"runtime/debug"
 	"sync"
+	"time"
 
 	"github.com/rs/zerolog/log"
 )
@@ -49,6 +48,11 @@ func VisitAndFetch(ctx context.Context, reg *NodesReg) error {
 		}
 	}
 
+	// error handlers get same tags with starts
+	if err := validateFetch(ctx, "", reg.errorStarts, reg); err != nil {
+		return err
+	}
+
 	return nil
 }
 
@@ -87,7 +91,7 @@ func validateFetch(ctx context.Context, current string, outputs []Connection, re
 			return fmt.Errorf("ID %s, %s validate failed: %w", output, node.GetType(), err)
 		}
 
-		if err := node.Fetch(ctx, reg.appStore.DB); err != nil {
+		if err := node.Fetch(ctx, reg.appStore.DB, reg.access); err != nil {
 			return fmt.Errorf("ID %s, %s fetch failed: %w", output, node.GetType(), err)
 		}
 
@@ -115,11 +119,14 @@ func GoAndRun(ctx context.Context, wg *sync.WaitGroup, reg *NodesReg, firstValue
 
 	starts := reg.GetStarts()
 
+	defer ActiveRuns.remove(reg)
+
 	// stuct count check
 
 	reg.stuckChan = make(chan bool, 1)
 
-	stuckCheckCtx, stuckCheckCtxCancel := context.WithCancel(ctx)
+	// not depend on run context, branches send to stuckChan until all of them finished
+	stuckCheckCtx, stuckCheckCtxCancel := context.WithCancel(context.WithoutCancel(ctx))
 
 	wg.Add(1)
 	go func() {
@@ -136,49 +143,60 @@ func GoAndRun(ctx context.Context, wg *sync.WaitGroup, reg *NodesReg, firstValue
 				}
 			case <-stuckCheckCtx.Done():
 				return
-			case <-ctx.Done():
-				return
 			}
 		}
 	}()
 
-	// change waitgroup to check all job is finished
-	for _, start := range starts {
-		branch(ctx, []Connection{start.Connection}, reg, &nodeRetOutput{firstValue})
+	// excess runs wait here
+	release, err := reg.waitRunLimit(ctx)
+	if err != nil {
+		// canceled run has own error
+		if !errors.Is(reg.Err(), ErrRunCanceled) {
+			reg.AddError(err)
+		}
+	} else {
+		// change waitgroup to check all job is finished
+		for _, start := range starts {
+			branch(ctx, []Connection{start.Connection}, reg, &nodeRetOutput{firstValue})
+		}
 	}
 
 	// wait to finish that control flow
 	reg.wgx.Wait()
 
+	if release != nil {
+		release()
+	}
+
 	// cancel stuck check
 	stuckCheckCtxCancel()
 
-	if reg.respondChan != nil {
-		if reg.respondChanActive {
-			// send error to channel
-			buff := bytes.Buffer{}
-			for _, err := range reg.errors {
-				buff.WriteString("[")
-				buff.WriteString(err.Error())
-				buff.WriteString("]")
-			}
+	// respond node sets the result before, otherwise result is errors or accepted
+	reg.mutex.Lock()
+	if reg.result == nil {
+		reg.result = errorsRespond(reg.errors)
 
-			if buff.Len() != 0 {
-				reg.respondChan <- Respond{
-					Data:    buff.Bytes(),
-					IsError: true,
-				}
-			} else {
-				reg.respondChan <- Respond{
-					Status:  http.StatusAccepted,
-					Data:    []byte("Accepted"),
-					IsError: false,
-				}
-			}
+		if reg.respondChanActive {
+			reg.respondChanActive = false
+			reg.respondChan <- *reg.result
 		}
+	}
 
+	if reg.respondChan != nil {
 		close(reg.respondChan)
 	}
+	reg.mutex.Unlock()
+
+	reg.historyFinish(ctx, reg.Err())
+
+	// release contexts of the nodes
+	reg.Clear()
+
+	if reg.cancel != nil {
+		reg.cancel()
+	}
+
+	close(reg.done)
 
 	log.Ctx(ctx).Info().Msgf("completed control flow")
 }
@@ -189,19 +207,27 @@ func branch(ctx context.Context, nexts []Connection, reg *NodesReg, value NodeRe
 		// going goroutine to prevent too much recursive call
 		reg.wgx.Add(1)
 		reg.UpdateStuck(CountTotalIncrease, false)
+		slotsAdd(ctx)
 
 		go branchRun(ctx, next, reg, value)
 	}
 }
 
 func branchRun(ctx context.Context, start Connection, reg *NodesReg, value NodeRet) {
+	var node Noder
+
 	defer func() {
 		// check panic
 		if r := recover(); r != nil {
 			log.Ctx(ctx).Error().Msgf("panic: %v\n%v", r, string(debug.Stack()))
 			reg.AddError(fmt.Errorf("panic: %s cannot run: %v\n%v", start.Node, r, string(debug.Stack())))
+
+			if node != nil {
+				reg.onError(ctx, node, value, fmt.Errorf("panic: %v", r))
+			}
 		}
 
+		slotsDone(ctx)
 		reg.UpdateStuck(CountTotalDecrease, true)
 		reg.wgx.Done()
 	}()
@@ -215,6 +241,7 @@ func branchRun(ctx context.Context, start Connection, reg *NodesReg, value NodeR
 
 	node, ok := reg.Get(start.Node)
 	if !ok {
+
 		log.Ctx(ctx).Error().Msgf("node %s not found", start.Node)
 
 		return
@@ -230,21 +257,28 @@ func branchRun(ctx context.Context, start Connection, reg *NodesReg, value NodeR
 	// log debug
 	log.Ctx(ctx).Debug().Msgf("running [%s]", node.GetType())
 
-	outputDatas, err := node.Run(ctx, &reg.wgx, reg.appStore, value, start.Output)
+	startedAt := time.Now()
+
+	outputDatas, err := reg.runNode(ctx, node, value, start.Output)
 	if err != nil {
 		if errors.Is(err, ErrStopGoroutine) {
 			return
 		}
 
+		reg.historyNode(ctx, node, value, nil, startedAt, err)
+
 		log.Ctx(ctx).Error().Err(err).Msgf("%v cannot run", node.GetType())
 
 		reg.AddError(fmt.Errorf("%s cannot run; nodeID=[%s]: %w", node.GetType(), node.NodeID(), err))
+		reg.onError(ctx, node, value, err)
 
 		return
 	}
 
 	log.Ctx(ctx).Debug().Msgf("complete [%s]", node.GetType())
 
+	reg.historyNode(ctx, node, value, outputDatas, startedAt, nil)
+
 	// direct go to output
 	if outputDatasRespond, ok := outputDatas.(NodeDirectGo); ok {
 		branch(ctx, node.Next(0), reg, outputDatasRespond.IsDirectGo())
@@ -256,11 +290,20 @@ func branchRun(ctx context.Context, start Connection, reg *NodesReg, value NodeR
 	if outputDatasRespond, ok := outputDatas.(NodeRetRespond); ok {
 		// only one respond protection
 		reg.mutex.Lock()
-		if reg.respondChanActive {
-			reg.respondChanActive = false
+		if reg.result == nil {
+			respond := outputDatasRespond.GetRespond()
+			if reg.keepResult {
+				respond = keepRespondFile(respond)
+			}
 
-			if reg.respondChan != nil {
-				reg.respondChan <- outputDatasRespond.GetRespond()
+			reg.result = &respond
+
+			if reg.respondChanActive {
+				reg.respondChanActive = false
+
+				if reg.respondChan != nil {
+					reg.respondChan <- openRespondFile(ctx, respond)
+				}
 			}
 		}
 		reg.mutex.Unlock()
@@ -269,11 +312,30 @@ func branchRun(ctx context.Context, start Connection, reg *NodesReg, value NodeR
 	}
 
 	// returning more than one data
-	// call everything as for loop
+	// call everything as for loop, selection chooses outputs of the datas
 	if outputDatasFor, ok := outputDatas.(NodeRetDatas); ok {
 		datas := outputDatasFor.GetBinaryDatas()
-		for i := range datas {
-			branch(ctx, node.Next(0), reg, &nodeRetOutput{datas[i]})
+
+		limit := MaxParallel
+		if v, ok := node.(NoderParallel); ok && v.Parallel() > 0 {
+			limit = v.Parallel()
+		}
+
+		selection := []int{0}
+		if v, ok := outputDatas.(NodeRetSelection); ok {
+			selection = v.GetSelection()
+		}
+
+		for _, output := range selection {
+			if limit > 0 && len(datas) > limit {
+				branchParallel(ctx, node.Next(output), reg, datas, limit)
+
+				continue
+			}
+
+			for i := range datas {
+				branch(ctx, node.Next(output), reg, &nodeRetOutput{datas[i]})
+			}
 		}
 
 		return
@@ -291,3 +353,21 @@ func branchRun(ctx context.Context, start Connection, reg *NodesReg, value NodeR
 	// just one output group
 	branch(ctx, node.Next(0), reg, outputDatas)
 }
+
+// openRespondFile opens the spooled body for the caller, file is removed at the end of the run.
+func openRespondFile(ctx context.Context, respond Respond) Respond {
+	if respond.File == nil || respond.File.Path == "" {
+		return respond
+	}
+
+	reader, err := respond.File.Open()
+	if err != nil {
+		log.Ctx(ctx).Error().Err(err).Msg("cannot open respond file")
+
+		return respond
+	}
+
+	respond.Reader = reader
+
+	return respond
+}
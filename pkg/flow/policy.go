@@ -0,0 +1,227 @@
+package flow
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"runtime/debug"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/rs/zerolog/log"
+
+	"github.com/rakunlabs/chore/pkg/flow/convert"
+)
+
+var (
+	ErrNodeTimeout = errors.New("node timeout")
+
+	// MaxRetries limits the retries of a node.
+	MaxRetries = 10
+	// MaxBackoff limits the wait time between retries.
+	MaxBackoff = 5 * time.Minute
+)
+
+// Policy is the run policy of a node, usable in any node with data fields
+// timeout, retries, backoff and retry_on.
+type Policy struct {
+	// Timeout of one attempt, 0 is no timeout.
+	Timeout time.Duration
+	// Retries after first failed attempt.
+	Retries int
+	// Backoff is the wait before first retry, doubles in every retry.
+	Backoff time.Duration
+	// RetryOn retries only errors containing one of the values, empty retries all errors.
+	RetryOn []string
+}
+
+// ParsePolicy gets policy from node data.
+func ParsePolicy(data map[string]interface{}) (Policy, error) {
+	policy := Policy{
+		RetryOn: convert.GetList(data["retry_on"]),
+	}
+
+	var err error
+
+	if policy.Timeout, err = parseDuration(data["timeout"]); err != nil {
+		return policy, fmt.Errorf("timeout is not valid: %w", err)
+	}
+
+	if policy.Backoff, err = parseDuration(data["backoff"]); err != nil {
+		return policy, fmt.Errorf("backoff is not valid: %w", err)
+	}
+
+	switch v := data["retries"].(type) {
+	case float64:
+		policy.Retries = int(v)
+	case string:
+		if v = strings.TrimSpace(v); v != "" {
+			if policy.Retries, err = strconv.Atoi(v); err != nil {
+				return policy, fmt.Errorf("retries is not valid: %w", err)
+			}
+		}
+	}
+
+	if policy.Retries < 0 || policy.Retries > MaxRetries {
+		return policy, fmt.Errorf("retries should be between 0 and %d", MaxRetries)
+	}
+
+	return policy, nil
+}
+
+// parseDuration accepts duration string like 10s, 1m or number as seconds.
+func parseDuration(v interface{}) (time.Duration, error) {
+	var d time.Duration
+
+	switch v := v.(type) {
+	case float64:
+		d = time.Duration(v * float64(time.Second))
+	case string:
+		v = strings.TrimSpace(v)
+		if v == "" {
+			return 0, nil
+		}
+
+		if seconds, err := strconv.ParseFloat(v, 64); err == nil {
+			d = time.Duration(seconds * float64(time.Second))
+
+			break
+		}
+
+		var err error
+		if d, err = time.ParseDuration(v); err != nil {
+			return 0, err //nolint:wrapcheck // no need
+		}
+	}
+
+	if d < 0 {
+		return 0, fmt.Errorf("negative duration %v", d)
+	}
+
+	return d, nil
+}
+
+func (p Policy) retryable(err error) bool {
+	if len(p.RetryOn) == 0 {
+		return true
+	}
+
+	msg := err.Error()
+	for _, v := range p.RetryOn {
+		if strings.Contains(msg, v) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (p Policy) backoff(attempt int) time.Duration {
+	d := p.Backoff
+	for i := 1; i < attempt && d < MaxBackoff; i++ {
+		d *= 2
+	}
+
+	if d > MaxBackoff {
+		d = MaxBackoff
+	}
+
+	return d
+}
+
+// Policy returns the run policy of the node.
+func (r *NodesReg) Policy(nodeID string) Policy {
+	return r.policies[nodeID]
+}
+
+// runNode runs the node with the policy of it.
+func (r *NodesReg) runNode(ctx context.Context, node Noder, value NodeRet, input string) (NodeRet, error) {
+	policy := r.policies[node.NodeID()]
+
+	for attempt := 1; ; attempt++ {
+		output, running, err := r.runNodeAttempt(ctx, policy, node, value, input)
+		if err == nil || errors.Is(err, ErrStopGoroutine) {
+			if attempt > 1 {
+				log.Ctx(ctx).Info().Msgf("%s succeeded in attempt %d", node.GetType(), attempt)
+			}
+
+			return output, err
+		}
+
+		if attempt > policy.Retries || !policy.retryable(err) {
+			return output, err
+		}
+
+		// timed out attempt must return before running the same node again
+		if running != nil {
+			log.Ctx(ctx).Warn().Err(err).Msgf("%s failed in attempt %d/%d, waiting the attempt to return", node.GetType(), attempt, policy.Retries+1)
+
+			select {
+			case <-ctx.Done():
+				return nil, fmt.Errorf("canceled in retry: %w", err)
+			case <-running:
+			}
+		}
+
+		wait := policy.backoff(attempt)
+
+		log.Ctx(ctx).Warn().Err(err).Msgf("%s failed in attempt %d/%d, retrying in %v", node.GetType(), attempt, policy.Retries+1, wait)
+
+		select {
+		case <-ctx.Done():
+			return nil, fmt.Errorf("canceled in retry: %w", err)
+		case <-time.After(wait):
+		}
+	}
+}
+
+// runNodeAttempt runs the node once, running is closed when the timed out node returns.
+func (r *NodesReg) runNodeAttempt(ctx context.Context, policy Policy, node Noder, value NodeRet, input string) (NodeRet, <-chan struct{}, error) {
+	if policy.Timeout <= 0 {
+		output, err := node.Run(ctx, &r.wgx, r.appStore, value, input)
+
+		return output, nil, err //nolint:wrapcheck // no need
+	}
+
+	// node may start background work like other controls, so returned attempt keeps the context.
+	// Context is released with its deadline or with the cancel of the run at the end.
+	ctxTimeout, cancel := context.WithTimeout(ctx, policy.Timeout)
+	removeCleanup := r.AddCleanup(cancel)
+
+	type result struct {
+		output NodeRet
+		err    error
+	}
+
+	resultChan := make(chan result, 1)
+	running := make(chan struct{})
+
+	go func() {
+		defer close(running)
+		defer func() {
+			if rec := recover(); rec != nil {
+				log.Ctx(ctx).Error().Msgf("panic: %v\n%v", rec, string(debug.Stack()))
+				resultChan <- result{err: fmt.Errorf("panic: %v", rec)}
+			}
+		}()
+
+		output, err := node.Run(ctxTimeout, &r.wgx, r.appStore, value, input)
+		resultChan <- result{output: output, err: err}
+	}()
+
+	select {
+	case res := <-resultChan:
+		removeCleanup()
+
+		return res.output, nil, res.err
+	case <-ctxTimeout.Done():
+		cancel()
+
+		if errors.Is(ctxTimeout.Err(), context.DeadlineExceeded) {
+			return nil, running, fmt.Errorf("%w after %v", ErrNodeTimeout, policy.Timeout)
+		}
+
+		return nil, running, ctxTimeout.Err() //nolint:wrapcheck // no need
+	}
+}
@@ -0,0 +1,164 @@
+package flow
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/rakunlabs/chore/pkg/models"
+	"github.com/rakunlabs/chore/pkg/registry"
+)
+
+func TestParsePolicy(t *testing.T) {
+	tests := []struct {
+		name    string
+		data    map[string]interface{}
+		want    Policy
+		wantErr bool
+	}{
+		{
+			name: "empty",
+			data: map[string]interface{}{},
+			want: Policy{},
+		},
+		{
+			name: "strings",
+			data: map[string]interface{}{
+				"timeout":  "1m30s",
+				"retries":  "3",
+				"backoff":  "2",
+				"retry_on": "timeout, EOF",
+			},
+			want: Policy{
+				Timeout: 90 * time.Second,
+				Retries: 3,
+				Backoff: 2 * time.Second,
+				RetryOn: []string{"timeout", "EOF"},
+			},
+		},
+		{
+			name: "numbers",
+			data: map[string]interface{}{
+				"timeout": 0.5,
+				"retries": float64(1),
+			},
+			want: Policy{
+				Timeout: 500 * time.Millisecond,
+				Retries: 1,
+			},
+		},
+		{
+			name:    "wrong timeout",
+			data:    map[string]interface{}{"timeout": "soon"},
+			wantErr: true,
+		},
+		{
+			name:    "too many retries",
+			data:    map[string]interface{}{"retries": "100"},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParsePolicy(tt.data)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParsePolicy() error = %v, wantErr %v", err, tt.wantErr)
+			}
+
+			if !tt.wantErr && !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ParsePolicy() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPolicyRetry(t *testing.T) {
+	policy := Policy{Backoff: time.Second, RetryOn: []string{"timeout"}}
+
+	if !policy.retryable(ErrNodeTimeout) {
+		t.Error("timeout should be retryable")
+	}
+
+	if policy.retryable(errors.New("bad request")) {
+		t.Error("bad request should not be retryable")
+	}
+
+	for attempt, want := range map[int]time.Duration{1: time.Second, 2: 2 * time.Second, 3: 4 * time.Second, 20: MaxBackoff} {
+		if got := policy.backoff(attempt); got != want {
+			t.Errorf("backoff(%d) = %v, want %v", attempt, got, want)
+		}
+	}
+}
+
+// slowNode ignores the context and counts the parallel runs.
+type slowNode struct {
+	Noder
+	running  *atomic.Int32
+	parallel *atomic.Int32
+	runs     *atomic.Int32
+}
+
+func (slowNode) NodeID() string  { return "1" }
+func (slowNode) GetType() string { return "slow" }
+
+func (n slowNode) Run(_ context.Context, _ *sync.WaitGroup, _ *registry.Registry, _ NodeRet, _ string) (NodeRet, error) {
+	n.runs.Add(1)
+
+	if v := n.running.Add(1); v > n.parallel.Load() {
+		n.parallel.Store(v)
+	}
+
+	defer n.running.Add(-1)
+
+	time.Sleep(50 * time.Millisecond)
+
+	return nil, nil
+}
+
+func TestPolicyRetryTimeout(t *testing.T) {
+	reg := NewNodesReg("", "", "", nil, models.Access{})
+	defer reg.Clear()
+
+	reg.policies["1"] = Policy{Timeout: 10 * time.Millisecond, Retries: 2}
+
+	node := slowNode{running: new(atomic.Int32), parallel: new(atomic.Int32), runs: new(atomic.Int32)}
+
+	if _, err := reg.runNode(context.Background(), node, nil, ""); !errors.Is(err, ErrNodeTimeout) {
+		t.Fatalf("runNode() error = %v, want %v", err, ErrNodeTimeout)
+	}
+
+	if got := node.runs.Load(); got != 3 {
+		t.Errorf("runs = %d, want 3", got)
+	}
+
+	if got := node.parallel.Load(); got != 1 {
+		t.Errorf("parallel runs = %d, want 1", got)
+	}
+}
+
+func TestPolicyTimeoutCleanup(t *testing.T) {
+	reg := NewNodesReg("", "", "", nil, models.Access{})
+	defer reg.Clear()
+
+	reg.policies["1"] = Policy{Timeout: time.Second}
+
+	node := slowNode{running: new(atomic.Int32), parallel: new(atomic.Int32), runs: new(atomic.Int32)}
+
+	for i := 0; i < 3; i++ {
+		if _, err := reg.runNode(context.Background(), node, nil, ""); err != nil {
+			t.Fatalf("runNode() error = %v", err)
+		}
+	}
+
+	reg.mutex.RLock()
+	defer reg.mutex.RUnlock()
+
+	if got := len(reg.cleanup); got != 0 {
+		t.Errorf("cleanup = %d, want 0 after returned attempts", got)
+	}
+}
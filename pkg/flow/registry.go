@@ -2,10 +2,18 @@ package flow
 
 import (
 	"context"
+	"fmt"
+	"io"
+	"os"
 	"strings"
 	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/google/uuid"
 
 	"github.com/rakunlabs/chore/pkg/flow/convert"
+	"github.com/rakunlabs/chore/pkg/models"
 	"github.com/rakunlabs/chore/pkg/registry"
 )
 
@@ -15,6 +23,10 @@ type Respond struct {
 	Data     []byte                 `json:"data"`
 	Status   int                    `json:"status"`
 	IsError  bool                   `json:"-"`
+	// File is the spooled body, Data is empty.
+	File *File `json:"-"`
+	// Reader streams the File to the caller, it is opened before the run removes the file.
+	Reader io.ReadCloser `json:"-"`
 }
 
 type CountStucker uint
@@ -29,12 +41,18 @@ const (
 // NodesReg hold concreate information of nodes and start points.
 type NodesReg struct {
 	appStore          *registry.Registry
+	access            models.Access
 	reg               map[string]Noder
 	respondChan       chan Respond
 	controlName       string
 	startName         string
 	method            string
 	starts            []Starts
+	errorStarts       []Connection
+	policies          map[string]Policy
+	cancel            context.CancelFunc
+	cancelOnce        sync.Once
+	waiting           atomic.Bool
 	mutex             sync.RWMutex
 	wgx               sync.WaitGroup
 	respondChanActive bool
@@ -44,26 +62,42 @@ type NodesReg struct {
 	stuckCount      int64
 	mutexCount      sync.Mutex
 	stuckCtxCancels []context.CancelFunc
-	cleanup         []func()
+	cleanup         []*func()
 	stuckChan       chan bool
+	// run history
+	runID        uuid.UUID
+	parentID     *uuid.UUID
+	startedAt    time.Time
+	historyMutex sync.Mutex
+	historyNodes []models.RunNode
+	// final respond of the run
+	result     *Respond
+	keepResult bool
+	done       chan struct{}
 }
 
-func NewNodesReg(controlName, startName, method string, appStore *registry.Registry) *NodesReg {
+// NewNodesReg returns registry of a run, access limits the records fetched by nodes.
+func NewNodesReg(controlName, startName, method string, appStore *registry.Registry, access models.Access) *NodesReg {
 	return &NodesReg{
+		access:      access,
 		controlName: controlName,
 		startName:   startName,
 		method:      method,
 		reg:         make(map[string]Noder),
+		policies:    make(map[string]Policy),
 		appStore:    appStore,
+		runID:       uuid.New(),
+		done:        make(chan struct{}),
 	}
 }
 
+// GetChan returns respond channel, nil if flow has no respond node.
+// Channel gets only one respond and closed at the end of the flow.
 func (r *NodesReg) GetChan() <-chan Respond {
-	if r.respondChanActive {
-		return r.respondChan
-	}
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
 
-	return nil
+	return r.respondChan
 }
 
 // CancelStucks cancel all stuck context of nodes and clear stuck context list.
@@ -94,20 +128,53 @@ func (r *NodesReg) GetStuctCancel(ctx context.Context) context.Context {
 
 // Clear clear all context.
 func (r *NodesReg) Clear() {
-	for _, cancel := range r.stuckCtxCancels {
+	r.mutex.Lock()
+	cancels, cleanup := r.stuckCtxCancels, r.cleanup
+	r.stuckCtxCancels, r.cleanup = nil, nil
+	r.mutex.Unlock()
+
+	for _, cancel := range cancels {
 		cancel()
 	}
 
-	for _, v := range r.cleanup {
-		v()
+	for _, v := range cleanup {
+		(*v)()
 	}
 }
 
-func (r *NodesReg) AddCleanup(v func()) {
+// AddCleanup calls v at the end of the run, returned function removes v when it is not needed before the end.
+func (r *NodesReg) AddCleanup(v func()) func() {
+	entry := &v
+
 	r.mutex.Lock()
-	defer r.mutex.Unlock()
+	r.cleanup = append(r.cleanup, entry)
+	r.mutex.Unlock()
+
+	return func() {
+		r.mutex.Lock()
+		defer r.mutex.Unlock()
+
+		for i := len(r.cleanup) - 1; i >= 0; i-- {
+			if r.cleanup[i] == entry {
+				r.cleanup = append(r.cleanup[:i], r.cleanup[i+1:]...)
 
-	r.cleanup = append(r.cleanup, v)
+				return
+			}
+		}
+	}
+}
+
+// TempFile creates a file in the temp directory, file is removed at the end of the run.
+func (r *NodesReg) TempFile(pattern string) (*os.File, error) {
+	f, err := os.CreateTemp("", pattern)
+	if err != nil {
+		return nil, fmt.Errorf("cannot create temp file: %w", err)
+	}
+
+	name := f.Name()
+	r.AddCleanup(func() { os.Remove(name) })
+
+	return f, nil
 }
 
 func (r *NodesReg) UpdateStuck(typeCount CountStucker, trigger bool) {
@@ -180,6 +247,10 @@ func (r *NodesReg) Set(number string, node Noder) {
 		}
 	}
 
+	if nodeErrorHandler, ok := node.(NoderErrorHandler); ok && nodeErrorHandler.IsErrorHandler() {
+		r.errorStarts = append(r.errorStarts, Connection{Node: number})
+	}
+
 	r.mutex.Lock()
 	defer r.mutex.Unlock()
 
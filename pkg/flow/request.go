@@ -0,0 +1,105 @@
+package flow
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// CtxRequest holds the incoming call of the run, nested runs get the same request.
+const CtxRequest ContextType = "request"
+
+// TemplateRequestKey is the key of the incoming call in the template values.
+const TemplateRequestKey = "_request"
+
+// Request is the incoming call started the flow.
+// Header keys are lower case and multiple values are joined with comma.
+type Request struct {
+	Method    string            `json:"method"`
+	Host      string            `json:"host"`
+	Path      string            `json:"path"`
+	Query     map[string]string `json:"query"`
+	Header    map[string]string `json:"header"`
+	RemoteIP  string            `json:"remote_ip"`
+	RequestID string            `json:"request_id"`
+	Control   string            `json:"control"`
+	Endpoint  string            `json:"endpoint"`
+	// Files of the multipart/form-data call.
+	Files []File `json:"files"`
+}
+
+// NodeRetRequest using if next node wants to get incoming call.
+type NodeRetRequest interface {
+	GetRequest() *Request
+}
+
+func NewRequest(method string, u *url.URL, header http.Header) *Request {
+	r := &Request{
+		Method: method,
+		Query:  make(map[string]string),
+		Header: make(map[string]string, len(header)),
+	}
+
+	if u != nil {
+		r.Host = u.Host
+		r.Path = u.Path
+
+		for k, v := range u.Query() {
+			r.Query[k] = strings.Join(v, ",")
+		}
+	}
+
+	for k, v := range header {
+		r.Header[strings.ToLower(k)] = strings.Join(v, ", ")
+	}
+
+	return r
+}
+
+// Map returns values with json names for scripts and templates.
+func (r *Request) Map() map[string]interface{} {
+	if r == nil {
+		return nil
+	}
+
+	return map[string]interface{}{
+		"method":     r.Method,
+		"host":       r.Host,
+		"path":       r.Path,
+		"query":      r.Query,
+		"header":     r.Header,
+		"remote_ip":  r.RemoteIP,
+		"request_id": r.RequestID,
+		"control":    r.Control,
+		"endpoint":   r.Endpoint,
+		"files":      filesInfo(r.Files),
+	}
+}
+
+// GetRequest returns the incoming call of the run, nil for schedule and error runs.
+func GetRequest(ctx context.Context) *Request {
+	r, _ := ctx.Value(CtxRequest).(*Request)
+
+	return r
+}
+
+// WithTemplateRequest adds the incoming call to the template values when values are object.
+func WithTemplateRequest(ctx context.Context, values interface{}) interface{} {
+	r := GetRequest(ctx)
+	if r == nil {
+		return values
+	}
+
+	if m, ok := values.(map[string]interface{}); ok {
+		m[TemplateRequestKey] = r.Map()
+
+		return m
+	}
+
+	if values == nil {
+		return map[string]interface{}{TemplateRequestKey: r.Map()}
+	}
+
+	return values
+}
@@ -0,0 +1,133 @@
+package flow
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+
+	"github.com/rakunlabs/chore/pkg/models"
+)
+
+// CtxKeepResult set true to record the final respond of the run for async calls.
+// Only affects the run started with this context, not the runs inside of it.
+const CtxKeepResult ContextType = "keep_result"
+
+// ResultLimit is the max spooled body read to the kept result, bigger ones are recorded as error; -1 for no limit.
+var ResultLimit int64 = 10 << 20
+
+// Done returns a channel closed when the run and its history recording finished.
+func (r *NodesReg) Done() <-chan struct{} {
+	return r.done
+}
+
+// Result returns the final respond of the run, false if run is not finished yet.
+func (r *NodesReg) Result() (Respond, bool) {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	select {
+	case <-r.done:
+	default:
+		return Respond{}, false
+	}
+
+	if r.result == nil {
+		return Respond{}, false
+	}
+
+	return *r.result, true
+}
+
+// Status returns the status of the run as recorded in the history.
+func (r *NodesReg) Status() string {
+	select {
+	case <-r.done:
+		return RunStatus(r.Err())
+	default:
+		return models.RunStatusRunning
+	}
+}
+
+// RunStatus returns the status of the finished run with the error.
+func RunStatus(err error) string {
+	if err == nil {
+		return models.RunStatusSuccess
+	}
+
+	if errors.Is(err, ErrRunCanceled) {
+		return models.RunStatusCanceled
+	}
+
+	return models.RunStatusFailed
+}
+
+// errorsRespond is the respond of the run which not reached a respond node.
+func errorsRespond(errs []error) *Respond {
+	if len(errs) == 0 {
+		return &Respond{
+			Status: http.StatusAccepted,
+			Data:   []byte("Accepted"),
+		}
+	}
+
+	buff := bytes.Buffer{}
+	for _, err := range errs {
+		buff.WriteString("[")
+		buff.WriteString(err.Error())
+		buff.WriteString("]")
+	}
+
+	return &Respond{
+		Data:    buff.Bytes(),
+		IsError: true,
+	}
+}
+
+// keepResultCtx moves the keep result request from context to the run.
+func (r *NodesReg) keepResultCtx(ctx context.Context) context.Context {
+	if keep, _ := ctx.Value(CtxKeepResult).(bool); keep {
+		r.keepResult = true
+
+		return context.WithValue(ctx, CtxKeepResult, false)
+	}
+
+	return ctx
+}
+
+// keepRespondFile reads the spooled body of the kept result, file is removed at the end of the run.
+func keepRespondFile(respond Respond) Respond {
+	if respond.File == nil || respond.File.Path == "" {
+		return respond
+	}
+
+	if ResultLimit >= 0 && respond.File.Size > ResultLimit {
+		return respondFileError(fmt.Errorf("respond body %d bytes is bigger than result limit %d", respond.File.Size, ResultLimit))
+	}
+
+	reader, err := respond.File.Open()
+	if err != nil {
+		return respondFileError(fmt.Errorf("cannot open respond file: %w", err))
+	}
+
+	defer reader.Close()
+
+	data, err := io.ReadAll(reader)
+	if err != nil {
+		return respondFileError(fmt.Errorf("cannot read respond file: %w", err))
+	}
+
+	respond.Data = data
+
+	return respond
+}
+
+func respondFileError(err error) Respond {
+	return Respond{
+		Data:    []byte(err.Error()),
+		Status:  http.StatusInsufficientStorage,
+		IsError: true,
+	}
+}
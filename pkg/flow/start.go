@@ -2,19 +2,29 @@ package flow
 
 import (
 	"context"
+	"errors"
 	"strings"
 	"sync"
 
+	"github.com/google/uuid"
+
+	"github.com/rakunlabs/chore/pkg/models"
 	"github.com/rakunlabs/chore/pkg/registry"
 	"github.com/rs/zerolog/log"
 )
 
+// MethodSchedule is the method name of the schedule triggered runs.
+var MethodSchedule = "SCHEDULE"
+
+// StartFlow runs the endpoint of the control content.
+// Access is the groups of the control, nodes only fetch records readable with it.
 func StartFlow(
 	ctx context.Context,
 	wg *sync.WaitGroup,
 	controlName, endPoint, method string,
 	content []byte,
 	appStore *registry.Registry,
+	access models.Access,
 	value []byte,
 ) (*NodesReg, error) {
 	nodesData, err := ParseData(content)
@@ -27,18 +37,43 @@ func StartFlow(
 	endPoint = strings.TrimSpace(endPoint)
 	method = strings.TrimSpace(method)
 
-	// set new logger for reg and set it in ctx
-	ctx = log.Ctx(ctx).With().Str("control", controlName).Str("endpoint", endPoint).Logger().WithContext(ctx)
-
-	nodesReg, err := DataToNode(ctx, controlName, endPoint, method, nodesData, appStore)
+	nodesReg, err := DataToNode(ctx, controlName, endPoint, method, nodesData, appStore, access)
 	if err != nil {
 		return nil, err
 	}
 
+	// called inside of another run
+	if parentID, ok := ctx.Value(CtxRunID).(uuid.UUID); ok {
+		nodesReg.parentID = &parentID
+	}
+
+	ctx = context.WithValue(ctx, CtxRunID, nodesReg.runID)
+	ctx = nodesReg.keepResultCtx(ctx)
+
+	// set new logger for reg and set it in ctx
+	ctx = log.Ctx(ctx).With().
+		Str("control", controlName).
+		Str("endpoint", endPoint).
+		Str("runID", nodesReg.runID.String()).
+		Logger().WithContext(ctx)
+
+	// cancel with DELETE /run, nodes get contexts in fetch
+	ctx, nodesReg.cancel = context.WithCancel(ctx)
+
 	if err := VisitAndFetch(ctx, nodesReg); err != nil {
+		nodesReg.cancel()
+
+		if !errors.Is(err, ErrEndpointNotFound) {
+			nodesReg.historyFailed(ctx, err)
+		}
+
 		return nil, err
 	}
 
+	// run is visible with its id before returning
+	nodesReg.historyStart(ctx)
+	ActiveRuns.add(nodesReg)
+
 	wg.Add(1)
 	go GoAndRun(ctx, wg, nodesReg, value)
 
@@ -0,0 +1,416 @@
+package flow
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	"gorm.io/gorm"
+
+	"github.com/rakunlabs/chore/pkg/models"
+	"github.com/rakunlabs/chore/pkg/registry"
+)
+
+// NodeTypesView hold nodes only used in the UI, skipped in the flow.
+var NodeTypesView = map[string]struct{}{
+	"note": {},
+}
+
+// Reference types of the stored records.
+const (
+	ReferenceTemplate = "template"
+	ReferenceAuth     = "auth"
+	ReferenceControl  = "control"
+	ReferenceSettings = "settings"
+)
+
+// Reference to a stored record used by a node.
+type Reference struct {
+	Type string
+	// Namespace is for settings like oauth2, email.
+	Namespace string
+	Name      string
+	// Field is the key of the name in the node data, empty for fixed names.
+	Field string
+}
+
+func (r Reference) String() string {
+	if r.Namespace != "" {
+		return fmt.Sprintf("%s %s/%s", r.Type, r.Namespace, r.Name)
+	}
+
+	return fmt.Sprintf("%s %s", r.Type, r.Name)
+}
+
+// NoderReference for nodes depend on stored records like template, auth.
+type NoderReference interface {
+	References() []Reference
+}
+
+// Issue is a finding of the validation.
+type Issue struct {
+	NodeID  string `json:"node_id,omitempty"`
+	Node    string `json:"node,omitempty"`
+	Message string `json:"message"`
+}
+
+// Validation result of the control content.
+// Errors breaks the flow in call time, warnings just informative.
+type Validation struct {
+	Errors   []Issue `json:"errors"`
+	Warnings []Issue `json:"warnings"`
+}
+
+func (v *Validation) HasError() bool {
+	return len(v.Errors) > 0
+}
+
+// Err returns joined errors of the validation.
+func (v *Validation) Err() error {
+	errs := make([]error, 0, len(v.Errors))
+	for _, issue := range v.Errors {
+		errs = append(errs, errors.New(issue.String()))
+	}
+
+	return errors.Join(errs...)
+}
+
+func (i Issue) String() string {
+	if i.NodeID == "" {
+		return i.Message
+	}
+
+	return fmt.Sprintf("node %s [%s]: %s", i.NodeID, i.Node, i.Message)
+}
+
+func (v *Validation) addError(nodeID, node, format string, a ...interface{}) {
+	v.Errors = append(v.Errors, Issue{NodeID: nodeID, Node: node, Message: fmt.Sprintf(format, a...)})
+}
+
+func (v *Validation) addWarning(nodeID, node, format string, a ...interface{}) {
+	v.Warnings = append(v.Warnings, Issue{NodeID: nodeID, Node: node, Message: fmt.Sprintf(format, a...)})
+}
+
+// Validate checks the control content without running it.
+// References checked in the store if appStore has DB, only records readable with access of the control are found.
+func Validate(ctx context.Context, content []byte, appStore *registry.Registry, access models.Access) Validation {
+	v := Validation{
+		Errors:   []Issue{},
+		Warnings: []Issue{},
+	}
+
+	datas, err := ParseData(content)
+	if err != nil {
+		v.addError("", "", "%v", err)
+
+		return v
+	}
+
+	nodeIDs := make([]string, 0, len(datas))
+	for nodeID := range datas {
+		nodeIDs = append(nodeIDs, nodeID)
+	}
+
+	sort.Strings(nodeIDs)
+
+	reg := NewNodesReg("", "", "", appStore, access)
+	defer reg.Clear()
+
+	nodes := make(map[string]Noder, len(datas))
+	starts := []string{}
+	errorStarts := []string{}
+
+	for _, nodeID := range nodeIDs {
+		data := datas[nodeID]
+
+		if _, ok := NodeTypesView[data.Name]; ok {
+			continue
+		}
+
+		createFunc := NodeTypes[data.Name]
+		if createFunc == nil {
+			v.addError(nodeID, data.Name, "unknown node type")
+
+			continue
+		}
+
+		node, err := createFunc(ctx, reg, data, nodeID)
+		if err != nil {
+			v.addError(nodeID, data.Name, "%v", err)
+
+			continue
+		}
+
+		nodes[nodeID] = node
+
+		if err := node.Validate(ctx); err != nil {
+			v.addError(nodeID, data.Name, "%v", err)
+		}
+
+		if _, err := ParsePolicy(data.Data); err != nil {
+			v.addError(nodeID, data.Name, "%v", err)
+		}
+
+		if nodeEndpoint, ok := node.(NoderEndpoint); ok && nodeEndpoint.Endpoint() != "" {
+			starts = append(starts, nodeID)
+		}
+
+		if nodeErrorHandler, ok := node.(NoderErrorHandler); ok && nodeErrorHandler.IsErrorHandler() {
+			errorStarts = append(errorStarts, nodeID)
+		}
+
+		if nodeReference, ok := node.(NoderReference); ok && appStore != nil && appStore.DB != nil {
+			for _, ref := range nodeReference.References() {
+				// record can be added later like in import or restore, fetch fails in call time
+				found, err := checkReference(ctx, appStore.DB, access, ref)
+				if err != nil {
+					v.addError(nodeID, data.Name, "%v", err)
+				} else if !found {
+					v.addWarning(nodeID, data.Name, "%s not found", ref)
+				}
+			}
+		}
+
+		validateConnections(&v, nodeID, data, datas)
+	}
+
+	if len(starts) == 0 {
+		v.addWarning("", "", "no start node, control cannot be called")
+	}
+
+	// unreachable nodes
+	reachable := visit(append(starts, errorStarts...), nodes)
+	for _, nodeID := range nodeIDs {
+		if _, ok := nodes[nodeID]; !ok {
+			continue
+		}
+
+		if _, ok := reachable[nodeID]; !ok {
+			v.addWarning(nodeID, datas[nodeID].Name, "node is not reachable from any start node")
+		}
+	}
+
+	// respond nodes of each endpoint
+	for _, path := range endpointPaths(starts, nodes) {
+		responds := []string{}
+		for nodeID := range visit(path.starts, nodes) {
+			if nodes[nodeID].IsRespond() {
+				responds = append(responds, nodeID)
+			}
+		}
+
+		if len(responds) > 1 {
+			sort.Strings(responds)
+			v.addWarning("", "",
+				"endpoint %s [%s] has more than one respond node %s, only first respond returns",
+				path.endpoint, path.method, strings.Join(responds, ","),
+			)
+		}
+	}
+
+	return v
+}
+
+// validateConnections checks both sides of the connections exist.
+func validateConnections(v *Validation, nodeID string, data NodeData, datas NodesData) {
+	outputNames := make([]string, 0, len(data.Outputs))
+	for name := range data.Outputs {
+		outputNames = append(outputNames, name)
+	}
+
+	sort.Strings(outputNames)
+
+	for _, outputName := range outputNames {
+		for _, conn := range data.Outputs[outputName].Connections {
+			target, ok := datas[conn.Node]
+			if !ok {
+				v.addError(nodeID, data.Name, "%s connected to missing node %s", outputName, conn.Node)
+
+				continue
+			}
+
+			if !hasConnection(target.Inputs[conn.Output].Connections, nodeID) {
+				v.addWarning(nodeID, data.Name, "%s connection is not in inputs of node %s", outputName, conn.Node)
+			}
+		}
+	}
+
+	inputNames := make([]string, 0, len(data.Inputs))
+	for name := range data.Inputs {
+		inputNames = append(inputNames, name)
+	}
+
+	sort.Strings(inputNames)
+
+	for _, inputName := range inputNames {
+		for _, conn := range data.Inputs[inputName].Connections {
+			if _, ok := datas[conn.Node]; !ok {
+				v.addError(nodeID, data.Name, "%s connected from missing node %s", inputName, conn.Node)
+			}
+		}
+	}
+}
+
+func hasConnection(conns []Connection, nodeID string) bool {
+	for _, conn := range conns {
+		if conn.Node == nodeID {
+			return true
+		}
+	}
+
+	return false
+}
+
+// visit returns all nodes reachable from the starts.
+func visit(starts []string, nodes map[string]Noder) map[string]struct{} {
+	visited := make(map[string]struct{}, len(nodes))
+	queue := append([]string{}, starts...)
+
+	for len(queue) > 0 {
+		nodeID := queue[0]
+		queue = queue[1:]
+
+		if _, ok := visited[nodeID]; ok {
+			continue
+		}
+
+		node, ok := nodes[nodeID]
+		if !ok {
+			continue
+		}
+
+		visited[nodeID] = struct{}{}
+
+		for i := 0; i < node.NextCount(); i++ {
+			for _, conn := range node.Next(i) {
+				queue = append(queue, conn.Node)
+			}
+		}
+	}
+
+	return visited
+}
+
+type endpointPath struct {
+	endpoint string
+	method   string
+	starts   []string
+}
+
+// endpointPaths groups start nodes with same endpoint and method.
+func endpointPaths(starts []string, nodes map[string]Noder) []endpointPath {
+	paths := []endpointPath{}
+	index := map[string]int{}
+
+	for _, nodeID := range starts {
+		nodeEndpoint, _ := nodes[nodeID].(NoderEndpoint)
+		for _, method := range nodeEndpoint.Methods() {
+			method = strings.ToUpper(strings.TrimSpace(method))
+			key := nodeEndpoint.Endpoint() + " " + method
+
+			i, ok := index[key]
+			if !ok {
+				i = len(paths)
+				index[key] = i
+				paths = append(paths, endpointPath{endpoint: nodeEndpoint.Endpoint(), method: method})
+			}
+
+			paths[i].starts = append(paths[i].starts, nodeID)
+		}
+	}
+
+	return paths
+}
+
+// checkReference returns false if the record is not readable with access.
+func checkReference(ctx context.Context, db *gorm.DB, access models.Access, ref Reference) (bool, error) {
+	query := db.WithContext(ctx).Scopes(access.Scope(false))
+
+	switch ref.Type {
+	case ReferenceTemplate:
+		query = query.Model(&models.Template{})
+	case ReferenceAuth:
+		query = query.Model(&models.Auth{})
+	case ReferenceControl:
+		query = query.Model(&models.Control{})
+	case ReferenceSettings:
+		query = query.Model(&models.Settings{}).Where("namespace = ?", ref.Namespace)
+	default:
+		return false, fmt.Errorf("unknown reference type %s", ref.Type)
+	}
+
+	var count int64
+	if result := query.Where("name = ?", ref.Name).Count(&count); result.Error != nil {
+		return false, fmt.Errorf("cannot check %s: %w", ref, result.Error)
+	}
+
+	return count > 0, nil
+}
+
+// RenameReferences changes names of the referenced records in the content.
+// rename returns the new name of the reference, fixed names without Field are kept.
+func RenameReferences(ctx context.Context, content []byte, rename func(ref Reference) (string, bool)) ([]byte, bool, error) {
+	datas, err := ParseData(content)
+	if err != nil {
+		return nil, false, err
+	}
+
+	// keep other fields of the nodes like positions
+	var raw map[string]map[string]interface{}
+	if err := json.Unmarshal(content, &raw); err != nil {
+		return nil, false, fmt.Errorf("cannot unmarshal content: %w", err)
+	}
+
+	reg := NewNodesReg("", "", "", nil, models.Access{})
+	defer reg.Clear()
+
+	changed := false
+
+	for nodeID, data := range datas {
+		createFunc := NodeTypes[data.Name]
+		if createFunc == nil {
+			continue
+		}
+
+		// broken nodes left to the validation
+		node, err := createFunc(ctx, reg, data, nodeID)
+		if err != nil {
+			continue
+		}
+
+		nodeReference, ok := node.(NoderReference)
+		if !ok {
+			continue
+		}
+
+		nodeData, _ := raw[nodeID]["data"].(map[string]interface{})
+		if nodeData == nil {
+			continue
+		}
+
+		for _, ref := range nodeReference.References() {
+			if ref.Field == "" {
+				continue
+			}
+
+			if name, ok := rename(ref); ok {
+				nodeData[ref.Field] = name
+				changed = true
+			}
+		}
+	}
+
+	if !changed {
+		return content, false, nil
+	}
+
+	newContent, err := json.Marshal(raw)
+	if err != nil {
+		return nil, false, fmt.Errorf("cannot marshal content: %w", err)
+	}
+
+	return newContent, true, nil
+}
@@ -0,0 +1,135 @@
+package flow_test
+
+import (
+	"context"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/rakunlabs/chore/pkg/flow"
+	_ "github.com/rakunlabs/chore/pkg/flow/nodes"
+	"github.com/rakunlabs/chore/pkg/models"
+)
+
+func TestValidate(t *testing.T) {
+	tests := []struct {
+		name     string
+		content  string
+		errors   []flow.Issue
+		warnings []flow.Issue
+	}{
+		{
+			name: "valid",
+			content: `{
+				"1": {"name": "endpoint", "data": {"endpoint": "test", "methods": "POST"}, "outputs": {"output_1": {"connections": [{"node": "2", "output": "input_1"}]}}},
+				"2": {"name": "respond", "data": {}, "inputs": {"input_1": {"connections": [{"node": "1", "input": "output_1"}]}}},
+				"3": {"name": "note", "data": {"note": "just for view"}}
+			}`,
+			errors:   []flow.Issue{},
+			warnings: []flow.Issue{},
+		},
+		{
+			name:    "not json",
+			content: `{`,
+			errors: []flow.Issue{
+				{Message: "parsedata cannot unmarshal: unexpected end of JSON input"},
+			},
+			warnings: []flow.Issue{},
+		},
+		{
+			name: "broken",
+			content: `{
+				"1": {"name": "endpoint", "data": {"endpoint": "test", "methods": "POST"}, "outputs": {"output_1": {"connections": [{"node": "2", "output": "input_1"}, {"node": "3", "output": "input_1"}, {"node": "9", "output": "input_1"}]}}},
+				"2": {"name": "respond", "data": {}, "inputs": {"input_1": {"connections": [{"node": "1", "input": "output_1"}]}}},
+				"3": {"name": "respond", "data": {}},
+				"4": {"name": "template", "data": {}},
+				"5": {"name": "unknown", "data": {}}
+			}`,
+			errors: []flow.Issue{
+				{NodeID: "1", Node: "endpoint", Message: "output_1 connected to missing node 9"},
+				{NodeID: "4", Node: "template", Message: "template is empty"},
+				{NodeID: "5", Node: "unknown", Message: "unknown node type"},
+			},
+			warnings: []flow.Issue{
+				{NodeID: "1", Node: "endpoint", Message: "output_1 connection is not in inputs of node 3"},
+				{NodeID: "4", Node: "template", Message: "node is not reachable from any start node"},
+				{Message: "endpoint test [POST] has more than one respond node 2,3, only first respond returns"},
+			},
+		},
+		{
+			name: "no start",
+			content: `{
+				"1": {"name": "log", "data": {}}
+			}`,
+			errors: []flow.Issue{},
+			warnings: []flow.Issue{
+				{Message: "no start node, control cannot be called"},
+				{NodeID: "1", Node: "log", Message: "node is not reachable from any start node"},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := flow.Validate(context.Background(), []byte(tt.content), nil, models.Access{})
+			if !reflect.DeepEqual(got.Errors, tt.errors) {
+				t.Errorf("Validate() errors = %v, want %v", got.Errors, tt.errors)
+			}
+
+			if !reflect.DeepEqual(got.Warnings, tt.warnings) {
+				t.Errorf("Validate() warnings = %v, want %v", got.Warnings, tt.warnings)
+			}
+		})
+	}
+}
+
+func TestRenameReferences(t *testing.T) {
+	content := `{
+		"1": {"name": "template", "data": {"template": "t1"}, "pos_x": 10},
+		"2": {"name": "request", "data": {"url": "http://localhost", "method": "POST", "auth": "a1", "tls": "x"}},
+		"3": {"name": "control", "data": {"control": "other"}},
+		"4": {"name": "note", "data": {"note": "t1"}}
+	}`
+
+	renamed := map[flow.Reference]string{
+		{Type: flow.ReferenceTemplate, Name: "t1"}:                     "t1-1",
+		{Type: flow.ReferenceAuth, Name: "a1"}:                         "a1-1",
+		{Type: flow.ReferenceSettings, Namespace: "oauth2", Name: "x"}: "x-1",
+	}
+
+	got, changed, err := flow.RenameReferences(context.Background(), []byte(content), func(ref flow.Reference) (string, bool) {
+		ref.Field = ""
+		name, ok := renamed[ref]
+
+		return name, ok
+	})
+	if err != nil {
+		t.Fatalf("RenameReferences() error = %v", err)
+	}
+
+	if !changed {
+		t.Fatal("RenameReferences() not changed")
+	}
+
+	datas, err := flow.ParseData(got)
+	if err != nil {
+		t.Fatalf("ParseData() error = %v", err)
+	}
+
+	want := map[string]map[string]interface{}{
+		"1": {"template": "t1-1"},
+		"2": {"url": "http://localhost", "method": "POST", "auth": "a1-1", "tls": "x"},
+		"3": {"control": "other"},
+		"4": {"note": "t1"},
+	}
+
+	for nodeID, data := range want {
+		if !reflect.DeepEqual(datas[nodeID].Data, data) {
+			t.Errorf("node %s data = %v, want %v", nodeID, datas[nodeID].Data, data)
+		}
+	}
+
+	if !strings.Contains(string(got), `"pos_x":10`) {
+		t.Errorf("RenameReferences() lost node fields: %s", got)
+	}
+}
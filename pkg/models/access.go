@@ -0,0 +1,137 @@
+package models
+
+import (
+	"encoding/json"
+	"strings"
+
+	"gorm.io/datatypes"
+	"gorm.io/gorm"
+)
+
+// GroupReadSuffix gives only read access to group, like "team1:read".
+var GroupReadSuffix = ":read"
+
+// Access holds groups of the requester to check resource groups.
+//
+// Resource without groups is open to everyone, "group" gives read and write access,
+// "group:read" gives only read access.
+type Access struct {
+	// All is true for admin and noop authentication.
+	All    bool
+	Groups []string
+}
+
+// allowed returns resource group values which give the access.
+func (a Access) allowed(write bool) []string {
+	values := make([]string, 0, len(a.Groups)*2)
+	for _, g := range a.Groups {
+		values = append(values, g)
+		if !write {
+			values = append(values, g+GroupReadSuffix)
+		}
+	}
+
+	return values
+}
+
+// ResourceAccess returns the access of a resource with its own groups, like a control reading templates and auths in the run.
+// Read only groups are not used so resource without write groups only reaches open records.
+func ResourceAccess(groups datatypes.JSON) Access {
+	access := Access{}
+	for _, g := range groupNames(groups) {
+		if g != "" && !strings.HasSuffix(g, GroupReadSuffix) {
+			access.Groups = append(access.Groups, g)
+		}
+	}
+
+	return access
+}
+
+// CanAssign checks the requester is member of the groups given to a resource, admin can give any group.
+// Groups already in the current groups of the resource are kept without membership.
+func (a Access) CanAssign(groups, current datatypes.JSON) bool {
+	if a.All {
+		return true
+	}
+
+	currentGroups := groupNames(current)
+
+	for _, g := range groupNames(groups) {
+		if contains(currentGroups, g) || contains(a.Groups, strings.TrimSuffix(g, GroupReadSuffix)) {
+			continue
+		}
+
+		return false
+	}
+
+	return true
+}
+
+// Can checks the resource groups.
+func (a Access) Can(groups datatypes.JSON, write bool) bool {
+	if a.All {
+		return true
+	}
+
+	resourceGroups := groupNames(groups)
+	if len(resourceGroups) == 0 {
+		return true
+	}
+
+	allowed := a.allowed(write)
+	for _, g := range resourceGroups {
+		if contains(allowed, g) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func groupNames(groups datatypes.JSON) []string {
+	var names []string
+	if len(groups) > 0 {
+		_ = json.Unmarshal(groups, &names)
+	}
+
+	return names
+}
+
+func contains(values []string, v string) bool {
+	for _, value := range values {
+		if value == v {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Scope filters records of the model with groups column in the database.
+func (a Access) Scope(write bool) func(*gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		if a.All {
+			return db
+		}
+
+		allowed := a.allowed(write)
+		if len(allowed) == 0 {
+			// IN with empty list is not valid in every database
+			allowed = []string{""}
+		}
+
+		if db.Dialector.Name() == "sqlite" {
+			return db.Where(
+				`CASE WHEN "groups" IS NULL OR json_type("groups") <> 'array' OR json_array_length("groups") = 0 THEN TRUE `+
+					`ELSE EXISTS (SELECT 1 FROM json_each("groups") WHERE json_each.value IN ?) END`,
+				allowed,
+			)
+		}
+
+		return db.Where(
+			`CASE WHEN "groups" IS NULL OR jsonb_typeof("groups") <> 'array' OR jsonb_array_length("groups") = 0 THEN TRUE `+
+				`ELSE EXISTS (SELECT 1 FROM jsonb_array_elements_text("groups") AS g WHERE g IN ?) END`,
+			allowed,
+		)
+	}
+}
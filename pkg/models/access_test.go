@@ -0,0 +1,138 @@
+package models
+
+import (
+	"testing"
+
+	"gorm.io/datatypes"
+)
+
+func TestAccessCan(t *testing.T) {
+	tests := []struct {
+		name   string
+		access Access
+		groups datatypes.JSON
+		write  bool
+		want   bool
+	}{
+		{
+			name:   "admin",
+			access: Access{All: true},
+			groups: datatypes.JSON(`["team1"]`),
+			write:  true,
+			want:   true,
+		},
+		{
+			name:   "empty groups",
+			access: Access{Groups: []string{"team2"}},
+			groups: datatypes.JSON(`[]`),
+			write:  true,
+			want:   true,
+		},
+		{
+			name:   "null groups",
+			access: Access{},
+			groups: datatypes.JSON(`null`),
+			write:  true,
+			want:   true,
+		},
+		{
+			name:   "member write",
+			access: Access{Groups: []string{"user", "team1"}},
+			groups: datatypes.JSON(`["team1"]`),
+			write:  true,
+			want:   true,
+		},
+		{
+			name:   "read only group read",
+			access: Access{Groups: []string{"team1"}},
+			groups: datatypes.JSON(`["team1:read"]`),
+			want:   true,
+		},
+		{
+			name:   "read only group write",
+			access: Access{Groups: []string{"team1"}},
+			groups: datatypes.JSON(`["team1:read"]`),
+			write:  true,
+			want:   false,
+		},
+		{
+			name:   "other group",
+			access: Access{Groups: []string{"team2"}},
+			groups: datatypes.JSON(`["team1"]`),
+			want:   false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.access.Can(tt.groups, tt.write); got != tt.want {
+				t.Errorf("Access.Can() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestAccessCanAssign(t *testing.T) {
+	tests := []struct {
+		name    string
+		access  Access
+		groups  datatypes.JSON
+		current datatypes.JSON
+		want    bool
+	}{
+		{
+			name:   "admin",
+			access: Access{All: true},
+			groups: datatypes.JSON(`["team2"]`),
+			want:   true,
+		},
+		{
+			name:   "no groups",
+			access: Access{},
+			groups: datatypes.JSON(`null`),
+			want:   true,
+		},
+		{
+			name:   "member",
+			access: Access{Groups: []string{"team1"}},
+			groups: datatypes.JSON(`["team1", "team1:read"]`),
+			want:   true,
+		},
+		{
+			name:   "not member",
+			access: Access{Groups: []string{"team1"}},
+			groups: datatypes.JSON(`["team1", "team2"]`),
+			want:   false,
+		},
+		{
+			name:   "not member read",
+			access: Access{Groups: []string{"team1"}},
+			groups: datatypes.JSON(`["team2:read"]`),
+			want:   false,
+		},
+		{
+			name:    "kept group",
+			access:  Access{Groups: []string{"team1"}},
+			groups:  datatypes.JSON(`["team1", "team2"]`),
+			current: datatypes.JSON(`["team2"]`),
+			want:    true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.access.CanAssign(tt.groups, tt.current); got != tt.want {
+				t.Errorf("Access.CanAssign() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestResourceAccess(t *testing.T) {
+	got := ResourceAccess(datatypes.JSON(`["team1", "team2:read", ""]`))
+	if got.All || len(got.Groups) != 1 || got.Groups[0] != "team1" {
+		t.Errorf("ResourceAccess() = %v, want team1", got)
+	}
+
+	if got := ResourceAccess(nil); got.All || len(got.Groups) != 0 {
+		t.Errorf("ResourceAccess() = %v, want no groups", got)
+	}
+}
@@ -7,4 +7,6 @@ var (
 	ErrRequiredName   = errors.New("name is required")
 	ErrRequiredIDName = errors.New("required at leats one of id or name")
 	ErrNotFound       = errors.New("not found any releated data")
+	ErrForbidden      = errors.New("not allowed to access with user groups")
+	ErrGroupAssign    = errors.New("not allowed to set groups without membership")
 )
@@ -0,0 +1,17 @@
+package models
+
+import "time"
+
+// BundleVersion is the format version of the export bundle.
+var BundleVersion = 1
+
+// Bundle holds exported records to move between chore instances.
+type Bundle struct {
+	Version   int                  `json:"version" example:"1"`
+	CreatedAt time.Time            `json:"created_at"`
+	Controls  []ControlPureContent `json:"controls,omitempty"`
+	Templates []TemplatePure       `json:"templates,omitempty"`
+	Folders   []FolderPure         `json:"folders,omitempty"`
+	Auths     []AuthPure           `json:"auths,omitempty"`
+	Settings  []SettingsPure       `json:"settings,omitempty"`
+}
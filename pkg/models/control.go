@@ -10,6 +10,12 @@ import (
 type ControlEndpoint struct {
 	Methods []string `json:"methods"`
 	Public  bool     `json:"public"`
+	// Queue runs the calls with the job queue.
+	Queue bool `json:"queue"`
+	// RateLimit of the endpoint for all callers.
+	RateLimit *RateLimit `json:"rate_limit,omitempty"`
+	// Signature verifies the webhook calls, verified calls not need a token.
+	Signature *EndpointSignature `json:"signature,omitempty"`
 }
 
 type ControlPure struct {
@@ -29,6 +35,8 @@ type ControlPureContent struct {
 
 type Control struct {
 	ControlPureContent
+	// Author is the subject of the token which changed the control last.
+	Author string `json:"author" example:"cf8a07d4-077e-402e-a46b-ac0ed50989ec"`
 	apimodels.ModelCU
 }
 
@@ -0,0 +1,45 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/datatypes"
+
+	"github.com/rakunlabs/chore/pkg/models/apimodels"
+)
+
+var (
+	JobStatusPending = "pending"
+	JobStatusRunning = "running"
+	JobStatusDone    = "done"
+	JobStatusFailed  = "failed"
+)
+
+type JobPure struct {
+	Control  string         `json:"control" gorm:"index" example:"deepcore"`
+	Endpoint string         `json:"endpoint" example:"create"`
+	Method   string         `json:"method" example:"POST"`
+	Header   datatypes.JSON `json:"header" swaggertype:"object"`
+	// URL and RemoteIP of the call for the request values of the flow.
+	URL         string `json:"url" example:"/api/v1/send?control=deepcore&endpoint=create"`
+	RemoteIP    string `json:"remote_ip" example:"10.0.0.1"`
+	Body        []byte `json:"-"`
+	Status      string `json:"status" gorm:"index" example:"pending"`
+	Attempts    int    `json:"attempts" example:"1"`
+	MaxAttempts int    `json:"max_attempts" example:"3"`
+	Error       string `json:"error" example:"template cannot render"`
+	// RunID is the last run of the job.
+	RunID *uuid.UUID `json:"run_id" gorm:"type:uuid" example:"cf8a07d4-077e-402e-a46b-ac0ed50989ec"`
+	// RunAt is the time of the next attempt.
+	RunAt       time.Time  `json:"run_at" gorm:"index"`
+	LockID      *uuid.UUID `json:"-" gorm:"type:uuid"`
+	LockedUntil *time.Time `json:"locked_until"`
+	CreatedAt   time.Time  `json:"created_at"`
+	FinishedAt  *time.Time `json:"finished_at"`
+}
+
+type Job struct {
+	JobPure
+	apimodels.ID
+}
@@ -0,0 +1,14 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Lease is held by one instance until LockedUntil, owner extends it while running.
+type Lease struct {
+	Name        string    `gorm:"primaryKey"`
+	Owner       uuid.UUID `gorm:"type:uuid"`
+	LockedUntil time.Time
+}
@@ -0,0 +1,12 @@
+package models
+
+// RateLimit is a token bucket, rate is requests per second and burst is the bucket size.
+type RateLimit struct {
+	Rate  float64 `json:"rate" example:"5"`
+	Burst int     `json:"burst" example:"10"`
+}
+
+// Enabled reports the limit is set, zero rate is unlimited.
+func (r RateLimit) Enabled() bool {
+	return r.Rate > 0
+}
@@ -0,0 +1,34 @@
+package models
+
+import (
+	"github.com/rakunlabs/chore/pkg/models/apimodels"
+)
+
+type ControlRevisionPure struct {
+	// Control is the name of the control.
+	Control string `json:"control" gorm:"index;not null" example:"deepcore"`
+	Content string `json:"content,omitempty" swaggertype:"string" format:"base64" example:"aGVsbG8ge3submFtZX19Cg=="`
+	Endpoints
+	apimodels.Groups
+	// Author is the subject of the token which changed the control.
+	Author string `json:"author" example:"cf8a07d4-077e-402e-a46b-ac0ed50989ec"`
+}
+
+type ControlRevision struct {
+	ControlRevisionPure
+	apimodels.ModelC
+}
+
+type TemplateRevisionPure struct {
+	// Template is the name of the template.
+	Template string `json:"template" gorm:"index;not null" example:"deepcore/template1"`
+	Content  string `json:"content,omitempty" swaggertype:"string" format:"base64" example:"aGVsbG8ge3submFtZX19Cg=="`
+	apimodels.Groups
+	// Author is the subject of the token which changed the template.
+	Author string `json:"author" example:"cf8a07d4-077e-402e-a46b-ac0ed50989ec"`
+}
+
+type TemplateRevision struct {
+	TemplateRevisionPure
+	apimodels.ModelC
+}
@@ -0,0 +1,62 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/datatypes"
+
+	"github.com/rakunlabs/chore/pkg/models/apimodels"
+)
+
+var (
+	RunStatusRunning  = "running"
+	RunStatusSuccess  = "success"
+	RunStatusFailed   = "failed"
+	RunStatusCanceled = "canceled"
+)
+
+type RunPure struct {
+	Control    string     `json:"control" gorm:"index" example:"deepcore"`
+	Endpoint   string     `json:"endpoint" gorm:"index" example:"create"`
+	Method     string     `json:"method" example:"POST"`
+	Status     string     `json:"status" gorm:"index" example:"success"`
+	Error      string     `json:"error" example:"template cannot render"`
+	ParentID   *uuid.UUID `json:"parent_id" gorm:"type:uuid" example:"cf8a07d4-077e-402e-a46b-ac0ed50989ec"`
+	StartedAt  time.Time  `json:"started_at" gorm:"index"`
+	FinishedAt *time.Time `json:"finished_at"`
+	// Duration in milliseconds.
+	Duration int64 `json:"duration" example:"120"`
+}
+
+// RunResult is the final respond of the run, only kept for async calls.
+type RunResult struct {
+	ResultStatus int            `json:"-"`
+	ResultHeader datatypes.JSON `json:"-"`
+	ResultError  bool           `json:"-"`
+	Result       []byte         `json:"-"`
+}
+
+type Run struct {
+	RunPure
+	RunResult
+	apimodels.ID
+}
+
+type RunNodePure struct {
+	RunID     uuid.UUID      `json:"run_id" gorm:"index;type:uuid;not null" example:"cf8a07d4-077e-402e-a46b-ac0ed50989ec"`
+	NodeID    string         `json:"node_id" example:"3"`
+	Type      string         `json:"type" example:"request"`
+	Input     string         `json:"input" example:"name: deepcore"`
+	Output    string         `json:"output" example:"{\"id\":\"1\"}"`
+	Selection datatypes.JSON `json:"selection" swaggertype:"array,integer"`
+	Error     string         `json:"error" example:"failed to send request"`
+	StartedAt time.Time      `json:"started_at"`
+	// Duration in milliseconds.
+	Duration int64 `json:"duration" example:"12"`
+}
+
+type RunNode struct {
+	RunNodePure
+	apimodels.ID
+}
@@ -5,6 +5,12 @@ import (
 	"gorm.io/datatypes"
 )
 
+// SettingsTLS is the settings namespace of client certificates and CA bundles of the request node.
+const SettingsTLS = "tls"
+
+// SettingsSigner is the settings namespace of the request signers like basic, hmac and sigv4.
+const SettingsSigner = "signer"
+
 type Email struct {
 	Host     string `json:"host"`
 	Email    string `json:"email"`
@@ -17,6 +23,8 @@ type SettingsPure struct {
 	Name      string            `json:"name" gorm:"uniqueIndex:idx_name_namespace" example:"email-1"`
 	Namespace string            `json:"namespace" gorm:"uniqueIndex:idx_name_namespace;not null" example:"email"`
 	Data      datatypes.JSONMap `json:"data" swaggertype:"object,string"`
+	// Groups limits the controls using the settings in the run.
+	apimodels.Groups
 }
 
 type Settings struct {
@@ -0,0 +1,23 @@
+package models
+
+// SettingsWebhook is the settings namespace of the webhook secrets, data has the secret key.
+const SettingsWebhook = "webhook"
+
+// EndpointSignature is the verification of the incoming webhook calls.
+type EndpointSignature struct {
+	// Preset is github, gitlab, stripe or slack, other values override it.
+	Preset string `json:"preset,omitempty" example:"github"`
+	// Secret is the settings name in the webhook namespace.
+	Secret string `json:"secret" example:"github-repo"`
+	// Type is hmac or token.
+	Type     string `json:"type,omitempty"`
+	Header   string `json:"header,omitempty" example:"X-Hub-Signature-256"`
+	Prefix   string `json:"prefix,omitempty" example:"sha256="`
+	Encoding string `json:"encoding,omitempty" example:"hex"`
+	// TimestampHeader enables replay protection.
+	TimestampHeader string `json:"timestamp_header,omitempty"`
+	// Payload is the signed content with {timestamp} and {body}.
+	Payload string `json:"payload,omitempty"`
+	// Tolerance is the max age of the timestamp, like 5m.
+	Tolerance string `json:"tolerance,omitempty"`
+}
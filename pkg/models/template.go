@@ -12,6 +12,8 @@ type TemplatePure struct {
 
 type Template struct {
 	TemplatePure
+	// Author is the subject of the token which changed the template last.
+	Author string `json:"author" example:"cf8a07d4-077e-402e-a46b-ac0ed50989ec"`
 	apimodels.ModelCU
 }
 
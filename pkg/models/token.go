@@ -16,6 +16,8 @@ var (
 type TokenData struct {
 	Name string     `json:"name" example:"mytoken" gorm:"not null;default:null"`
 	Date *time.Time `json:"date" example:"2021-02-18T21:54:42.123Z"`
+	// RateLimit of the personal access token on /send.
+	RateLimit RateLimit `json:"rate_limit" gorm:"embedded;embeddedPrefix:rate_limit_"`
 	apimodels.Groups
 }
 
@@ -0,0 +1,399 @@
+package queue
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/rs/zerolog/log"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"github.com/rakunlabs/chore/pkg/flow"
+	"github.com/rakunlabs/chore/pkg/models"
+	"github.com/rakunlabs/chore/pkg/registry"
+)
+
+// MaxBackoff is the upper limit of the wait between attempts.
+var MaxBackoff = time.Hour
+
+var errLeaseExpired = errors.New("lease expired, worker stopped")
+
+var GlobalQueue *Queue
+
+type Config struct {
+	Workers      int
+	MaxAttempts  int
+	Backoff      time.Duration
+	Lease        time.Duration
+	PollInterval time.Duration
+}
+
+// Queue runs jobs in the database with workers, claimed jobs are locked with a lease.
+type Queue struct {
+	appStore *registry.Registry
+	ctx      context.Context //nolint:containedctx // application context
+	cfg      Config
+	notify   chan struct{}
+	// sqlite has no SKIP LOCKED, claims serialized in this instance
+	skipLocked bool
+	claimMutex sync.Mutex
+}
+
+func InitGlobalQueue(ctx context.Context, appStore *registry.Registry, cfg Config) *Queue {
+	ctx = log.With().Str("component", "queue").Logger().WithContext(ctx)
+
+	if cfg.MaxAttempts < 1 {
+		cfg.MaxAttempts = 1
+	}
+
+	if cfg.Lease <= 0 {
+		cfg.Lease = time.Minute
+	}
+
+	if cfg.PollInterval <= 0 {
+		cfg.PollInterval = 5 * time.Second
+	}
+
+	GlobalQueue = &Queue{
+		appStore:   appStore,
+		ctx:        ctx,
+		cfg:        cfg,
+		notify:     make(chan struct{}, 1),
+		skipLocked: appStore.DB.Dialector.Name() == "postgres",
+	}
+
+	return GlobalQueue
+}
+
+// Start runs the workers until application context done.
+func (q *Queue) Start(wg *sync.WaitGroup) {
+	for i := 0; i < q.cfg.Workers; i++ {
+		wg.Add(1)
+
+		go q.worker(wg)
+	}
+
+	log.Ctx(q.ctx).Info().Msgf("started %d queue workers", q.cfg.Workers)
+}
+
+// Enqueue adds the job as pending, job id is set after.
+func (q *Queue) Enqueue(ctx context.Context, job *models.Job) error {
+	job.ID.ID = uuid.New()
+	job.Status = models.JobStatusPending
+	job.MaxAttempts = q.cfg.MaxAttempts
+	job.RunAt = time.Now()
+
+	if result := q.appStore.DB.WithContext(ctx).Create(job); result.Error != nil {
+		return fmt.Errorf("cannot add job: %w", result.Error)
+	}
+
+	// wake up a waiting worker
+	select {
+	case q.notify <- struct{}{}:
+	default:
+	}
+
+	return nil
+}
+
+func (q *Queue) worker(wg *sync.WaitGroup) {
+	defer wg.Done()
+
+	ticker := time.NewTicker(q.cfg.PollInterval)
+	defer ticker.Stop()
+
+	for {
+		job, err := q.claim(q.ctx)
+		if err != nil && q.ctx.Err() == nil {
+			log.Ctx(q.ctx).Error().Err(err).Msg("cannot claim job")
+		}
+
+		if job != nil {
+			q.process(wg, job)
+
+			continue
+		}
+
+		select {
+		case <-q.ctx.Done():
+			return
+		case <-q.notify:
+		case <-ticker.C:
+		}
+	}
+}
+
+// claim locks one pending job or a running job with expired lease.
+func (q *Queue) claim(ctx context.Context) (*models.Job, error) {
+	if !q.skipLocked {
+		q.claimMutex.Lock()
+		defer q.claimMutex.Unlock()
+	}
+
+	var job *models.Job
+
+	err := q.appStore.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		now := time.Now()
+
+		query := tx.Model(&models.Job{}).
+			Where("(status = ? AND run_at <= ?) OR (status = ? AND locked_until < ?)",
+				models.JobStatusPending, now, models.JobStatusRunning, now).
+			Order("run_at").Limit(1)
+
+		if q.skipLocked {
+			query = query.Clauses(clause.Locking{Strength: "UPDATE", Options: "SKIP LOCKED"})
+		}
+
+		found := models.Job{}
+
+		result := query.Find(&found)
+		if result.Error != nil {
+			return result.Error
+		}
+
+		if result.RowsAffected == 0 {
+			return nil
+		}
+
+		lockID := uuid.New()
+		lockedUntil := now.Add(q.cfg.Lease)
+
+		found.Status = models.JobStatusRunning
+		found.Attempts++
+		found.LockID = &lockID
+		found.LockedUntil = &lockedUntil
+
+		result = tx.Model(&models.Job{}).Where("id = ?", found.ID.ID).Updates(map[string]interface{}{
+			"status":       found.Status,
+			"attempts":     found.Attempts,
+			"lock_id":      lockID,
+			"locked_until": lockedUntil,
+		})
+		if result.Error != nil {
+			return result.Error
+		}
+
+		job = &found
+
+		return nil
+	})
+
+	return job, err //nolint:wrapcheck // no need
+}
+
+// process runs the job and waits the flow to finish.
+func (q *Queue) process(wg *sync.WaitGroup, job *models.Job) {
+	ctx := log.Ctx(q.ctx).With().
+		Str("job", job.ID.ID.String()).
+		Str("control", job.Control).
+		Str("endpoint", job.Endpoint).
+		Int("attempt", job.Attempts).
+		Logger().WithContext(q.ctx)
+
+	// lease expired too many times
+	if job.Attempts > job.MaxAttempts {
+		q.finish(ctx, job, nil, errLeaseExpired)
+
+		return
+	}
+
+	heartbeatCtx, heartbeatCancel := context.WithCancel(ctx)
+	heartbeatDone := make(chan struct{})
+
+	go func() {
+		defer close(heartbeatDone)
+
+		q.heartbeat(heartbeatCtx, job)
+	}()
+
+	log.Ctx(ctx).Info().Msg("job started")
+
+	runID, err := q.run(ctx, wg, job)
+
+	heartbeatCancel()
+	<-heartbeatDone
+
+	// shutdown in the middle, give it to other workers
+	if q.ctx.Err() != nil {
+		q.release(ctx, job)
+
+		return
+	}
+
+	q.finish(ctx, job, runID, err)
+}
+
+func (q *Queue) run(ctx context.Context, wg *sync.WaitGroup, job *models.Job) (*uuid.UUID, error) {
+	control := models.Control{}
+
+	result := q.appStore.DB.WithContext(ctx).Where("name = ?", job.Control).First(&control)
+	if result.Error != nil {
+		return nil, fmt.Errorf("cannot get control: %w", result.Error)
+	}
+
+	content, err := base64.StdEncoding.DecodeString(control.Content)
+	if err != nil {
+		return nil, fmt.Errorf("cannot decode control content: %w", err)
+	}
+
+	// result is checked with /send/result as async call
+	ctx = context.WithValue(ctx, flow.CtxKeepResult, true)
+	request := jobRequest(job)
+
+	body, err := request.ReadMultipart(bytes.NewReader(job.Body))
+	if err != nil {
+		return nil, err //nolint:wrapcheck // no need
+	}
+
+	if len(body) == 0 {
+		body = nil
+	}
+
+	ctx = context.WithValue(ctx, flow.CtxRequest, request)
+
+	nodesReg, err := flow.StartFlow(ctx, wg, control.Name, job.Endpoint, job.Method, content, q.appStore, models.ResourceAccess(control.Groups.Groups), body)
+	if err != nil {
+		request.RemoveFiles()
+
+		return nil, err //nolint:wrapcheck // no need
+	}
+
+	request.RemoveFilesAfter(nodesReg)
+
+	runID := nodesReg.RunID()
+
+	// record run before waiting
+	q.update(ctx, job, map[string]interface{}{"run_id": runID})
+
+	<-nodesReg.Done()
+
+	return &runID, nodesReg.Err()
+}
+
+// jobRequest returns the call values of the job for scripts and templates.
+func jobRequest(job *models.Job) *flow.Request {
+	header := http.Header{}
+	if len(job.Header) > 0 {
+		_ = json.Unmarshal(job.Header, &header)
+	}
+
+	u, _ := url.Parse(job.URL)
+
+	request := flow.NewRequest(job.Method, u, header)
+	request.RemoteIP = job.RemoteIP
+	request.RequestID = header.Get("X-Request-Id")
+	request.Control = job.Control
+	request.Endpoint = job.Endpoint
+
+	return request
+}
+
+// heartbeat extends the lease while job is running.
+func (q *Queue) heartbeat(ctx context.Context, job *models.Job) {
+	ticker := time.NewTicker(q.cfg.Lease / 3) //nolint:gomnd // extend before expire
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			q.update(ctx, job, map[string]interface{}{"locked_until": time.Now().Add(q.cfg.Lease)})
+		}
+	}
+}
+
+// finish marks the job done, failed or pending for the next attempt.
+func (q *Queue) finish(ctx context.Context, job *models.Job, runID *uuid.UUID, runErr error) {
+	values := map[string]interface{}{
+		"lock_id":      nil,
+		"locked_until": nil,
+		"error":        "",
+	}
+
+	if runID != nil {
+		values["run_id"] = *runID
+	}
+
+	now := time.Now()
+
+	switch {
+	case runErr == nil:
+		values["status"] = models.JobStatusDone
+		values["finished_at"] = now
+
+		log.Ctx(ctx).Info().Msg("job done")
+	case job.Attempts < job.MaxAttempts && !permanent(runErr):
+		values["status"] = models.JobStatusPending
+		values["error"] = runErr.Error()
+		values["run_at"] = now.Add(q.backoff(job.Attempts))
+
+		log.Ctx(ctx).Warn().Err(runErr).Msg("job failed, retrying")
+	default:
+		values["status"] = models.JobStatusFailed
+		values["error"] = runErr.Error()
+		values["finished_at"] = now
+
+		log.Ctx(ctx).Error().Err(runErr).Msg("job failed")
+	}
+
+	q.update(context.WithoutCancel(ctx), job, values)
+}
+
+// release gives back the job without counting the attempt.
+func (q *Queue) release(ctx context.Context, job *models.Job) {
+	q.update(context.WithoutCancel(ctx), job, map[string]interface{}{
+		"status":       models.JobStatusPending,
+		"attempts":     job.Attempts - 1,
+		"run_at":       time.Now(),
+		"lock_id":      nil,
+		"locked_until": nil,
+	})
+
+	log.Ctx(ctx).Info().Msg("job released")
+}
+
+// update changes the job if it still belongs to this worker.
+func (q *Queue) update(ctx context.Context, job *models.Job, values map[string]interface{}) {
+	result := q.appStore.DB.WithContext(ctx).Model(&models.Job{}).
+		Where("id = ? AND lock_id = ?", job.ID.ID, *job.LockID).Updates(values)
+	if result.Error != nil {
+		log.Ctx(ctx).Error().Err(result.Error).Msg("cannot update job")
+
+		return
+	}
+
+	if result.RowsAffected == 0 {
+		log.Ctx(ctx).Warn().Msg("job taken by another worker")
+	}
+}
+
+// permanent errors not change with retry.
+func permanent(err error) bool {
+	return errors.Is(err, flow.ErrRunCanceled) ||
+		errors.Is(err, flow.ErrEndpointNotFound) ||
+		errors.Is(err, gorm.ErrRecordNotFound)
+}
+
+// backoff returns the wait before the next attempt.
+func (q *Queue) backoff(attempt int) time.Duration {
+	wait := q.cfg.Backoff
+	for i := 1; i < attempt && wait < MaxBackoff; i++ {
+		wait *= 2
+	}
+
+	if wait > MaxBackoff {
+		return MaxBackoff
+	}
+
+	return wait
+}
@@ -0,0 +1,142 @@
+package queue
+
+import (
+	"context"
+	"encoding/base64"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/rakunlabs/chore/internal/store"
+	_ "github.com/rakunlabs/chore/pkg/flow/nodes"
+	"github.com/rakunlabs/chore/pkg/models"
+	"github.com/rakunlabs/chore/pkg/models/apimodels"
+	"github.com/rakunlabs/chore/pkg/registry"
+)
+
+func TestQueue(t *testing.T) {
+	db, err := store.OpenConnection("sqlite", map[string]interface{}{
+		"fileName": filepath.Join(t.TempDir(), "chore.db"),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := db.AutoMigrate(store.Models...); err != nil {
+		t.Fatal(err)
+	}
+
+	contents := map[string]string{
+		"ok": `{
+			"1": {"name": "endpoint", "data": {"endpoint": "test", "methods": "POST"}, "outputs": {"output_1": {"connections": [{"node": "2", "output": "input_1"}]}}},
+			"2": {"name": "respond", "data": {}, "inputs": {"input_1": {"connections": [{"node": "1"}]}}}
+		}`,
+		"fail": `{
+			"1": {"name": "endpoint", "data": {"endpoint": "test", "methods": "POST"}, "outputs": {"output_1": {"connections": [{"node": "2", "output": "input_1"}]}}},
+			"2": {"name": "forLoop", "data": {"for": "throw new Error('boom')"}, "inputs": {"input_1": {"connections": [{"node": "1"}]}}}
+		}`,
+	}
+
+	for name, content := range contents {
+		control := models.Control{ControlPureContent: models.ControlPureContent{
+			Content:     base64.StdEncoding.EncodeToString([]byte(content)),
+			ControlPure: models.ControlPure{Name: name},
+		}}
+		control.ID.ID = uuid.New()
+
+		if err := db.Create(&control).Error; err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	wg := &sync.WaitGroup{}
+
+	defer wg.Wait()
+	defer cancel()
+
+	q := InitGlobalQueue(ctx, &registry.Registry{DB: db}, Config{
+		Workers:      2,
+		MaxAttempts:  2,
+		Backoff:      10 * time.Millisecond,
+		PollInterval: 10 * time.Millisecond,
+	})
+
+	jobOK := models.Job{JobPure: models.JobPure{Control: "ok", Endpoint: "test", Method: "POST", Body: []byte("deepcore")}}
+	jobFail := models.Job{JobPure: models.JobPure{Control: "fail", Endpoint: "test", Method: "POST"}}
+
+	for _, job := range []*models.Job{&jobOK, &jobFail} {
+		if err := q.Enqueue(ctx, job); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// worker stopped in the middle of the job
+	expired := time.Now().Add(-time.Minute)
+	jobStale := models.Job{
+		JobPure: models.JobPure{
+			Control: "ok", Endpoint: "test", Method: "POST",
+			Status: models.JobStatusRunning, Attempts: 1, MaxAttempts: 2,
+			RunAt: expired, LockedUntil: &expired,
+		},
+		ID: apimodels.ID{ID: uuid.New()},
+	}
+
+	if err := db.Create(&jobStale).Error; err != nil {
+		t.Fatal(err)
+	}
+
+	q.Start(wg)
+
+	tests := []struct {
+		id           uuid.UUID
+		wantStatus   string
+		wantAttempts int
+	}{
+		{id: jobOK.ID.ID, wantStatus: models.JobStatusDone, wantAttempts: 1},
+		{id: jobFail.ID.ID, wantStatus: models.JobStatusFailed, wantAttempts: 2},
+		{id: jobStale.ID.ID, wantStatus: models.JobStatusDone, wantAttempts: 2},
+	}
+
+	for _, tt := range tests {
+		got := models.Job{}
+
+		for i := 0; ; i++ {
+			if err := db.Where("id = ?", tt.id).First(&got).Error; err != nil {
+				t.Fatal(err)
+			}
+
+			if got.Status == models.JobStatusDone || got.Status == models.JobStatusFailed {
+				break
+			}
+
+			if i > 500 {
+				t.Fatalf("job %s not finished, status %s", got.Control, got.Status)
+			}
+
+			time.Sleep(10 * time.Millisecond)
+		}
+
+		if got.Status != tt.wantStatus || got.Attempts != tt.wantAttempts {
+			t.Errorf("job %s = %s attempts %d, want %s attempts %d, error %q",
+				got.Control, got.Status, got.Attempts, tt.wantStatus, tt.wantAttempts, got.Error)
+		}
+
+		if got.RunID == nil {
+			t.Errorf("job %s has no run id", got.Control)
+		}
+	}
+}
+
+func TestQueueBackoff(t *testing.T) {
+	q := &Queue{cfg: Config{Backoff: time.Second}}
+
+	for attempt, want := range map[int]time.Duration{1: time.Second, 2: 2 * time.Second, 3: 4 * time.Second, 30: MaxBackoff} {
+		if got := q.backoff(attempt); got != want {
+			t.Errorf("backoff(%d) = %v, want %v", attempt, got, want)
+		}
+	}
+}
@@ -0,0 +1,67 @@
+package request
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+)
+
+var ErrResponseTooLarge = errors.New("response body exceeds max size")
+
+var (
+	// DefaultMaxResponseSize is the max response body in bytes when config has no limit, 0 is unlimited.
+	DefaultMaxResponseSize int64
+	// DefaultSpoolSize writes bigger response bodies to a file when config has no value, 0 keeps in memory.
+	DefaultSpoolSize int64
+)
+
+// readBody reads the body in the limit, body bigger than spool size is copied to the spool file.
+func (c *Client) readBody(body io.Reader) ([]byte, string, int64, error) {
+	if c.maxSize > 0 {
+		body = io.LimitReader(body, c.maxSize+1)
+	}
+
+	if c.spoolSize <= 0 || c.spool == nil {
+		data, err := io.ReadAll(body)
+		if err != nil {
+			return nil, "", 0, fmt.Errorf("failed to read response: %w", err)
+		}
+
+		if c.maxSize > 0 && int64(len(data)) > c.maxSize {
+			return nil, "", 0, fmt.Errorf("%w of %d bytes", ErrResponseTooLarge, c.maxSize)
+		}
+
+		return data, "", int64(len(data)), nil
+	}
+
+	// small bodies stay in memory
+	var buf bytes.Buffer
+	if _, err := io.CopyN(&buf, body, c.spoolSize+1); err != nil {
+		if !errors.Is(err, io.EOF) {
+			return nil, "", 0, fmt.Errorf("failed to read response: %w", err)
+		}
+
+		return buf.Bytes(), "", int64(buf.Len()), nil
+	}
+
+	file, err := c.spool()
+	if err != nil {
+		return nil, "", 0, err
+	}
+
+	size, err := io.Copy(file, io.MultiReader(&buf, body))
+	if err == nil && c.maxSize > 0 && size > c.maxSize {
+		err = fmt.Errorf("%w of %d bytes", ErrResponseTooLarge, c.maxSize)
+	}
+
+	if errClose := file.Close(); err == nil {
+		err = errClose
+	}
+
+	if err != nil {
+		return nil, "", 0, fmt.Errorf("failed to spool response: %w", err)
+	}
+
+	return nil, file.Name(), size, nil
+}
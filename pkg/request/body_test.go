@@ -0,0 +1,136 @@
+package request
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestClient_ResponseSize(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(strings.Repeat("x", 100))) //nolint:errcheck // test
+	}))
+	defer server.Close()
+
+	dir := t.TempDir()
+	spool := func() (*os.File, error) { return os.CreateTemp(dir, "response-*") }
+
+	tests := []struct {
+		name      string
+		cfg       Config
+		wantBody  int
+		wantFile  bool
+		wantErr   error
+		wantFiles int
+	}{
+		{
+			name:     "unlimited",
+			cfg:      Config{},
+			wantBody: 100,
+		},
+		{
+			name:     "in limit",
+			cfg:      Config{MaxResponseSize: 100},
+			wantBody: 100,
+		},
+		{
+			name:    "over limit",
+			cfg:     Config{MaxResponseSize: 99},
+			wantErr: ErrResponseTooLarge,
+		},
+		{
+			name:     "under spool size",
+			cfg:      Config{SpoolSize: 100, Spool: spool},
+			wantBody: 100,
+		},
+		{
+			name:      "spooled",
+			cfg:       Config{SpoolSize: 10, Spool: spool},
+			wantFile:  true,
+			wantFiles: 1,
+		},
+		{
+			name:      "spooled over limit",
+			cfg:       Config{MaxResponseSize: 50, SpoolSize: 10, Spool: spool},
+			wantErr:   ErrResponseTooLarge,
+			wantFiles: 1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// files of the previous case
+			entries, _ := os.ReadDir(dir)
+			for _, e := range entries {
+				os.Remove(dir + "/" + e.Name())
+			}
+
+			c, err := NewClient(tt.cfg)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			resp, err := c.Call(context.Background(), server.URL, http.MethodGet, nil, nil)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("Client.Call() error = %v, want %v", err, tt.wantErr)
+			}
+
+			if entries, _ := os.ReadDir(dir); len(entries) != tt.wantFiles {
+				t.Errorf("spooled files = %d, want %d", len(entries), tt.wantFiles)
+			}
+
+			if err != nil {
+				return
+			}
+
+			if len(resp.Body) != tt.wantBody || (resp.File != "") != tt.wantFile {
+				t.Fatalf("body = %d file = %q, want %d %v", len(resp.Body), resp.File, tt.wantBody, tt.wantFile)
+			}
+
+			if tt.wantFile {
+				data, err := os.ReadFile(resp.File)
+				if err != nil {
+					t.Fatal(err)
+				}
+
+				if len(data) != 100 || resp.Size != 100 {
+					t.Errorf("spooled = %d size = %d, want 100", len(data), resp.Size)
+				}
+			}
+		})
+	}
+}
+
+func TestClient_CallFile(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.ContentLength != 4 {
+			t.Errorf("content length = %d, want 4", r.ContentLength)
+		}
+
+		w.Write([]byte(r.Method)) //nolint:errcheck // test
+	}))
+	defer server.Close()
+
+	path := t.TempDir() + "/body"
+	if err := os.WriteFile(path, []byte("data"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	c, err := NewClient(Config{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	resp, err := c.CallFile(context.Background(), server.URL, http.MethodPut, nil, path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if string(resp.Body) != http.MethodPut {
+		t.Errorf("body = %s, want %s", resp.Body, http.MethodPut)
+	}
+}
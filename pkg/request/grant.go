@@ -0,0 +1,185 @@
+package request
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+
+	"golang.org/x/oauth2"
+	"golang.org/x/oauth2/jwt"
+)
+
+const (
+	GrantClientCredentials = "client_credentials"
+	GrantPassword          = "password"
+	GrantRefreshToken      = "refresh_token"
+	GrantJWTBearer         = "jwt_bearer"
+)
+
+// key returns the registry key, client credentials are shared with the client id.
+func (c AuthConfig) key() string {
+	switch c.Grant {
+	case "", GrantClientCredentials:
+		return c.ClientID
+	case GrantPassword:
+		return strings.Join([]string{c.Grant, c.Name, c.ClientID, c.Username}, "/")
+	case GrantJWTBearer:
+		return strings.Join([]string{c.Grant, c.Name, c.Issuer, c.Subject}, "/")
+	}
+
+	return strings.Join([]string{c.Grant, c.Name, c.ClientID}, "/")
+}
+
+func (c AuthConfig) validate() error {
+	switch c.Grant {
+	case "", GrantClientCredentials:
+		if c.ClientID == "" {
+			return ErrClientIDNotFound
+		}
+	case GrantPassword:
+		if c.ClientID == "" {
+			return ErrClientIDNotFound
+		}
+
+		if c.Username == "" {
+			return errors.New("password grant username is empty")
+		}
+	case GrantRefreshToken:
+		if c.ClientID == "" {
+			return ErrClientIDNotFound
+		}
+
+		if c.RefreshToken == "" {
+			return errors.New("refresh_token grant refresh token is empty")
+		}
+	case GrantJWTBearer:
+		if c.PrivateKey == "" {
+			return errors.New("jwt_bearer grant private key is empty")
+		}
+
+		if c.Issuer == "" && c.ClientID == "" {
+			return errors.New("jwt_bearer grant needs issuer or client id")
+		}
+	default:
+		return fmt.Errorf("unknown oauth2 grant %q", c.Grant)
+	}
+
+	if c.TokenURL == "" {
+		return errors.New("oauth2 token url is empty")
+	}
+
+	return nil
+}
+
+// tokenSource returns the cached token source of the user delegated grants.
+func (c AuthConfig) tokenSource(ctx context.Context) oauth2.TokenSource {
+	conf := &oauth2.Config{
+		ClientID:     c.ClientID,
+		ClientSecret: c.ClientSecret,
+		Endpoint:     oauth2.Endpoint{TokenURL: c.TokenURL},
+		Scopes:       c.Scopes,
+	}
+
+	switch c.Grant {
+	case GrantPassword:
+		// refresh token of the server is used first, password again when there is no refresh
+		return oauth2.ReuseTokenSource(nil, &passwordSource{ctx: ctx, conf: conf, username: c.Username, password: c.Password})
+	case GrantJWTBearer:
+		issuer := c.Issuer
+		if issuer == "" {
+			issuer = c.ClientID
+		}
+
+		return (&jwt.Config{
+			Email:        issuer,
+			PrivateKey:   []byte(c.PrivateKey),
+			PrivateKeyID: c.KeyID,
+			Subject:      c.Subject,
+			Scopes:       c.Scopes,
+			TokenURL:     c.TokenURL,
+			Audience:     c.Audience,
+		}).TokenSource(ctx)
+	}
+
+	return &rotateSource{
+		base:         conf.TokenSource(ctx, &oauth2.Token{RefreshToken: c.RefreshToken}),
+		refreshToken: c.RefreshToken,
+		onRotate:     c.OnRefreshToken,
+	}
+}
+
+// roundTripper adds the token of the grant to the requests.
+func (c AuthConfig) roundTripper(ctx context.Context) func(context.Context, http.RoundTripper) (http.RoundTripper, error) {
+	source := c.tokenSource(ctx)
+
+	return func(_ context.Context, transport http.RoundTripper) (http.RoundTripper, error) {
+		return &oauth2.Transport{Source: source, Base: transport}, nil
+	}
+}
+
+// passwordSource gets a new token with the resource owner password credentials.
+type passwordSource struct {
+	ctx      context.Context //nolint:containedctx // application context
+	conf     *oauth2.Config
+	username string
+	password string
+
+	mutex   sync.Mutex
+	refresh oauth2.TokenSource
+}
+
+func (s *passwordSource) Token() (*oauth2.Token, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if s.refresh != nil {
+		if token, err := s.refresh.Token(); err == nil {
+			return token, nil
+		}
+
+		s.refresh = nil
+	}
+
+	token, err := s.conf.PasswordCredentialsToken(s.ctx, s.username, s.password)
+	if err != nil {
+		return nil, err //nolint:wrapcheck // oauth2 error is clear
+	}
+
+	if token.RefreshToken != "" {
+		s.refresh = s.conf.TokenSource(s.ctx, &oauth2.Token{RefreshToken: token.RefreshToken})
+	}
+
+	return token, nil
+}
+
+// rotateSource calls onRotate when the refresh token changes.
+type rotateSource struct {
+	base         oauth2.TokenSource
+	refreshToken string
+	onRotate     func(string)
+
+	mutex sync.Mutex
+}
+
+func (s *rotateSource) Token() (*oauth2.Token, error) {
+	token, err := s.base.Token()
+	if err != nil {
+		return nil, err //nolint:wrapcheck // oauth2 error is clear
+	}
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if token.RefreshToken != "" && token.RefreshToken != s.refreshToken {
+		s.refreshToken = token.RefreshToken
+
+		if s.onRotate != nil {
+			s.onRotate(token.RefreshToken)
+		}
+	}
+
+	return token, nil
+}
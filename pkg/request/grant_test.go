@@ -0,0 +1,150 @@
+package request
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestRegistry_Grants(t *testing.T) {
+	InitGlobalRegistry(context.Background())
+
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	privateKey := string(pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(key)}))
+
+	tokenServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if err := r.ParseForm(); err != nil {
+			t.Error(err)
+		}
+
+		token := map[string]interface{}{"token_type": "Bearer", "expires_in": 3600}
+
+		switch r.PostForm.Get("grant_type") {
+		case "password":
+			if r.PostForm.Get("username") != "user" || r.PostForm.Get("password") != "pass" {
+				w.WriteHeader(http.StatusUnauthorized)
+
+				return
+			}
+
+			token["access_token"] = "password-token"
+		case "refresh_token":
+			if r.PostForm.Get("refresh_token") != "refresh-1" {
+				w.WriteHeader(http.StatusUnauthorized)
+
+				return
+			}
+
+			token["access_token"] = "refresh-token"
+			token["refresh_token"] = "refresh-2"
+		case "urn:ietf:params:oauth:grant-type:jwt-bearer":
+			parts := strings.Split(r.PostForm.Get("assertion"), ".")
+			if len(parts) != 3 {
+				w.WriteHeader(http.StatusBadRequest)
+
+				return
+			}
+
+			claimsRaw, _ := base64.RawURLEncoding.DecodeString(parts[1])
+
+			var claims map[string]interface{}
+			_ = json.Unmarshal(claimsRaw, &claims)
+
+			token["access_token"] = "jwt-" + claims["iss"].(string) + "-" + claims["sub"].(string)
+		default:
+			w.WriteHeader(http.StatusBadRequest)
+
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(token)
+	}))
+	defer tokenServer.Close()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(r.Header.Get("Authorization"))) //nolint:errcheck // test
+	}))
+	defer server.Close()
+
+	var rotated []string
+
+	tests := []struct {
+		name        string
+		auth        AuthConfig
+		want        string
+		wantRotated []string
+		wantErr     bool
+	}{
+		{
+			name: "password",
+			auth: AuthConfig{Grant: GrantPassword, ClientID: "chore", Username: "user", Password: "pass"},
+			want: "Bearer password-token",
+		},
+		{
+			name: "refresh token rotated",
+			auth: AuthConfig{
+				Grant: GrantRefreshToken, ClientID: "chore", RefreshToken: "refresh-1", Name: "saas",
+				OnRefreshToken: func(v string) { rotated = append(rotated, v) },
+			},
+			want:        "Bearer refresh-token",
+			wantRotated: []string{"refresh-2"},
+		},
+		{
+			name: "jwt bearer",
+			auth: AuthConfig{Grant: GrantJWTBearer, Issuer: "chore", Subject: "ops", PrivateKey: privateKey},
+			want: "Bearer jwt-chore-ops",
+		},
+		{
+			name:    "unknown grant",
+			auth:    AuthConfig{Grant: "implicit", ClientID: "chore"},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rotated = nil
+
+			tt.auth.Enabled = true
+			tt.auth.TokenURL = tokenServer.URL
+
+			c, err := NewClient(Config{Auth: tt.auth})
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("NewClient() error = %v, wantErr %v", err, tt.wantErr)
+			}
+
+			if err != nil {
+				return
+			}
+
+			// second call uses the cached token
+			for i := 0; i < 2; i++ {
+				resp, err := c.Call(context.Background(), server.URL, http.MethodGet, nil, nil)
+				if err != nil {
+					t.Fatal(err)
+				}
+
+				if string(resp.Body) != tt.want {
+					t.Errorf("Authorization = %s, want %s", resp.Body, tt.want)
+				}
+			}
+
+			if strings.Join(rotated, ",") != strings.Join(tt.wantRotated, ",") {
+				t.Errorf("rotated = %v, want %v", rotated, tt.wantRotated)
+			}
+		})
+	}
+}
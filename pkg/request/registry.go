@@ -19,6 +19,7 @@ var (
 	DefaultTickerDuration = time.Minute
 )
 
+// Registry shares token sources, client credentials with the client id and other grants with the settings name.
 type Registry struct {
 	clientIDs map[string]Auth
 	timers    map[string]Timer
@@ -55,18 +56,31 @@ func (r *Registry) AddService(cfg AuthConfig) (func(_ context.Context, transport
 	r.mutex.Lock()
 	defer r.mutex.Unlock()
 
-	if cfg.ClientID == "" {
-		return nil, ErrClientIDNotFound
+	if err := cfg.validate(); err != nil {
+		return nil, err
 	}
 
-	if _, ok := r.clientIDs[cfg.ClientID]; ok {
-		r.resetTimer(cfg.ClientID)
-		// log.Debug().Msgf("using client id: %s", cfg.ClientID)
+	key := cfg.key()
 
-		return r.clientIDs[cfg.ClientID].RoundTripper, nil
+	if _, ok := r.clientIDs[key]; ok {
+		r.resetTimer(key)
+		// log.Debug().Msgf("using client id: %s", key)
+
+		return r.clientIDs[key].RoundTripper, nil
 	}
 
-	log.Debug().Msgf("adding client id: %s", cfg.ClientID)
+	log.Debug().Msgf("adding client id: %s", key)
+
+	if cfg.Grant != "" && cfg.Grant != GrantClientCredentials {
+		ctx, cancel := context.WithCancel(r.ctx)
+
+		r.add(key, Auth{
+			RoundTripper: cfg.roundTripper(ctx),
+			Cancel:       cancel,
+		})
+
+		return r.clientIDs[key].RoundTripper, nil
+	}
 
 	provider := auth.Provider{Generic: &providers.Generic{
 		ClientID:     cfg.ClientID,
@@ -84,19 +98,21 @@ func (r *Registry) AddService(cfg AuthConfig) (func(_ context.Context, transport
 		return nil, err //nolint:wrapcheck // no need
 	}
 
-	timer := time.NewTimer(DefaultTimeDuration)
-
-	r.clientIDs[cfg.ClientID] = Auth{
+	r.add(key, Auth{
 		RoundTripper: shared.RoundTripper,
 		Cancel:       cancel,
-	}
+	})
 
-	r.timers[cfg.ClientID] = Timer{
-		Timer:    timer,
-		ClientID: cfg.ClientID,
-	}
+	return r.clientIDs[key].RoundTripper, nil
+}
 
-	return r.clientIDs[cfg.ClientID].RoundTripper, nil
+func (r *Registry) add(key string, a Auth) {
+	r.clientIDs[key] = a
+
+	r.timers[key] = Timer{
+		Timer:    time.NewTimer(DefaultTimeDuration),
+		ClientID: key,
+	}
 }
 
 func (r *Registry) Start(wg *sync.WaitGroup) {
@@ -5,8 +5,12 @@ import (
 	"context"
 	"fmt"
 	"io"
+	"net"
 	"net/http"
+	"os"
 	"strconv"
+	"syscall"
+	"time"
 
 	"github.com/rs/zerolog"
 	"github.com/worldline-go/klient"
@@ -23,10 +27,17 @@ type ClientResponse struct {
 	Header     http.Header
 	Body       []byte
 	StatusCode int
+	// File is the path of the spooled body, Body is empty.
+	File string
+	Size int64
 }
 
 type Client struct {
-	klient *klient.Client
+	klient    *klient.Client
+	signer    Signer
+	maxSize   int64
+	spoolSize int64
+	spool     func() (*os.File, error)
 }
 
 type Config struct {
@@ -35,6 +46,17 @@ type Config struct {
 	Log        *zerolog.Logger
 	Retry      Retry
 	Auth       AuthConfig
+	TLS        TLSConfig
+	// Signer adds authentication to every request.
+	Signer Signer
+	// MaxResponseSize is the max body in bytes, 0 is unlimited.
+	MaxResponseSize int64
+	// SpoolSize writes bigger bodies to the file of Spool.
+	SpoolSize int64
+	Spool     func() (*os.File, error)
+	// DialControl checks the resolved address before the connection, like rejecting private networks.
+	// Proxy is not used with it, proxy connection would skip the check of the target.
+	DialControl func(network, address string, c syscall.RawConn) error
 }
 
 type AuthConfig struct {
@@ -43,6 +65,27 @@ type AuthConfig struct {
 	ClientSecret string   `json:"client_secret"`
 	TokenURL     string   `json:"token_url"`
 	Scopes       []string `json:"scopes"`
+	// Grant is client_credentials (default), password, refresh_token or jwt_bearer.
+	Grant string `json:"grant"`
+
+	// password grant
+	Username string `json:"username"`
+	Password string `json:"password"`
+
+	// refresh_token grant, rotated token is given to OnRefreshToken.
+	RefreshToken string `json:"refresh_token"`
+
+	// jwt_bearer grant, assertion is signed with RSA private key and issuer is client id if empty.
+	PrivateKey string `json:"private_key"`
+	KeyID      string `json:"key_id"`
+	Issuer     string `json:"issuer"`
+	Subject    string `json:"subject"`
+	Audience   string `json:"audience"`
+
+	// Name of the settings, tokens are shared with the same name.
+	Name string `json:"-"`
+	// OnRefreshToken is called when the server returns a new refresh token.
+	OnRefreshToken func(refreshToken string) `json:"-"`
 }
 
 func NewClient(cfg Config) (*Client, error) {
@@ -51,6 +94,15 @@ func NewClient(cfg Config) (*Client, error) {
 		options = append(options, klient.WithLogger(logz.AdapterKV{Log: *cfg.Log}))
 	}
 
+	if !cfg.TLS.IsEmpty() || cfg.DialControl != nil {
+		httpClient, err := cfg.httpClient()
+		if err != nil {
+			return nil, err
+		}
+
+		options = append(options, klient.WithHTTPClient(httpClient))
+	}
+
 	if cfg.SkipVerify {
 		options = append(options, klient.WithInsecureSkipVerify(true))
 	}
@@ -91,10 +143,42 @@ func NewClient(cfg Config) (*Client, error) {
 	}
 
 	return &Client{
-		klient: client,
+		klient:    client,
+		signer:    cfg.Signer,
+		maxSize:   cfg.MaxResponseSize,
+		spoolSize: cfg.SpoolSize,
+		spool:     cfg.Spool,
 	}, nil
 }
 
+// httpClient returns pooled client with the tls config and the dial control.
+func (cfg Config) httpClient() (*http.Client, error) {
+	httpClient, err := cfg.TLS.httpClient()
+	if err != nil {
+		return nil, err
+	}
+
+	if cfg.DialControl == nil {
+		return httpClient, nil
+	}
+
+	if cfg.Proxy != "" {
+		return nil, fmt.Errorf("proxy cannot be used with dial control")
+	}
+
+	//nolint:forcetypeassert // clear
+	transport := httpClient.Transport.(*http.Transport)
+	// pooled client gets the proxy from environment
+	transport.Proxy = nil
+	transport.DialContext = (&net.Dialer{
+		Timeout:   30 * time.Second,
+		KeepAlive: 30 * time.Second,
+		Control:   cfg.DialControl,
+	}).DialContext
+
+	return httpClient, nil
+}
+
 func (c *Client) Call(
 	ctx context.Context,
 	url, method string,
@@ -106,16 +190,87 @@ func (c *Client) Call(
 		return nil, err
 	}
 
+	return c.do(req, payload)
+}
+
+// CallFile sends the file as body without reading it to the memory.
+func (c *Client) CallFile(
+	ctx context.Context,
+	url, method string,
+	headers map[string]interface{},
+	path string,
+) (*ClientResponse, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open body: %w", err)
+	}
+
+	defer file.Close()
+
+	info, err := file.Stat()
+	if err != nil {
+		return nil, fmt.Errorf("failed to open body: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, url, file)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request for [%s]: %w", url, err)
+	}
+
+	for k, v := range headers {
+		req.Header.Add(k, fmt.Sprint(v))
+	}
+
+	req.ContentLength = info.Size()
+
+	if fileSigner, ok := c.signer.(FileSigner); ok {
+		if err := fileSigner.SignFile(req, file); err != nil {
+			return nil, fmt.Errorf("failed to sign request: %w", err)
+		}
+
+		// signer reads the file, send it from the start
+		if _, err := file.Seek(0, io.SeekStart); err != nil {
+			return nil, fmt.Errorf("failed to read body: %w", err)
+		}
+
+		return c.send(req)
+	}
+
+	// other signers need the body
+	var payload []byte
+	if c.signer != nil {
+		if payload, err = os.ReadFile(path); err != nil {
+			return nil, fmt.Errorf("failed to read body: %w", err)
+		}
+	}
+
+	return c.do(req, payload)
+}
+
+func (c *Client) do(req *http.Request, payload []byte) (*ClientResponse, error) {
+	if c.signer != nil {
+		if err := c.signer.Sign(req, payload); err != nil {
+			return nil, fmt.Errorf("failed to sign request: %w", err)
+		}
+	}
+
+	return c.send(req)
+}
+
+func (c *Client) send(req *http.Request) (*ClientResponse, error) {
 	resp, err := c.klient.HTTP.Do(req)
 	if err != nil {
 		return nil, fmt.Errorf("failed to send request: %w", err)
 	}
 
 	defer resp.Body.Close()
-	body, err := io.ReadAll(resp.Body)
+
+	body, file, size, err := c.readBody(resp.Body)
 
 	return &ClientResponse{
 		Body:       body,
+		File:       file,
+		Size:       size,
 		StatusCode: resp.StatusCode,
 		Header:     resp.Header,
 	}, err
@@ -3,9 +3,11 @@ package request
 import (
 	"bytes"
 	"context"
+	"errors"
 	"io"
 	"net/http"
 	"net/http/httptest"
+	"syscall"
 	"testing"
 )
 
@@ -75,3 +77,37 @@ func TestClient_Send(t *testing.T) {
 		})
 	}
 }
+
+func TestClientDialControl(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer server.Close()
+
+	errDenied := errors.New("denied")
+
+	c, err := NewClient(Config{
+		DialControl: func(_, _ string, _ syscall.RawConn) error {
+			return errDenied
+		},
+	})
+	if err != nil {
+		t.Fatalf("NewClient() error = %v", err)
+	}
+
+	if _, err := c.Call(context.Background(), server.URL, http.MethodGet, nil, nil); !errors.Is(err, errDenied) {
+		t.Errorf("Call() error = %v, want %v", err, errDenied)
+	}
+
+	// proxy connects to the target instead of the checked dial
+	httpClient, err := Config{DialControl: func(_, _ string, _ syscall.RawConn) error { return nil }}.httpClient()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if httpClient.Transport.(*http.Transport).Proxy != nil {
+		t.Error("proxy from environment is set with dial control")
+	}
+
+	if _, err := NewClient(Config{Proxy: "http://localhost:3128", DialControl: func(_, _ string, _ syscall.RawConn) error { return nil }}); err == nil {
+		t.Error("NewClient() with proxy and dial control expected error")
+	}
+}
@@ -0,0 +1,254 @@
+package request
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha1" //nolint:gosec // some APIs still sign with sha1
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"hash"
+	"io"
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	SignerBasic = "basic"
+	SignerHMAC  = "hmac"
+	SignerSigV4 = "sigv4"
+)
+
+// Signer adds authentication to the outgoing request, body is the payload of the request.
+type Signer interface {
+	Sign(req *http.Request, body []byte) error
+}
+
+// FileSigner signs the body sent from the file, body is read from the start as needed.
+type FileSigner interface {
+	SignFile(req *http.Request, body io.ReadSeeker) error
+}
+
+// SignerConfig is the data of the signer settings, fields are used by the type.
+type SignerConfig struct {
+	// Type is basic, hmac or sigv4.
+	Type string `json:"type"`
+
+	// basic
+	Username string `json:"username"`
+	Password string `json:"password"`
+
+	// hmac
+	Secret string `json:"secret"`
+	// Algorithm is sha256 (default), sha1 or sha512.
+	Algorithm string `json:"algorithm"`
+	// Header holds the signature, default is X-Signature.
+	Header string `json:"header"`
+	Prefix string `json:"prefix"`
+	// Encoding of the signature, hex (default) or base64.
+	Encoding string `json:"encoding"`
+	// TimestampHeader sets unix seconds of the signing time to the header.
+	TimestampHeader string `json:"timestamp_header"`
+	// Canonical is the signed string, default is {body}.
+	Canonical string `json:"canonical"`
+
+	// sigv4
+	AccessKey    string `json:"access_key"`
+	SecretKey    string `json:"secret_key"`
+	SessionToken string `json:"session_token"`
+	Region       string `json:"region"`
+	Service      string `json:"service"`
+}
+
+// Signer returns the signer of the type.
+func (c SignerConfig) Signer() (Signer, error) {
+	switch c.Type {
+	case SignerBasic:
+		return &BasicSigner{Username: c.Username, Password: c.Password}, nil
+	case SignerHMAC:
+		if c.Secret == "" {
+			return nil, fmt.Errorf("hmac signer secret is empty")
+		}
+
+		if _, err := hashFunc(c.Algorithm); err != nil {
+			return nil, err
+		}
+
+		return &HMACSigner{
+			Secret:          []byte(c.Secret),
+			Algorithm:       c.Algorithm,
+			Header:          c.Header,
+			Prefix:          c.Prefix,
+			Encoding:        c.Encoding,
+			TimestampHeader: c.TimestampHeader,
+			Canonical:       c.Canonical,
+		}, nil
+	case SignerSigV4:
+		if c.AccessKey == "" || c.SecretKey == "" || c.Region == "" || c.Service == "" {
+			return nil, fmt.Errorf("sigv4 signer needs access_key, secret_key, region and service")
+		}
+
+		return &SigV4Signer{
+			AccessKey:    c.AccessKey,
+			SecretKey:    c.SecretKey,
+			SessionToken: c.SessionToken,
+			Region:       c.Region,
+			Service:      c.Service,
+		}, nil
+	}
+
+	return nil, fmt.Errorf("unknown signer type %q", c.Type)
+}
+
+// BasicSigner sets HTTP Basic authorization.
+type BasicSigner struct {
+	Username string
+	Password string
+}
+
+func (s *BasicSigner) Sign(req *http.Request, _ []byte) error {
+	req.SetBasicAuth(s.Username, s.Password)
+
+	return nil
+}
+
+func (s *BasicSigner) SignFile(req *http.Request, _ io.ReadSeeker) error {
+	return s.Sign(req, nil)
+}
+
+// HMACSigner sets HMAC of the canonical string to the header.
+//
+// Canonical placeholders are {method}, {host}, {path}, {query}, {timestamp}, {body}, {body_sha256}
+// and {header:Name} for the value of a request header.
+type HMACSigner struct {
+	Secret          []byte
+	Algorithm       string
+	Header          string
+	Prefix          string
+	Encoding        string
+	TimestampHeader string
+	Canonical       string
+
+	now func() time.Time
+}
+
+var rgxHeaderPlaceholder = regexp.MustCompile(`\{header:([^}]+)\}`)
+
+func (s *HMACSigner) Sign(req *http.Request, body []byte) error {
+	return s.SignFile(req, bytes.NewReader(body))
+}
+
+// SignFile streams the body to the mac, body is not read to the memory.
+func (s *HMACSigner) SignFile(req *http.Request, body io.ReadSeeker) error {
+	newHash, err := hashFunc(s.Algorithm)
+	if err != nil {
+		return err
+	}
+
+	timestamp := strconv.FormatInt(timeNow(s.now).Unix(), 10)
+	if s.TimestampHeader != "" {
+		req.Header.Set(s.TimestampHeader, timestamp)
+	}
+
+	canonical := s.Canonical
+	if canonical == "" {
+		canonical = "{body}"
+	}
+
+	// header values first, body could have placeholder like text
+	canonical = rgxHeaderPlaceholder.ReplaceAllStringFunc(canonical, func(v string) string {
+		return req.Header.Get(rgxHeaderPlaceholder.FindStringSubmatch(v)[1])
+	})
+
+	var bodyHash string
+	if strings.Contains(canonical, "{body_sha256}") {
+		if bodyHash, err = bodySHA256(body); err != nil {
+			return err
+		}
+	}
+
+	replacer := strings.NewReplacer(
+		"{method}", req.Method,
+		"{host}", req.URL.Host,
+		"{path}", req.URL.EscapedPath(),
+		"{query}", req.URL.RawQuery,
+		"{timestamp}", timestamp,
+		"{body_sha256}", bodyHash,
+	)
+
+	mac := hmac.New(newHash, s.Secret)
+
+	// body is written between the parts
+	for i, part := range strings.Split(canonical, "{body}") {
+		if i > 0 {
+			if err := copyBody(mac, body); err != nil {
+				return err
+			}
+		}
+
+		mac.Write([]byte(replacer.Replace(part)))
+	}
+
+	signature := hex.EncodeToString(mac.Sum(nil))
+	if s.Encoding == "base64" {
+		signature = base64.StdEncoding.EncodeToString(mac.Sum(nil))
+	}
+
+	header := s.Header
+	if header == "" {
+		header = "X-Signature"
+	}
+
+	req.Header.Set(header, s.Prefix+signature)
+
+	return nil
+}
+
+func hashFunc(algorithm string) (func() hash.Hash, error) {
+	switch algorithm {
+	case "", "sha256":
+		return sha256.New, nil
+	case "sha1":
+		return sha1.New, nil
+	case "sha512":
+		return sha512.New, nil
+	}
+
+	return nil, fmt.Errorf("unknown hmac algorithm %q", algorithm)
+}
+
+func timeNow(now func() time.Time) time.Time {
+	if now != nil {
+		return now()
+	}
+
+	return time.Now()
+}
+
+// copyBody writes the body from the start.
+func copyBody(w io.Writer, body io.ReadSeeker) error {
+	if _, err := body.Seek(0, io.SeekStart); err != nil {
+		return fmt.Errorf("cannot read body: %w", err)
+	}
+
+	if _, err := io.Copy(w, body); err != nil {
+		return fmt.Errorf("cannot read body: %w", err)
+	}
+
+	return nil
+}
+
+// bodySHA256 returns hex encoded sha256 of the body.
+func bodySHA256(body io.ReadSeeker) (string, error) {
+	h := sha256.New()
+	if err := copyBody(h, body); err != nil {
+		return "", err
+	}
+
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
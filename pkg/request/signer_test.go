@@ -0,0 +1,196 @@
+package request
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSigner_Sign(t *testing.T) {
+	fixed := func() time.Time { return time.Date(2015, 8, 30, 12, 36, 0, 0, time.UTC) }
+
+	// aws signature v4 test suite credentials
+	sigV4 := &SigV4Signer{
+		AccessKey: "AKIDEXAMPLE",
+		SecretKey: "wJalrXUtnFEMI/K7MDENG+bPxRfiCYEXAMPLEKEY",
+		Region:    "us-east-1",
+		Service:   "service",
+		now:       fixed,
+	}
+
+	tests := []struct {
+		name   string
+		signer Signer
+		method string
+		url    string
+		body   string
+		header string
+		want   string
+	}{
+		{
+			name:   "basic",
+			signer: &BasicSigner{Username: "user", Password: "pass"},
+			method: http.MethodGet,
+			url:    "https://example.com/",
+			header: "Authorization",
+			want:   "Basic dXNlcjpwYXNz",
+		},
+		{
+			name:   "hmac body",
+			signer: &HMACSigner{Secret: []byte("secret"), Prefix: "sha256="},
+			method: http.MethodPost,
+			url:    "https://example.com/hook",
+			body:   "hello",
+			header: "X-Signature",
+			want:   "sha256=88aab3ede8d3adf94d26ab90d3bafd4a2083070c3bcce9c014ee04a443847c0b",
+		},
+		{
+			name: "hmac canonical",
+			signer: &HMACSigner{
+				Secret:          []byte("secret"),
+				Header:          "X-Sign",
+				Encoding:        "base64",
+				TimestampHeader: "X-Timestamp",
+				Canonical:       "{method}\n{path}\n{query}\n{header:X-Timestamp}\n{body}",
+				now:             fixed,
+			},
+			method: http.MethodPost,
+			url:    "https://example.com/api/items?page=2",
+			body:   "{}",
+			header: "X-Sign",
+			want:   "nbj5f6ml/00eVp/+M1WI7+JR1UG/ON2TsGGfe8HqIdQ=",
+		},
+		{
+			name:   "sigv4 get-vanilla",
+			signer: sigV4,
+			method: http.MethodGet,
+			url:    "https://example.amazonaws.com/",
+			header: "Authorization",
+			want:   "AWS4-HMAC-SHA256 Credential=AKIDEXAMPLE/20150830/us-east-1/service/aws4_request, SignedHeaders=host;x-amz-date, Signature=5fa00fa31553b73ebf1942676e86291e8372ff2a2260956d9b8aae1d763fbf31",
+		},
+		{
+			name:   "sigv4 get-vanilla-query-order-key-case",
+			signer: sigV4,
+			method: http.MethodGet,
+			url:    "https://example.amazonaws.com/?Param2=value2&Param1=value1",
+			header: "Authorization",
+			want:   "AWS4-HMAC-SHA256 Credential=AKIDEXAMPLE/20150830/us-east-1/service/aws4_request, SignedHeaders=host;x-amz-date, Signature=b97d918cfa904a5beff61c982a1b6f458b799221646efd99d3219ec94cdf2500",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req, err := http.NewRequest(tt.method, tt.url, nil) //nolint:noctx // test
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if err := tt.signer.Sign(req, []byte(tt.body)); err != nil {
+				t.Fatal(err)
+			}
+
+			if got := req.Header.Get(tt.header); got != tt.want {
+				t.Errorf("%s = %s, want %s", tt.header, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestClient_Signer(t *testing.T) {
+	signer := &SigV4Signer{
+		AccessKey:    "AKIDEXAMPLE",
+		SecretKey:    "secret",
+		SessionToken: "token",
+		Region:       "eu-west-1",
+		Service:      "s3",
+	}
+
+	// stub signs the received request again and compares
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+
+		check, _ := http.NewRequest(r.Method, "http://"+r.Host+r.URL.String(), nil) //nolint:noctx // test
+		for _, k := range []string{"Content-Type", "X-Amz-Security-Token"} {
+			check.Header[k] = r.Header[k]
+		}
+
+		amzDate, _ := time.Parse(sigV4TimeFormat, r.Header.Get("X-Amz-Date"))
+
+		stub := *signer
+		stub.now = func() time.Time { return amzDate }
+
+		if err := stub.Sign(check, body); err != nil {
+			t.Error(err)
+		}
+
+		if check.Header.Get("Authorization") != r.Header.Get("Authorization") ||
+			check.Header.Get("X-Amz-Content-Sha256") != r.Header.Get("X-Amz-Content-Sha256") {
+			w.WriteHeader(http.StatusForbidden)
+		}
+	}))
+	defer server.Close()
+
+	c, err := NewClient(Config{Signer: signer})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	resp, err := c.Call(context.Background(), server.URL+"/bucket/key.txt?versionId=a%20b~c", http.MethodPut,
+		map[string]interface{}{"Content-Type": "text/plain"}, []byte("data"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		t.Errorf("status = %d, want %d", resp.StatusCode, http.StatusOK)
+	}
+
+	// file body is signed without reading it to the memory
+	path := filepath.Join(t.TempDir(), "body")
+	if err := os.WriteFile(path, []byte("data"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	resp, err = c.CallFile(context.Background(), server.URL+"/bucket/key.txt", http.MethodPut,
+		map[string]interface{}{"Content-Type": "text/plain"}, path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		t.Errorf("file status = %d, want %d", resp.StatusCode, http.StatusOK)
+	}
+}
+
+func TestHMACSigner_SignFile(t *testing.T) {
+	signer := &HMACSigner{
+		Secret:    []byte("secret"),
+		Canonical: "{method}\n{body}\n{body_sha256}\n{body}",
+	}
+
+	// placeholder in the body is not replaced
+	body := `{"name":"{method}"}`
+	bodyHash := sha256.Sum256([]byte(body))
+
+	mac := hmac.New(sha256.New, []byte("secret"))
+	mac.Write([]byte("POST\n" + body + "\n" + hex.EncodeToString(bodyHash[:]) + "\n" + body))
+	want := hex.EncodeToString(mac.Sum(nil))
+
+	req, _ := http.NewRequest(http.MethodPost, "http://localhost/hook", nil) //nolint:noctx // test
+	if err := signer.SignFile(req, strings.NewReader(body)); err != nil {
+		t.Fatal(err)
+	}
+
+	if got := req.Header.Get("X-Signature"); got != want {
+		t.Errorf("SignFile() = %s, want %s", got, want)
+	}
+}
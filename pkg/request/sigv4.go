@@ -0,0 +1,175 @@
+package request
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+)
+
+const (
+	sigV4Algorithm  = "AWS4-HMAC-SHA256"
+	sigV4TimeFormat = "20060102T150405Z"
+)
+
+// SigV4Signer signs the request with AWS Signature Version 4.
+type SigV4Signer struct {
+	AccessKey    string
+	SecretKey    string
+	SessionToken string
+	Region       string
+	Service      string
+
+	now func() time.Time
+}
+
+func (s *SigV4Signer) Sign(req *http.Request, body []byte) error {
+	return s.SignFile(req, bytes.NewReader(body))
+}
+
+// SignFile signs with the hash of the body, body is not read to the memory.
+func (s *SigV4Signer) SignFile(req *http.Request, body io.ReadSeeker) error {
+	now := timeNow(s.now).UTC()
+	amzDate := now.Format(sigV4TimeFormat)
+	date := now.Format("20060102")
+
+	payloadHex, err := bodySHA256(body)
+	if err != nil {
+		return err
+	}
+
+	req.Header.Set("X-Amz-Date", amzDate)
+
+	if s.SessionToken != "" {
+		req.Header.Set("X-Amz-Security-Token", s.SessionToken)
+	}
+
+	// s3 requires the payload hash in the header
+	if s.Service == "s3" {
+		req.Header.Set("X-Amz-Content-Sha256", payloadHex)
+	}
+
+	signedHeaders, canonicalHeaders := sigV4Headers(req)
+
+	canonicalRequest := strings.Join([]string{
+		req.Method,
+		sigV4Path(req.URL),
+		sigV4Query(req.URL.Query()),
+		canonicalHeaders,
+		signedHeaders,
+		payloadHex,
+	}, "\n")
+
+	scope := strings.Join([]string{date, s.Region, s.Service, "aws4_request"}, "/")
+
+	canonicalHash := sha256.Sum256([]byte(canonicalRequest))
+	stringToSign := strings.Join([]string{
+		sigV4Algorithm,
+		amzDate,
+		scope,
+		hex.EncodeToString(canonicalHash[:]),
+	}, "\n")
+
+	key := hmacSHA256([]byte("AWS4"+s.SecretKey), date)
+	key = hmacSHA256(key, s.Region)
+	key = hmacSHA256(key, s.Service)
+	key = hmacSHA256(key, "aws4_request")
+
+	signature := hex.EncodeToString(hmacSHA256(key, stringToSign))
+
+	req.Header.Set("Authorization", sigV4Algorithm+
+		" Credential="+s.AccessKey+"/"+scope+
+		", SignedHeaders="+signedHeaders+
+		", Signature="+signature)
+
+	return nil
+}
+
+// sigV4Headers returns signed header names and canonical headers with host, content-type and x-amz-* headers.
+func sigV4Headers(req *http.Request) (string, string) {
+	host := req.Host
+	if host == "" {
+		host = req.URL.Host
+	}
+
+	values := map[string]string{"host": host}
+
+	for k, v := range req.Header {
+		name := strings.ToLower(k)
+		if name == "content-type" || strings.HasPrefix(name, "x-amz-") {
+			trimmed := make([]string, 0, len(v))
+			for _, item := range v {
+				trimmed = append(trimmed, strings.Join(strings.Fields(item), " "))
+			}
+
+			values[name] = strings.Join(trimmed, ",")
+		}
+	}
+
+	names := make([]string, 0, len(values))
+	for k := range values {
+		names = append(names, k)
+	}
+
+	sort.Strings(names)
+
+	var canonical strings.Builder
+	for _, name := range names {
+		canonical.WriteString(name + ":" + values[name] + "\n")
+	}
+
+	return strings.Join(names, ";"), canonical.String()
+}
+
+func sigV4Path(u *url.URL) string {
+	path := u.EscapedPath()
+	if path == "" {
+		return "/"
+	}
+
+	return path
+}
+
+// sigV4Query returns the query sorted by key and value with RFC 3986 encoding.
+func sigV4Query(query url.Values) string {
+	keys := make([]string, 0, len(query))
+	for k := range query {
+		keys = append(keys, k)
+	}
+
+	sort.Slice(keys, func(i, j int) bool { return sigV4Escape(keys[i]) < sigV4Escape(keys[j]) })
+
+	pairs := make([]string, 0, len(query))
+
+	for _, k := range keys {
+		values := make([]string, 0, len(query[k]))
+		for _, v := range query[k] {
+			values = append(values, sigV4Escape(v))
+		}
+
+		sort.Strings(values)
+
+		for _, v := range values {
+			pairs = append(pairs, sigV4Escape(k)+"="+v)
+		}
+	}
+
+	return strings.Join(pairs, "&")
+}
+
+func sigV4Escape(v string) string {
+	return strings.ReplaceAll(url.QueryEscape(v), "+", "%20")
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(data))
+
+	return mac.Sum(nil)
+}
@@ -0,0 +1,73 @@
+package request
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/hashicorp/go-cleanhttp"
+)
+
+// TLSConfig is the client certificate and root CAs of the server, values are PEM encoded.
+type TLSConfig struct {
+	// Cert and Key are the client certificate for mutual TLS.
+	Cert string `json:"cert"`
+	Key  string `json:"key"`
+	// CA is the bundle of root CAs, system roots are used when empty.
+	CA string `json:"ca"`
+	// ServerName overrides the name checked in the server certificate.
+	ServerName string `json:"server_name"`
+}
+
+// IsEmpty reports there is nothing to change in the default TLS settings.
+func (c TLSConfig) IsEmpty() bool {
+	return c.Cert == "" && c.Key == "" && c.CA == "" && c.ServerName == ""
+}
+
+// Config returns tls config of the client.
+func (c TLSConfig) Config() (*tls.Config, error) {
+	tlsConfig := &tls.Config{
+		MinVersion: tls.VersionTLS12,
+		ServerName: c.ServerName,
+	}
+
+	if c.Cert != "" || c.Key != "" {
+		if c.Cert == "" || c.Key == "" {
+			return nil, errors.New("tls client certificate needs both cert and key")
+		}
+
+		cert, err := tls.X509KeyPair([]byte(c.Cert), []byte(c.Key))
+		if err != nil {
+			return nil, fmt.Errorf("tls client certificate: %w", err)
+		}
+
+		tlsConfig.Certificates = []tls.Certificate{cert}
+	}
+
+	if c.CA != "" {
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM([]byte(c.CA)) {
+			return nil, errors.New("tls ca has no valid PEM certificate")
+		}
+
+		tlsConfig.RootCAs = pool
+	}
+
+	return tlsConfig, nil
+}
+
+// httpClient returns pooled client with the tls config.
+func (c TLSConfig) httpClient() (*http.Client, error) {
+	tlsConfig, err := c.Config()
+	if err != nil {
+		return nil, err
+	}
+
+	client := cleanhttp.DefaultPooledClient()
+	//nolint:forcetypeassert // clear
+	client.Transport.(*http.Transport).TLSClientConfig = tlsConfig
+
+	return client, nil
+}
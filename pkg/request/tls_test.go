@@ -0,0 +1,143 @@
+package request
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+// clientCert returns self signed client certificate and key as PEM.
+func clientCert(t *testing.T) (*x509.Certificate, string, string) {
+	t.Helper()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "chore"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+		IsCA:         true,
+
+		BasicConstraintsValid: true,
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return cert,
+		string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})),
+		string(pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER}))
+}
+
+func TestClient_TLS(t *testing.T) {
+	cert, certPEM, keyPEM := clientCert(t)
+
+	server := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(r.TLS.PeerCertificates[0].Subject.CommonName)) //nolint:errcheck // test
+	}))
+
+	clientCAs := x509.NewCertPool()
+	clientCAs.AddCert(cert)
+
+	server.TLS = &tls.Config{
+		ClientAuth: tls.RequireAndVerifyClientCert,
+		ClientCAs:  clientCAs,
+		MinVersion: tls.VersionTLS12,
+	}
+
+	server.StartTLS()
+	defer server.Close()
+
+	serverCA := string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: server.Certificate().Raw}))
+
+	tests := []struct {
+		name    string
+		tls     TLSConfig
+		want    string
+		wantErr bool
+	}{
+		{
+			name:    "unknown server CA",
+			tls:     TLSConfig{Cert: certPEM, Key: keyPEM},
+			wantErr: true,
+		},
+		{
+			name:    "missing client certificate",
+			tls:     TLSConfig{CA: serverCA},
+			wantErr: true,
+		},
+		{
+			name: "mutual TLS",
+			tls:  TLSConfig{Cert: certPEM, Key: keyPEM, CA: serverCA},
+			want: "chore",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c, err := NewClient(Config{TLS: tt.tls})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			resp, err := c.Call(context.Background(), server.URL, http.MethodGet, nil, nil)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Client.Call() error = %v, wantErr %v", err, tt.wantErr)
+			}
+
+			if err == nil && string(resp.Body) != tt.want {
+				t.Errorf("Client.Call() = %s, want %s", resp.Body, tt.want)
+			}
+		})
+	}
+}
+
+func TestTLSConfig_Config(t *testing.T) {
+	_, certPEM, _ := clientCert(t)
+
+	tests := []struct {
+		name    string
+		tls     TLSConfig
+		wantErr bool
+	}{
+		{name: "cert without key", tls: TLSConfig{Cert: certPEM}, wantErr: true},
+		{name: "invalid CA", tls: TLSConfig{CA: "not a certificate"}, wantErr: true},
+		{name: "CA only", tls: TLSConfig{CA: certPEM}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := tt.tls.Config(); (err != nil) != tt.wantErr {
+				t.Errorf("TLSConfig.Config() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
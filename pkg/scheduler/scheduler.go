@@ -0,0 +1,327 @@
+package scheduler
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/robfig/cron/v3"
+	"github.com/rs/zerolog/log"
+	"gorm.io/gorm/clause"
+
+	"github.com/rakunlabs/chore/pkg/flow"
+	"github.com/rakunlabs/chore/pkg/models"
+	"github.com/rakunlabs/chore/pkg/registry"
+)
+
+// Parser accepts standard cron with optional seconds and descriptors like @every 1h.
+var Parser = cron.NewParser(
+	cron.SecondOptional | cron.Minute | cron.Hour | cron.Dom | cron.Month | cron.Dow | cron.Descriptor,
+)
+
+// leaseName is the lease row of the scheduler, only the owner runs the schedules.
+const leaseName = "scheduler"
+
+var GlobalScheduler *Scheduler
+
+type Config struct {
+	// Lease of the running instance, other replicas take it after expired.
+	Lease time.Duration
+	// ReloadInterval reads the schedules again to get changes of other replicas.
+	ReloadInterval time.Duration
+}
+
+// Scheduler starts controls which have schedule nodes.
+//
+// All replicas load the schedules but only the lease owner runs them.
+type Scheduler struct {
+	cron     *cron.Cron
+	appStore *registry.Registry
+	ctx      context.Context //nolint:containedctx // application context
+	cfg      Config
+	wg       *sync.WaitGroup
+	entries  []cron.EntryID
+	mutex    sync.Mutex
+
+	owner      uuid.UUID
+	leaseUntil time.Time
+	leaseMutex sync.RWMutex
+}
+
+type job struct {
+	control string
+	nodeID  string
+	spec    string
+	payload []byte
+}
+
+func InitGlobalScheduler(ctx context.Context, appStore *registry.Registry, cfg Config) *Scheduler {
+	ctx = log.With().Str("component", "scheduler").Logger().WithContext(ctx)
+
+	if cfg.Lease <= 0 {
+		cfg.Lease = 30 * time.Second
+	}
+
+	if cfg.ReloadInterval <= 0 {
+		cfg.ReloadInterval = time.Minute
+	}
+
+	GlobalScheduler = &Scheduler{
+		cron:     cron.New(cron.WithParser(Parser)),
+		appStore: appStore,
+		ctx:      ctx,
+		cfg:      cfg,
+		owner:    uuid.New(),
+	}
+
+	return GlobalScheduler
+}
+
+// Start loads schedules and runs until application context done.
+func (s *Scheduler) Start(wg *sync.WaitGroup) {
+	s.wg = wg
+
+	s.extendLease(s.ctx)
+
+	if err := s.Reload(s.ctx); err != nil {
+		log.Ctx(s.ctx).Error().Err(err).Msg("cannot load schedules")
+	}
+
+	s.cron.Start()
+
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+
+		leaseTicker := time.NewTicker(s.cfg.Lease / 3)
+		defer leaseTicker.Stop()
+
+		reloadTicker := time.NewTicker(s.cfg.ReloadInterval)
+		defer reloadTicker.Stop()
+
+		for {
+			select {
+			case <-s.ctx.Done():
+				<-s.cron.Stop().Done()
+				s.releaseLease()
+
+				return
+			case <-leaseTicker.C:
+				s.extendLease(s.ctx)
+			case <-reloadTicker.C:
+				if err := s.Reload(s.ctx); err != nil {
+					log.Ctx(s.ctx).Error().Err(err).Msg("cannot reload schedules")
+				}
+			}
+		}
+	}()
+}
+
+// Leader reports this instance holds the lease and runs the schedules.
+func (s *Scheduler) Leader() bool {
+	s.leaseMutex.RLock()
+	defer s.leaseMutex.RUnlock()
+
+	return time.Now().Before(s.leaseUntil)
+}
+
+// extendLease takes the lease if it is free or expired, owner extends it.
+func (s *Scheduler) extendLease(ctx context.Context) {
+	now := time.Now()
+	lockedUntil := now.Add(s.cfg.Lease)
+
+	db := s.appStore.DB.WithContext(ctx)
+
+	result := db.Model(&models.Lease{}).
+		Where("name = ? AND (owner = ? OR locked_until < ?)", leaseName, s.owner, now).
+		Updates(map[string]interface{}{
+			"owner":        s.owner,
+			"locked_until": lockedUntil,
+		})
+
+	if result.Error == nil && result.RowsAffected == 0 {
+		result = db.Clauses(clause.OnConflict{DoNothing: true}).Create(&models.Lease{
+			Name:        leaseName,
+			Owner:       s.owner,
+			LockedUntil: lockedUntil,
+		})
+	}
+
+	if result.Error != nil {
+		if ctx.Err() == nil {
+			log.Ctx(ctx).Error().Err(result.Error).Msg("cannot extend scheduler lease")
+		}
+
+		// keep running until the current lease expires
+		return
+	}
+
+	leader := result.RowsAffected > 0
+
+	s.leaseMutex.Lock()
+	defer s.leaseMutex.Unlock()
+
+	if leader != now.Before(s.leaseUntil) {
+		if leader {
+			log.Ctx(ctx).Info().Msg("took scheduler lease, running schedules")
+		} else {
+			log.Ctx(ctx).Info().Msg("scheduler lease owned by other instance")
+		}
+	}
+
+	if leader {
+		s.leaseUntil = lockedUntil
+	} else {
+		s.leaseUntil = time.Time{}
+	}
+}
+
+// releaseLease lets other replicas take the lease without waiting expiration.
+func (s *Scheduler) releaseLease() {
+	s.leaseMutex.Lock()
+	s.leaseUntil = time.Time{}
+	s.leaseMutex.Unlock()
+
+	result := s.appStore.DB.Model(&models.Lease{}).
+		Where("name = ? AND owner = ?", leaseName, s.owner).
+		Update("locked_until", time.Now())
+	if result.Error != nil {
+		log.Ctx(s.ctx).Warn().Err(result.Error).Msg("cannot release scheduler lease")
+	}
+}
+
+// Reload reads all controls and replaces the schedules.
+func (s *Scheduler) Reload(ctx context.Context) error {
+	var controls []models.Control
+
+	result := s.appStore.DB.WithContext(ctx).Model(&models.Control{}).Select("name", "content").Find(&controls)
+	if result.Error != nil {
+		return fmt.Errorf("cannot get controls: %w", result.Error)
+	}
+
+	var jobs []job
+
+	for i := range controls {
+		controlJobs, err := controlSchedules(ctx, controls[i])
+		if err != nil {
+			log.Ctx(ctx).Warn().Err(err).Str("control", controls[i].Name).Msg("skipping schedules of control")
+
+			continue
+		}
+
+		jobs = append(jobs, controlJobs...)
+	}
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	for _, id := range s.entries {
+		s.cron.Remove(id)
+	}
+
+	s.entries = s.entries[:0]
+
+	for _, j := range jobs {
+		j := j
+
+		id, err := s.cron.AddFunc(j.spec, func() { s.run(j) })
+		if err != nil {
+			log.Ctx(ctx).Warn().Err(err).Str("control", j.control).Str("nodeID", j.nodeID).Msgf("invalid schedule %q", j.spec)
+
+			continue
+		}
+
+		s.entries = append(s.entries, id)
+	}
+
+	log.Ctx(ctx).Info().Msgf("loaded %d schedules", len(s.entries))
+
+	return nil
+}
+
+func (s *Scheduler) run(j job) {
+	ctx := log.Ctx(s.ctx).With().Str("control", j.control).Str("nodeID", j.nodeID).Logger().WithContext(s.ctx)
+
+	if !s.Leader() {
+		log.Ctx(ctx).Debug().Msg("skipping scheduled call, lease owned by other instance")
+
+		return
+	}
+
+	control := models.Control{}
+
+	result := s.appStore.DB.WithContext(ctx).Where("name = ?", j.control).First(&control)
+	if result.Error != nil {
+		log.Ctx(ctx).Error().Err(result.Error).Msg("cannot get control for schedule")
+
+		return
+	}
+
+	content, err := base64.StdEncoding.DecodeString(control.Content)
+	if err != nil {
+		log.Ctx(ctx).Error().Err(err).Msg("cannot decode control content")
+
+		return
+	}
+
+	log.Ctx(ctx).Info().Msg("scheduled call")
+
+	if _, err := flow.StartFlow(ctx, s.wg, control.Name, j.nodeID, flow.MethodSchedule, content, s.appStore, models.ResourceAccess(control.Groups.Groups), j.payload); err != nil {
+		log.Ctx(ctx).Error().Err(err).Msg("cannot start scheduled flow")
+	}
+}
+
+// controlSchedules returns schedule nodes' jobs of the control.
+func controlSchedules(ctx context.Context, control models.Control) ([]job, error) {
+	content, err := base64.StdEncoding.DecodeString(control.Content)
+	if err != nil {
+		return nil, fmt.Errorf("cannot decode content: %w", err)
+	}
+
+	if len(content) == 0 {
+		return nil, nil
+	}
+
+	nodesData, err := flow.ParseData(content)
+	if err != nil {
+		return nil, err
+	}
+
+	var jobs []job
+
+	for nodeID, data := range nodesData {
+		createFunc := flow.NodeTypes[data.Name]
+		if createFunc == nil {
+			continue
+		}
+
+		node, err := createFunc(ctx, nil, data, nodeID)
+		if err != nil {
+			return nil, err
+		}
+
+		nodeSchedule, ok := node.(flow.NoderSchedule)
+		if !ok {
+			continue
+		}
+
+		spec := nodeSchedule.Schedule()
+		if spec == "" {
+			log.Ctx(ctx).Warn().Str("control", control.Name).Str("nodeID", nodeID).Msg("schedule node has empty cron")
+
+			continue
+		}
+
+		jobs = append(jobs, job{
+			control: control.Name,
+			nodeID:  nodeSchedule.NodeID(),
+			spec:    spec,
+			payload: nodeSchedule.Payload(),
+		})
+	}
+
+	return jobs, nil
+}
@@ -0,0 +1,237 @@
+package scheduler
+
+import (
+	"context"
+	"encoding/base64"
+	"path/filepath"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+
+	"github.com/rakunlabs/chore/internal/store"
+	"github.com/rakunlabs/chore/pkg/flow"
+	"github.com/rakunlabs/chore/pkg/models"
+	"github.com/rakunlabs/chore/pkg/registry"
+)
+
+// testSchedule is a schedule node, nodes package imports the scheduler.
+type testSchedule struct {
+	flow.Noder
+	cron    string
+	payload string
+	nodeID  string
+}
+
+func (n *testSchedule) Schedule() string { return n.cron }
+func (n *testSchedule) Payload() []byte  { return []byte(n.payload) }
+func (n *testSchedule) NodeID() string   { return n.nodeID }
+
+// testNode is not a schedule node.
+type testNode struct {
+	flow.Noder
+}
+
+//nolint:gochecknoinits // test nodes
+func init() {
+	flow.NodeTypes["testSchedule"] = func(_ context.Context, _ *flow.NodesReg, data flow.NodeData, nodeID string) (flow.Noder, error) {
+		cron, _ := data.Data["cron"].(string)
+		payload, _ := data.Data["payload"].(string)
+
+		return &testSchedule{cron: cron, payload: payload, nodeID: nodeID}, nil
+	}
+
+	flow.NodeTypes["testNode"] = func(_ context.Context, _ *flow.NodesReg, _ flow.NodeData, _ string) (flow.Noder, error) {
+		return &testNode{}, nil
+	}
+}
+
+func testDB(t *testing.T) *gorm.DB {
+	t.Helper()
+
+	db, err := store.OpenConnection("sqlite", map[string]interface{}{
+		"fileName": filepath.Join(t.TempDir(), "chore.db"),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := db.AutoMigrate(store.Models...); err != nil {
+		t.Fatal(err)
+	}
+
+	return db
+}
+
+func TestSchedulerLease(t *testing.T) {
+	db := testDB(t)
+	ctx := context.Background()
+
+	cfg := Config{Lease: 100 * time.Millisecond}
+
+	s1 := InitGlobalScheduler(ctx, &registry.Registry{DB: db}, cfg)
+	s2 := InitGlobalScheduler(ctx, &registry.Registry{DB: db}, cfg)
+
+	s1.extendLease(ctx)
+	s2.extendLease(ctx)
+
+	if !s1.Leader() || s2.Leader() {
+		t.Fatalf("first instance should own the lease, got %v %v", s1.Leader(), s2.Leader())
+	}
+
+	// owner extends its lease
+	s1.extendLease(ctx)
+	s2.extendLease(ctx)
+
+	if !s1.Leader() || s2.Leader() {
+		t.Fatalf("owner should keep the lease, got %v %v", s1.Leader(), s2.Leader())
+	}
+
+	// owner stopped, lease expired
+	time.Sleep(cfg.Lease + 10*time.Millisecond)
+	s2.extendLease(ctx)
+
+	if s1.Leader() || !s2.Leader() {
+		t.Fatalf("second instance should take the expired lease, got %v %v", s1.Leader(), s2.Leader())
+	}
+
+	s1.extendLease(ctx)
+	if s1.Leader() {
+		t.Fatal("old owner should not take the lease back")
+	}
+
+	// released lease taken without waiting
+	s2.releaseLease()
+	s1.extendLease(ctx)
+
+	if !s1.Leader() || s2.Leader() {
+		t.Fatalf("released lease should be taken, got %v %v", s1.Leader(), s2.Leader())
+	}
+}
+
+func testControl(name, content string) models.Control {
+	control := models.Control{ControlPureContent: models.ControlPureContent{
+		Content:     base64.StdEncoding.EncodeToString([]byte(content)),
+		ControlPure: models.ControlPure{Name: name},
+	}}
+	control.ID.ID = uuid.New()
+
+	return control
+}
+
+func TestControlSchedules(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		want    []job
+		wantErr bool
+	}{
+		{
+			name:    "empty",
+			content: "",
+		},
+		{
+			name: "schedules",
+			content: `{
+				"1": {"name": "testSchedule", "data": {"cron": "*/5 * * * *", "payload": "deepcore"}},
+				"2": {"name": "testSchedule", "data": {"cron": "CRON_TZ=Europe/Amsterdam 0 9 * * *"}},
+				"3": {"name": "testNode", "data": {}},
+				"4": {"name": "unknown", "data": {}}
+			}`,
+			want: []job{
+				{control: "test", nodeID: "1", spec: "*/5 * * * *", payload: []byte("deepcore")},
+				{control: "test", nodeID: "2", spec: "CRON_TZ=Europe/Amsterdam 0 9 * * *", payload: []byte{}},
+			},
+		},
+		{
+			name:    "empty cron skipped",
+			content: `{"1": {"name": "testSchedule", "data": {"cron": ""}}}`,
+		},
+		{
+			name:    "invalid content",
+			content: `{`,
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := controlSchedules(context.Background(), testControl("test", tt.content))
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("controlSchedules() error = %v, wantErr %v", err, tt.wantErr)
+			}
+
+			sort.Slice(got, func(i, j int) bool { return got[i].nodeID < got[j].nodeID })
+
+			if len(got) != len(tt.want) {
+				t.Fatalf("controlSchedules() = %v, want %v", got, tt.want)
+			}
+
+			for i := range got {
+				if got[i].control != tt.want[i].control || got[i].nodeID != tt.want[i].nodeID ||
+					got[i].spec != tt.want[i].spec || string(got[i].payload) != string(tt.want[i].payload) {
+					t.Errorf("controlSchedules()[%d] = %v, want %v", i, got[i], tt.want[i])
+				}
+			}
+		})
+	}
+}
+
+func TestReload(t *testing.T) {
+	db := testDB(t)
+	ctx := context.Background()
+
+	controls := []models.Control{
+		testControl("daily", `{
+			"1": {"name": "testSchedule", "data": {"cron": "CRON_TZ=Asia/Tokyo 0 9 * * *"}},
+			"2": {"name": "testSchedule", "data": {"cron": "not a cron"}}
+		}`),
+		testControl("broken", `{`),
+		testControl("none", `{"1": {"name": "testNode", "data": {}}}`),
+	}
+
+	for i := range controls {
+		if err := db.Create(&controls[i]).Error; err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	s := InitGlobalScheduler(ctx, &registry.Registry{DB: db}, Config{})
+
+	if err := s.Reload(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(s.entries) != 1 {
+		t.Fatalf("Reload() loaded %d schedules, want 1", len(s.entries))
+	}
+
+	// next run is 09:00 in the timezone of the spec
+	tokyo, err := time.LoadLocation("Asia/Tokyo")
+	if err != nil {
+		t.Skip("timezone data not found")
+	}
+
+	// 10:00 in Tokyo, 09:00 UTC not passed yet
+	from := time.Date(2024, 1, 1, 1, 0, 0, 0, time.UTC)
+	next := s.cron.Entry(s.entries[0]).Schedule.Next(from)
+
+	if want := time.Date(2024, 1, 2, 9, 0, 0, 0, tokyo); !next.Equal(want) {
+		t.Errorf("next run = %v, want %v", next, want)
+	}
+
+	// removed control's schedules dropped
+	if err := db.Where("name = ?", "daily").Delete(&models.Control{}).Error; err != nil {
+		t.Fatal(err)
+	}
+
+	if err := s.Reload(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(s.entries) != 0 || len(s.cron.Entries()) != 0 {
+		t.Fatalf("Reload() kept %d schedules, want 0", len(s.cron.Entries()))
+	}
+}
@@ -0,0 +1,281 @@
+package sec
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var (
+	ErrSignature        = errors.New("signature not valid")
+	ErrSignatureMissing = errors.New("signature header not found")
+	ErrTimestamp        = errors.New("timestamp not in tolerance")
+)
+
+// DefaultTolerance is the max age of the signed timestamp.
+var DefaultTolerance = 5 * time.Minute
+
+const (
+	// SignatureHMAC compares HMAC-SHA256 of the payload.
+	SignatureHMAC = "hmac"
+	// SignatureToken compares the header with the secret directly, like GitLab.
+	SignatureToken = "token"
+
+	EncodingHex    = "hex"
+	EncodingBase64 = "base64"
+)
+
+// Signature holds the verification of incoming webhooks.
+type Signature struct {
+	// Preset fills empty values with github, gitlab, stripe or slack style.
+	Preset string
+	// Type is hmac (default) or token.
+	Type   string
+	Secret []byte
+	// Header holds the signature.
+	Header string
+	// Prefix is removed from the signature value, like sha256=
+	Prefix string
+	// Encoding of the signature, hex (default) or base64.
+	Encoding string
+	// TimestampHeader enables replay protection with the timestamp seconds in the header.
+	TimestampHeader string
+	// Payload is the signed content, {timestamp} and {body} are replaced, default is {body}.
+	Payload string
+	// Tolerance is the max age of the timestamp, default is DefaultTolerance.
+	Tolerance time.Duration
+}
+
+var presets = map[string]Signature{
+	"github": {
+		Type:   SignatureHMAC,
+		Header: "X-Hub-Signature-256",
+		Prefix: "sha256=",
+	},
+	"gitlab": {
+		Type:   SignatureToken,
+		Header: "X-Gitlab-Token",
+	},
+	// stripe header has own format, t=<timestamp>,v1=<signature>
+	"stripe": {
+		Type:    SignatureHMAC,
+		Header:  "Stripe-Signature",
+		Payload: "{timestamp}.{body}",
+	},
+	"slack": {
+		Type:            SignatureHMAC,
+		Header:          "X-Slack-Signature",
+		Prefix:          "v0=",
+		TimestampHeader: "X-Slack-Request-Timestamp",
+		Payload:         "v0:{timestamp}:{body}",
+	},
+}
+
+// IsPreset reports the name is a known preset.
+func IsPreset(name string) bool {
+	_, ok := presets[name]
+
+	return ok
+}
+
+func (s Signature) fill() (Signature, error) {
+	if s.Preset != "" {
+		preset, ok := presets[s.Preset]
+		if !ok {
+			return s, fmt.Errorf("unknown signature preset %q", s.Preset)
+		}
+
+		if s.Type == "" {
+			s.Type = preset.Type
+		}
+
+		if s.Header == "" {
+			s.Header = preset.Header
+		}
+
+		if s.Prefix == "" {
+			s.Prefix = preset.Prefix
+		}
+
+		if s.TimestampHeader == "" {
+			s.TimestampHeader = preset.TimestampHeader
+		}
+
+		if s.Payload == "" {
+			s.Payload = preset.Payload
+		}
+	}
+
+	if s.Type == "" {
+		s.Type = SignatureHMAC
+	}
+
+	if s.Encoding == "" {
+		s.Encoding = EncodingHex
+	}
+
+	if s.Payload == "" {
+		s.Payload = "{body}"
+	}
+
+	if s.Tolerance <= 0 {
+		s.Tolerance = DefaultTolerance
+	}
+
+	if s.Header == "" {
+		return s, fmt.Errorf("signature header is empty")
+	}
+
+	if len(s.Secret) == 0 {
+		return s, fmt.Errorf("signature secret is empty")
+	}
+
+	return s, nil
+}
+
+// Verify checks the signature of the body in the headers.
+func (s Signature) Verify(header http.Header, body []byte, now time.Time) error {
+	s, err := s.fill()
+	if err != nil {
+		return err
+	}
+
+	value := header.Get(s.Header)
+	if value == "" {
+		return ErrSignatureMissing
+	}
+
+	if s.Type == SignatureToken {
+		if subtle.ConstantTimeCompare([]byte(value), s.Secret) != 1 {
+			return ErrSignature
+		}
+
+		return nil
+	}
+
+	if s.Type != SignatureHMAC {
+		return fmt.Errorf("unknown signature type %q", s.Type)
+	}
+
+	var timestamp string
+	signatures := []string{strings.TrimPrefix(value, s.Prefix)}
+
+	if s.Preset == "stripe" {
+		timestamp, signatures = parseStripe(value)
+	} else if s.TimestampHeader != "" {
+		timestamp = header.Get(s.TimestampHeader)
+		if timestamp == "" {
+			return ErrTimestamp
+		}
+	}
+
+	if s.TimestampHeader != "" || s.Preset == "stripe" || strings.Contains(s.Payload, "{timestamp}") {
+		if err := checkTimestamp(timestamp, now, s.Tolerance); err != nil {
+			return err
+		}
+	}
+
+	payload := strings.NewReplacer("{timestamp}", timestamp, "{body}", string(body)).Replace(s.Payload)
+
+	mac := hmac.New(sha256.New, s.Secret)
+	mac.Write([]byte(payload))
+	expected := mac.Sum(nil)
+
+	for _, signature := range signatures {
+		got, err := decode(signature, s.Encoding)
+		if err != nil {
+			continue
+		}
+
+		if hmac.Equal(got, expected) {
+			return nil
+		}
+	}
+
+	return ErrSignature
+}
+
+// Sign returns the signature value of the body with prefix, mostly for tests and clients.
+func (s Signature) Sign(body []byte, timestamp string) (string, error) {
+	s, err := s.fill()
+	if err != nil {
+		return "", err
+	}
+
+	if s.Type == SignatureToken {
+		return string(s.Secret), nil
+	}
+
+	payload := strings.NewReplacer("{timestamp}", timestamp, "{body}", string(body)).Replace(s.Payload)
+
+	mac := hmac.New(sha256.New, s.Secret)
+	mac.Write([]byte(payload))
+
+	signature := hex.EncodeToString(mac.Sum(nil))
+	if s.Encoding == EncodingBase64 {
+		signature = base64.StdEncoding.EncodeToString(mac.Sum(nil))
+	}
+
+	if s.Preset == "stripe" {
+		return "t=" + timestamp + ",v1=" + signature, nil
+	}
+
+	return s.Prefix + signature, nil
+}
+
+// parseStripe returns timestamp and v1 signatures of the header.
+func parseStripe(value string) (string, []string) {
+	var timestamp string
+
+	var signatures []string
+
+	for _, part := range strings.Split(value, ",") {
+		key, v, ok := strings.Cut(strings.TrimSpace(part), "=")
+		if !ok {
+			continue
+		}
+
+		switch key {
+		case "t":
+			timestamp = v
+		case "v1":
+			signatures = append(signatures, v)
+		}
+	}
+
+	return timestamp, signatures
+}
+
+func checkTimestamp(timestamp string, now time.Time, tolerance time.Duration) error {
+	seconds, err := strconv.ParseInt(timestamp, 10, 64)
+	if err != nil {
+		return ErrTimestamp
+	}
+
+	diff := now.Sub(time.Unix(seconds, 0))
+	if diff < 0 {
+		diff = -diff
+	}
+
+	if diff > tolerance {
+		return ErrTimestamp
+	}
+
+	return nil
+}
+
+func decode(signature, encoding string) ([]byte, error) {
+	if encoding == EncodingBase64 {
+		return base64.StdEncoding.DecodeString(signature)
+	}
+
+	return hex.DecodeString(signature)
+}
@@ -0,0 +1,146 @@
+package sec
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"testing"
+	"time"
+)
+
+func TestSignatureVerify(t *testing.T) {
+	now := time.Unix(1700000000, 0)
+	ts := strconv.FormatInt(now.Unix(), 10)
+	old := strconv.FormatInt(now.Add(-time.Hour).Unix(), 10)
+	body := []byte(`{"action":"opened"}`)
+	secret := []byte("mysecret")
+
+	sign := func(s Signature, timestamp string) string {
+		v, err := s.Sign(body, timestamp)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		return v
+	}
+
+	github := Signature{Preset: "github", Secret: secret}
+	gitlab := Signature{Preset: "gitlab", Secret: secret}
+	stripe := Signature{Preset: "stripe", Secret: secret}
+	slack := Signature{Preset: "slack", Secret: secret}
+	custom := Signature{Secret: secret, Header: "X-Signature", Encoding: EncodingBase64}
+
+	tests := []struct {
+		name      string
+		signature Signature
+		header    map[string]string
+		body      []byte
+		wantErr   error
+	}{
+		{
+			name:      "github",
+			signature: github,
+			header:    map[string]string{"X-Hub-Signature-256": sign(github, "")},
+		},
+		{
+			name:      "github changed body",
+			signature: github,
+			header:    map[string]string{"X-Hub-Signature-256": sign(github, "")},
+			body:      []byte(`{"action":"closed"}`),
+			wantErr:   ErrSignature,
+		},
+		{
+			name:      "github missing header",
+			signature: github,
+			wantErr:   ErrSignatureMissing,
+		},
+		{
+			name:      "github other secret",
+			signature: github,
+			header:    map[string]string{"X-Hub-Signature-256": sign(Signature{Preset: "github", Secret: []byte("other")}, "")},
+			wantErr:   ErrSignature,
+		},
+		{
+			name:      "gitlab",
+			signature: gitlab,
+			header:    map[string]string{"X-Gitlab-Token": "mysecret"},
+		},
+		{
+			name:      "gitlab wrong token",
+			signature: gitlab,
+			header:    map[string]string{"X-Gitlab-Token": "mysecre"},
+			wantErr:   ErrSignature,
+		},
+		{
+			name:      "stripe",
+			signature: stripe,
+			header:    map[string]string{"Stripe-Signature": sign(stripe, ts) + ",v0=ignored"},
+		},
+		{
+			name:      "stripe replay",
+			signature: stripe,
+			header:    map[string]string{"Stripe-Signature": sign(stripe, old)},
+			wantErr:   ErrTimestamp,
+		},
+		{
+			name:      "slack",
+			signature: slack,
+			header:    map[string]string{"X-Slack-Signature": sign(slack, ts), "X-Slack-Request-Timestamp": ts},
+		},
+		{
+			name:      "slack changed timestamp",
+			signature: slack,
+			header:    map[string]string{"X-Slack-Signature": sign(slack, old), "X-Slack-Request-Timestamp": ts},
+			wantErr:   ErrSignature,
+		},
+		{
+			name:      "slack missing timestamp",
+			signature: slack,
+			header:    map[string]string{"X-Slack-Signature": sign(slack, ts)},
+			wantErr:   ErrTimestamp,
+		},
+		{
+			name:      "custom base64",
+			signature: custom,
+			header:    map[string]string{"X-Signature": sign(custom, "")},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			header := http.Header{}
+			for k, v := range tt.header {
+				header.Set(k, v)
+			}
+
+			b := body
+			if tt.body != nil {
+				b = tt.body
+			}
+
+			err := tt.signature.Verify(header, b, now)
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("Verify() error = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestSignatureConfig(t *testing.T) {
+	tests := []struct {
+		name      string
+		signature Signature
+	}{
+		{name: "unknown preset", signature: Signature{Preset: "bitbucket", Secret: []byte("x")}},
+		{name: "empty secret", signature: Signature{Preset: "github"}},
+		{name: "empty header", signature: Signature{Secret: []byte("x")}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.signature.Verify(http.Header{}, nil, time.Now()); err == nil {
+				t.Error("Verify() should return config error")
+			}
+		})
+	}
+}